@@ -1,25 +1,40 @@
 package app
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
 	"github.com/johannes-kuhfuss/probesvc/config"
 	"github.com/johannes-kuhfuss/probesvc/domain"
 	"github.com/johannes-kuhfuss/probesvc/handler"
 	"github.com/johannes-kuhfuss/probesvc/service"
 	"github.com/johannes-kuhfuss/services_utils/api_error"
 	"github.com/johannes-kuhfuss/services_utils/logger"
+	_ "github.com/lib/pq"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	_ "modernc.org/sqlite"
 )
 
 var (
-	router      *gin.Engine
-	jobHandler  handler.JobHandlers
-	azureClient *azblob.ServiceClient
-	jobService  service.JobService
-	fileService service.FileService
+	router       *gin.Engine
+	server       *http.Server
+	jobHandler   handler.JobHandlers
+	probeHandler handler.ProbeHandlers
+	azureClient  *azblob.ServiceClient
+	s3Client     *minio.Client
+	jobService   service.JobService
+	fileService  service.FileService
+	workerPool   service.WorkerPool
 )
 
 func connectToAzureBlob() (*azblob.ServiceClient, api_error.ApiErr) {
@@ -44,6 +59,24 @@ func connectToAzureBlob() (*azblob.ServiceClient, api_error.ApiErr) {
 	return &serviceClient, nil
 }
 
+func connectToS3() (*minio.Client, api_error.ApiErr) {
+	lookup := minio.BucketLookupAuto
+	if config.S3PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(config.S3Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(config.S3AccessKey, config.S3SecretKey, ""),
+		Secure:       config.S3UseSsl,
+		Region:       config.S3Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		logger.Error("Cannot access S3 storage - could not create client", err)
+		return nil, api_error.NewInternalServerError("Cannot access S3 storage - could not create client", err)
+	}
+	return client, nil
+}
+
 func initRouter() {
 	gin.SetMode(config.GinMode)
 	gin.DefaultWriter = logger.GetLogger()
@@ -52,20 +85,105 @@ func initRouter() {
 	router.Use(gin.Recovery())
 }
 
+func createJobRepository() (domain.JobRepository, api_error.ApiErr) {
+	switch config.JobRepository {
+	case "sqlite":
+		db, err := sqlx.Open("sqlite", config.DbDsn)
+		if err != nil {
+			logger.Error("Cannot open job database", err)
+			return nil, api_error.NewInternalServerError("Cannot open job database", err)
+		}
+		db.SetMaxOpenConns(1)
+		return domain.NewJobRepositorySql(db)
+	case "postgres":
+		db, err := sqlx.Open("postgres", config.DbDsn)
+		if err != nil {
+			logger.Error("Cannot open job database", err)
+			return nil, api_error.NewInternalServerError("Cannot open job database", err)
+		}
+		return domain.NewJobRepositorySql(db)
+	default:
+		return domain.NewJobRepositoryMem(), nil
+	}
+}
+
 func wireApp() {
-	customerRepo := domain.NewJobRepositoryMem()
-	jobService = service.NewJobService(customerRepo)
+	jobRepo, err := createJobRepository()
+	if err != nil {
+		panic(err)
+	}
+	jobService = service.NewJobService(jobRepo)
 	jobHandler = handler.JobHandlers{Service: jobService}
+	defaultFileService := service.NewFileService(createFileRepositories(), jobService, createProbers()...)
+	fileService = defaultFileService
+	probeHandler = handler.ProbeHandlers{Service: defaultFileService}
+	workerPool = service.NewWorkerPool(fileService, config.WorkerCount, workerName())
+}
+
+func createProbers() []service.Prober {
+	var ffprobe service.Prober = service.NewFfprobeProber(config.FfprobePath, config.ProbeProfiles)
+	if config.NativeProbe {
+		ffprobe = service.NewNativeProber(ffprobe)
+	}
+	probers := []service.Prober{ffprobe}
+	if config.MediaInfoPath != "" {
+		probers = append(probers, service.NewMediaInfoProber(config.MediaInfoPath))
+	}
+	return probers
+}
+
+func createFileRepositories() domain.FileRepositoryRegistry {
+	fileRepos := domain.NewFileRepositoryRegistry()
 	azureFileRepo := domain.NewFileRepositoryAzure(azureClient)
-	fileService = service.NewFileService(azureFileRepo, jobService)
+	if blobUrl, err := url.Parse(config.StorageBaseUrl); err == nil {
+		fileRepos.RegisterHost(blobUrl.Host, azureFileRepo)
+	}
+	fileRepos.RegisterScheme("az", azureFileRepo)
+	httpFileRepo := domain.NewFileRepositoryHttp(config.HttpHostHeaders, config.HttpRangeChunkSize)
+	fileRepos.RegisterScheme("http", httpFileRepo)
+	fileRepos.RegisterScheme("https", httpFileRepo)
+	if s3Client != nil {
+		fileRepos.RegisterScheme("s3", domain.NewFileRepositoryS3(s3Client))
+	}
+	if len(config.LocalAllowedRoots) > 0 {
+		fileRepos.RegisterScheme("file", domain.NewFileRepositoryLocal(config.LocalAllowedRoots))
+	}
+	return fileRepos
 }
 
 func startRouter() {
 	listenAddr := fmt.Sprintf("%s:%s", config.ServerAddr, config.ServerPort)
+	server = &http.Server{
+		Addr:    listenAddr,
+		Handler: router,
+	}
 	logger.Info(fmt.Sprintf("Listening on %v", listenAddr))
-	if err := router.Run(listenAddr); err != nil {
-		logger.Error("Error while starting router", err)
-		panic(err)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Error while starting router", err)
+			panic(err)
+		}
+	}()
+}
+
+func waitForShutdown() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-sigChan
+	logger.Info(fmt.Sprintf("Received signal %v, shutting down", sig))
+	signal.Stop(sigChan)
+	shutdown(time.Duration(config.ShutdownGraceTime) * time.Second)
+}
+
+func shutdown(grace time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Error("Error while shutting down router", err)
+	}
+	deadline, _ := ctx.Deadline()
+	if !workerPool.Stop(time.Until(deadline)) {
+		logger.Info("Unfinished jobs have been requeued")
 	}
 }
 
@@ -79,14 +197,29 @@ func StartApp() {
 	if err != nil {
 		panic(err)
 	}
+	if config.S3Endpoint != "" {
+		s3Client, err = connectToS3()
+		if err != nil {
+			panic(err)
+		}
+	}
 	initRouter()
 	wireApp()
 	mapUrls()
 	startProcessing()
 	startRouter()
+	waitForShutdown()
 	logger.Info("Application ended")
 }
 
+func workerName() string {
+	hostName, err := os.Hostname()
+	if err != nil {
+		return "probesvc"
+	}
+	return hostName
+}
+
 func startProcessing() {
-	go fileService.Run()
+	workerPool.Start()
 }
@@ -6,4 +6,16 @@ func mapUrls() {
 	router.POST("/jobs", jobHandler.CreateJob)
 	router.DELETE("jobs/:job_id", jobHandler.DeleteJobById)
 	router.GET("/jobs/next", jobHandler.GetNextJob)
+	router.POST("/jobs/:job_id/cancel", jobHandler.CancelJob)
+	router.POST("/jobs/:job_id/pause", jobHandler.PauseJob)
+	router.POST("/jobs/:job_id/resume", jobHandler.ResumeJob)
+	router.POST("/jobs/deadletter/retry", jobHandler.RetryDeadLetterJobs)
+	router.PATCH("/jobs/:job_id/status", jobHandler.SetStatus)
+	router.GET("/jobs/:job_id/result", jobHandler.GetResult)
+	router.PUT("/jobs/:job_id/result", jobHandler.SetResult)
+	router.POST("/probe", probeHandler.Probe)
+	router.POST("/probe/upload", probeHandler.ProbeUpload)
+	router.GET("/processing", jobHandler.GetProcessingStatus)
+	router.POST("/processing/pause", jobHandler.PauseProcessing)
+	router.POST("/processing/resume", jobHandler.ResumeProcessing)
 }
@@ -1,8 +1,14 @@
 package config
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -14,6 +20,21 @@ const (
 	EnvFile = ".env"
 )
 
+var (
+	probeProfileName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+	// probeProfileOptions lists the ffprobe options a probe profile may use,
+	// with the pattern their value has to match, or nil for plain flags.
+	probeProfileOptions = map[string]*regexp.Regexp{
+		"-show_chapters":   nil,
+		"-show_programs":   nil,
+		"-count_frames":    nil,
+		"-count_packets":   nil,
+		"-show_entries":    regexp.MustCompile(`^[a-z_]+(=[a-z_,]*)?(:[a-z_]+(=[a-z_,]*)?)*$`),
+		"-analyzeduration": regexp.MustCompile(`^[0-9]+[KMG]?$`),
+		"-probesize":       regexp.MustCompile(`^[0-9]+[KMG]?$`),
+	}
+)
+
 var (
 	GinMode            string
 	ServerAddr         string
@@ -21,9 +42,35 @@ var (
 	StorageAccountName string
 	StorageAccountKey  string
 	StorageBaseUrl     string
-	Shutdown           bool = false
-	NoJobWaitTime      int  = 10
+	NoJobWaitTime      int   = 10
+	ShutdownGraceTime  int   = 30
+	JobTimeout         int   = 3600
+	CancelPollInterval int   = 5
+	ProbeTimeout       int   = 30
+	ProbeMaxSize       int64 = 1024 * 1024 * 1024
+	UploadMaxSize      int64 = 1024 * 1024 * 1024
+	RetryMaxAttempts   int   = 3
+	RetryBaseDelay     int   = 30
+	RetryMaxDelay      int   = 3600
 	FfprobePath        string
+	MediaInfoPath      string
+	NativeProbe        bool = false
+	ProbeProfiles      map[string][]string
+	SeekMode           string = "auto"
+	SpoolDir           string
+	SpoolMaxSize       int64 = 4 * 1024 * 1024 * 1024
+	LocalAllowedRoots  []string
+	HttpHostHeaders    map[string]map[string]string
+	HttpRangeChunkSize int64 = 8 * 1024 * 1024
+	S3Endpoint         string
+	S3AccessKey        string
+	S3SecretKey        string
+	S3Region           string
+	S3UseSsl           bool   = true
+	S3PathStyle        bool   = false
+	JobRepository      string = "memory"
+	DbDsn              string
+	WorkerCount        int = runtime.NumCPU()
 )
 
 func InitConfig(file string) error {
@@ -39,6 +86,51 @@ func InitConfig(file string) error {
 	}
 	configGin()
 	configServer()
+	configLocalStorage()
+	err = configHttp()
+	if err != nil {
+		return err
+	}
+	err = configS3()
+	if err != nil {
+		return err
+	}
+	err = configJobRepository()
+	if err != nil {
+		return err
+	}
+	err = configWorkers()
+	if err != nil {
+		return err
+	}
+	err = configShutdown()
+	if err != nil {
+		return err
+	}
+	err = configJobTimeout()
+	if err != nil {
+		return err
+	}
+	err = configCancelPollInterval()
+	if err != nil {
+		return err
+	}
+	err = configRetries()
+	if err != nil {
+		return err
+	}
+	err = configProbe()
+	if err != nil {
+		return err
+	}
+	err = configProbeProfiles()
+	if err != nil {
+		return err
+	}
+	err = configSeek()
+	if err != nil {
+		return err
+	}
 	logger.Info("Done initalizing configuration")
 	return nil
 }
@@ -79,6 +171,7 @@ func ffProbepathConfig() error {
 		logger.Error("environment variable \"FFPROBE_PATH\" not set. Cannot start", nil)
 		return errors.New("environment variable \"FFPROBE_PATH\" not set. Cannot start")
 	}
+	MediaInfoPath = strings.TrimSpace(os.Getenv("MEDIAINFO_PATH"))
 	return nil
 }
 
@@ -102,3 +195,285 @@ func configServer() {
 		ServerPort = "8080"
 	}
 }
+
+func configLocalStorage() {
+	LocalAllowedRoots = make([]string, 0)
+	roots, ok := os.LookupEnv("LOCAL_ALLOWED_ROOTS")
+	if !ok {
+		return
+	}
+	for _, root := range filepath.SplitList(roots) {
+		if strings.TrimSpace(root) != "" {
+			LocalAllowedRoots = append(LocalAllowedRoots, strings.TrimSpace(root))
+		}
+	}
+}
+
+func configHttp() error {
+	HttpHostHeaders = make(map[string]map[string]string)
+	hostHeaders, ok := os.LookupEnv("HTTP_HOST_HEADERS")
+	if ok && strings.TrimSpace(hostHeaders) != "" {
+		if err := json.Unmarshal([]byte(hostHeaders), &HttpHostHeaders); err != nil {
+			logger.Error("environment variable \"HTTP_HOST_HEADERS\" is not valid JSON. Cannot start", err)
+			return errors.New("environment variable \"HTTP_HOST_HEADERS\" is not valid JSON. Cannot start")
+		}
+	}
+	chunkSize, ok := os.LookupEnv("HTTP_RANGE_CHUNK_SIZE")
+	if ok {
+		size, err := strconv.ParseInt(chunkSize, 10, 64)
+		if err != nil || size < 0 {
+			logger.Error("environment variable \"HTTP_RANGE_CHUNK_SIZE\" is not a valid size. Cannot start", err)
+			return errors.New("environment variable \"HTTP_RANGE_CHUNK_SIZE\" is not a valid size. Cannot start")
+		}
+		HttpRangeChunkSize = size
+	}
+	return nil
+}
+
+func configS3() error {
+	var ok bool
+	S3Endpoint, ok = os.LookupEnv("S3_ENDPOINT")
+	if !ok || strings.TrimSpace(S3Endpoint) == "" {
+		S3Endpoint = ""
+		return nil
+	}
+	S3AccessKey, ok = os.LookupEnv("S3_ACCESS_KEY")
+	if !ok || strings.TrimSpace(S3AccessKey) == "" {
+		logger.Error("environment variable \"S3_ACCESS_KEY\" not set. Cannot start", nil)
+		return errors.New("environment variable \"S3_ACCESS_KEY\" not set. Cannot start")
+	}
+	S3SecretKey, ok = os.LookupEnv("S3_SECRET_KEY")
+	if !ok || strings.TrimSpace(S3SecretKey) == "" {
+		logger.Error("environment variable \"S3_SECRET_KEY\" not set. Cannot start", nil)
+		return errors.New("environment variable \"S3_SECRET_KEY\" not set. Cannot start")
+	}
+	S3Region, _ = os.LookupEnv("S3_REGION")
+	useSsl, ok := os.LookupEnv("S3_USE_SSL")
+	if ok {
+		S3UseSsl = !strings.EqualFold(strings.TrimSpace(useSsl), "false")
+	}
+	pathStyle, ok := os.LookupEnv("S3_PATH_STYLE")
+	if ok {
+		S3PathStyle = strings.EqualFold(strings.TrimSpace(pathStyle), "true")
+	}
+	return nil
+}
+
+func configJobRepository() error {
+	repoType, ok := os.LookupEnv("JOB_REPOSITORY")
+	if !ok || strings.TrimSpace(repoType) == "" {
+		repoType = "memory"
+	}
+	switch strings.ToLower(repoType) {
+	case "memory":
+		JobRepository = "memory"
+		DbDsn = ""
+	case "sqlite":
+		JobRepository = "sqlite"
+		DbDsn, ok = os.LookupEnv("DB_DSN")
+		if !ok || strings.TrimSpace(DbDsn) == "" {
+			DbDsn = "probesvc.db"
+		}
+	case "postgres":
+		JobRepository = "postgres"
+		DbDsn, ok = os.LookupEnv("DB_DSN")
+		if !ok || strings.TrimSpace(DbDsn) == "" {
+			logger.Error("environment variable \"DB_DSN\" not set. Cannot start", nil)
+			return errors.New("environment variable \"DB_DSN\" not set. Cannot start")
+		}
+	default:
+		logger.Error(fmt.Sprintf("environment variable \"JOB_REPOSITORY\" has unknown value %v. Cannot start", repoType), nil)
+		return fmt.Errorf("environment variable \"JOB_REPOSITORY\" has unknown value %v. Cannot start", repoType)
+	}
+	return nil
+}
+
+func configWorkers() error {
+	workerCount, ok := os.LookupEnv("WORKER_COUNT")
+	if !ok || strings.TrimSpace(workerCount) == "" || strings.EqualFold(strings.TrimSpace(workerCount), "auto") {
+		WorkerCount = runtime.NumCPU()
+		return nil
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(workerCount))
+	if err != nil || count < 1 {
+		logger.Error("environment variable \"WORKER_COUNT\" is not a valid worker count. Cannot start", err)
+		return errors.New("environment variable \"WORKER_COUNT\" is not a valid worker count. Cannot start")
+	}
+	WorkerCount = count
+	return nil
+}
+
+func configShutdown() error {
+	graceTime, ok := os.LookupEnv("SHUTDOWN_GRACE_TIME")
+	if !ok || strings.TrimSpace(graceTime) == "" {
+		return nil
+	}
+	seconds, err := strconv.Atoi(strings.TrimSpace(graceTime))
+	if err != nil || seconds < 0 {
+		logger.Error("environment variable \"SHUTDOWN_GRACE_TIME\" is not a valid number of seconds. Cannot start", err)
+		return errors.New("environment variable \"SHUTDOWN_GRACE_TIME\" is not a valid number of seconds. Cannot start")
+	}
+	ShutdownGraceTime = seconds
+	return nil
+}
+
+func configJobTimeout() error {
+	timeout, ok := os.LookupEnv("JOB_TIMEOUT")
+	if !ok || strings.TrimSpace(timeout) == "" {
+		return nil
+	}
+	seconds, err := strconv.Atoi(strings.TrimSpace(timeout))
+	if err != nil || seconds < 0 {
+		logger.Error("environment variable \"JOB_TIMEOUT\" is not a valid number of seconds. Cannot start", err)
+		return errors.New("environment variable \"JOB_TIMEOUT\" is not a valid number of seconds. Cannot start")
+	}
+	JobTimeout = seconds
+	return nil
+}
+
+func configCancelPollInterval() error {
+	interval, ok := os.LookupEnv("CANCEL_POLL_INTERVAL")
+	if !ok || strings.TrimSpace(interval) == "" {
+		return nil
+	}
+	seconds, err := strconv.Atoi(strings.TrimSpace(interval))
+	if err != nil || seconds < 1 {
+		logger.Error("environment variable \"CANCEL_POLL_INTERVAL\" is not a valid number of seconds. Cannot start", err)
+		return errors.New("environment variable \"CANCEL_POLL_INTERVAL\" is not a valid number of seconds. Cannot start")
+	}
+	CancelPollInterval = seconds
+	return nil
+}
+
+func configRetries() error {
+	settings := []struct {
+		name  string
+		value *int
+		min   int
+	}{
+		{"RETRY_MAX_ATTEMPTS", &RetryMaxAttempts, 1},
+		{"RETRY_BASE_DELAY", &RetryBaseDelay, 0},
+		{"RETRY_MAX_DELAY", &RetryMaxDelay, 0},
+	}
+	for _, setting := range settings {
+		value, ok := os.LookupEnv(setting.name)
+		if !ok || strings.TrimSpace(value) == "" {
+			continue
+		}
+		number, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || number < setting.min {
+			logger.Error(fmt.Sprintf("environment variable \"%v\" is not a valid number. Cannot start", setting.name), err)
+			return fmt.Errorf("environment variable \"%v\" is not a valid number. Cannot start", setting.name)
+		}
+		*setting.value = number
+	}
+	return nil
+}
+
+func configProbe() error {
+	timeout, ok := os.LookupEnv("PROBE_TIMEOUT")
+	if ok && strings.TrimSpace(timeout) != "" {
+		seconds, err := strconv.Atoi(strings.TrimSpace(timeout))
+		if err != nil || seconds < 1 {
+			logger.Error("environment variable \"PROBE_TIMEOUT\" is not a valid number of seconds. Cannot start", err)
+			return errors.New("environment variable \"PROBE_TIMEOUT\" is not a valid number of seconds. Cannot start")
+		}
+		ProbeTimeout = seconds
+	}
+	maxSize, ok := os.LookupEnv("PROBE_MAX_SIZE")
+	if ok && strings.TrimSpace(maxSize) != "" {
+		size, err := strconv.ParseInt(strings.TrimSpace(maxSize), 10, 64)
+		if err != nil || size < 0 {
+			logger.Error("environment variable \"PROBE_MAX_SIZE\" is not a valid size in bytes. Cannot start", err)
+			return errors.New("environment variable \"PROBE_MAX_SIZE\" is not a valid size in bytes. Cannot start")
+		}
+		ProbeMaxSize = size
+	}
+	uploadSize, ok := os.LookupEnv("UPLOAD_MAX_SIZE")
+	if ok && strings.TrimSpace(uploadSize) != "" {
+		size, err := strconv.ParseInt(strings.TrimSpace(uploadSize), 10, 64)
+		if err != nil || size < 1 {
+			logger.Error("environment variable \"UPLOAD_MAX_SIZE\" is not a valid size in bytes. Cannot start", err)
+			return errors.New("environment variable \"UPLOAD_MAX_SIZE\" is not a valid size in bytes. Cannot start")
+		}
+		UploadMaxSize = size
+	}
+	nativeProbe, ok := os.LookupEnv("NATIVE_PROBE")
+	if ok {
+		NativeProbe = strings.EqualFold(strings.TrimSpace(nativeProbe), "true")
+	}
+	return nil
+}
+
+func configProbeProfiles() error {
+	ProbeProfiles = make(map[string][]string)
+	profiles, ok := os.LookupEnv("PROBE_PROFILES")
+	if !ok || strings.TrimSpace(profiles) == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(profiles), &ProbeProfiles); err != nil {
+		ProbeProfiles = make(map[string][]string)
+		logger.Error("environment variable \"PROBE_PROFILES\" is not valid JSON. Cannot start", err)
+		return errors.New("environment variable \"PROBE_PROFILES\" is not valid JSON. Cannot start")
+	}
+	for name, args := range ProbeProfiles {
+		if err := validateProbeProfile(name, args); err != nil {
+			ProbeProfiles = make(map[string][]string)
+			logger.Error(fmt.Sprintf("environment variable \"PROBE_PROFILES\" has invalid profile %v. Cannot start", name), err)
+			return fmt.Errorf("environment variable \"PROBE_PROFILES\" has invalid profile %v: %v. Cannot start", name, err)
+		}
+	}
+	return nil
+}
+
+// configSeek sets how probers get at sources that have to be read out of
+// order: "stream" always pipes the source, "seekable" always lets the prober
+// seek, and "auto" only does so for containers that need it, for containers
+// that profit from it if the source can be read from any position, or after
+// streaming failed.
+func configSeek() error {
+	seekMode, ok := os.LookupEnv("SEEK_MODE")
+	if !ok || strings.TrimSpace(seekMode) == "" {
+		seekMode = "auto"
+	}
+	switch strings.ToLower(strings.TrimSpace(seekMode)) {
+	case "auto", "stream", "seekable":
+		SeekMode = strings.ToLower(strings.TrimSpace(seekMode))
+	default:
+		logger.Error(fmt.Sprintf("environment variable \"SEEK_MODE\" has unknown value %v. Cannot start", seekMode), nil)
+		return fmt.Errorf("environment variable \"SEEK_MODE\" has unknown value %v. Cannot start", seekMode)
+	}
+	SpoolDir = strings.TrimSpace(os.Getenv("SPOOL_DIR"))
+	spoolSize, ok := os.LookupEnv("SPOOL_MAX_SIZE")
+	if ok && strings.TrimSpace(spoolSize) != "" {
+		size, err := strconv.ParseInt(strings.TrimSpace(spoolSize), 10, 64)
+		if err != nil || size < 0 {
+			logger.Error("environment variable \"SPOOL_MAX_SIZE\" is not a valid size in bytes. Cannot start", err)
+			return errors.New("environment variable \"SPOOL_MAX_SIZE\" is not a valid size in bytes. Cannot start")
+		}
+		SpoolMaxSize = size
+	}
+	return nil
+}
+
+// validateProbeProfile makes sure a profile only passes whitelisted options
+// with well-formed values to ffprobe.
+func validateProbeProfile(name string, args []string) error {
+	if !probeProfileName.MatchString(name) {
+		return errors.New("name may only contain lower case letters, digits, - and _")
+	}
+	for i := 0; i < len(args); i++ {
+		pattern, ok := probeProfileOptions[args[i]]
+		if !ok {
+			return fmt.Errorf("option %v is not allowed", args[i])
+		}
+		if pattern == nil {
+			continue
+		}
+		if i+1 == len(args) || !pattern.MatchString(args[i+1]) {
+			return fmt.Errorf("option %v has no valid value", args[i])
+		}
+		i++
+	}
+	return nil
+}
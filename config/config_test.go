@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -54,6 +56,33 @@ func unsetEnvVars() {
 	os.Unsetenv("STORAGE_ACCOUNT_KEY")
 	os.Unsetenv("STORAGE_BASE_URL")
 	os.Unsetenv("FFPROBE_PATH")
+	os.Unsetenv("MEDIAINFO_PATH")
+	os.Unsetenv("LOCAL_ALLOWED_ROOTS")
+	os.Unsetenv("HTTP_HOST_HEADERS")
+	os.Unsetenv("HTTP_RANGE_CHUNK_SIZE")
+	os.Unsetenv("S3_ENDPOINT")
+	os.Unsetenv("S3_ACCESS_KEY")
+	os.Unsetenv("S3_SECRET_KEY")
+	os.Unsetenv("S3_REGION")
+	os.Unsetenv("S3_USE_SSL")
+	os.Unsetenv("S3_PATH_STYLE")
+	os.Unsetenv("JOB_REPOSITORY")
+	os.Unsetenv("DB_DSN")
+	os.Unsetenv("WORKER_COUNT")
+	os.Unsetenv("SHUTDOWN_GRACE_TIME")
+	os.Unsetenv("JOB_TIMEOUT")
+	os.Unsetenv("CANCEL_POLL_INTERVAL")
+	os.Unsetenv("RETRY_MAX_ATTEMPTS")
+	os.Unsetenv("RETRY_BASE_DELAY")
+	os.Unsetenv("RETRY_MAX_DELAY")
+	os.Unsetenv("PROBE_TIMEOUT")
+	os.Unsetenv("PROBE_MAX_SIZE")
+	os.Unsetenv("UPLOAD_MAX_SIZE")
+	os.Unsetenv("NATIVE_PROBE")
+	os.Unsetenv("PROBE_PROFILES")
+	os.Unsetenv("SEEK_MODE")
+	os.Unsetenv("SPOOL_DIR")
+	os.Unsetenv("SPOOL_MAX_SIZE")
 }
 
 func Test_loadConfig_NoEnvFile_Returns_Error(t *testing.T) {
@@ -174,3 +203,421 @@ func Test_InitConfig_ReturnsNoError(t *testing.T) {
 	assert.EqualValues(t, "storage_key", StorageAccountKey)
 	assert.EqualValues(t, "ffpath", FfprobePath)
 }
+
+func Test_configLocalStorage_NoEnvVar_SetsEmptyList(t *testing.T) {
+	configLocalStorage()
+
+	assert.NotNil(t, LocalAllowedRoots)
+	assert.EqualValues(t, 0, len(LocalAllowedRoots))
+}
+
+func Test_configLocalStorage_WithEnvVar_SetsRoots(t *testing.T) {
+	roots := strings.Join([]string{"/mnt/media", " ", "/mnt/archive"}, string(os.PathListSeparator))
+	os.Setenv("LOCAL_ALLOWED_ROOTS", roots)
+	defer unsetEnvVars()
+	configLocalStorage()
+
+	assert.EqualValues(t, []string{"/mnt/media", "/mnt/archive"}, LocalAllowedRoots)
+}
+
+func Test_configHttp_NoEnvVars_SetsDefaults(t *testing.T) {
+	err := configHttp()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 0, len(HttpHostHeaders))
+	assert.EqualValues(t, 8*1024*1024, HttpRangeChunkSize)
+}
+
+func Test_configHttp_InvalidHeaders_Returns_Error(t *testing.T) {
+	os.Setenv("HTTP_HOST_HEADERS", "not json")
+	defer unsetEnvVars()
+	err := configHttp()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"HTTP_HOST_HEADERS\" is not valid JSON. Cannot start", err.Error())
+}
+
+func Test_configHttp_InvalidChunkSize_Returns_Error(t *testing.T) {
+	os.Setenv("HTTP_RANGE_CHUNK_SIZE", "-5")
+	defer unsetEnvVars()
+	err := configHttp()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"HTTP_RANGE_CHUNK_SIZE\" is not a valid size. Cannot start", err.Error())
+}
+
+func Test_configHttp_WithEnvVars_SetsValues(t *testing.T) {
+	os.Setenv("HTTP_HOST_HEADERS", `{"partner.example.com": {"Authorization": "Bearer token"}}`)
+	os.Setenv("HTTP_RANGE_CHUNK_SIZE", "1024")
+	defer unsetEnvVars()
+	err := configHttp()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "Bearer token", HttpHostHeaders["partner.example.com"]["Authorization"])
+	assert.EqualValues(t, 1024, HttpRangeChunkSize)
+	HttpRangeChunkSize = 8 * 1024 * 1024
+}
+
+func Test_configS3_NoEndpoint_DisablesS3(t *testing.T) {
+	err := configS3()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "", S3Endpoint)
+}
+
+func Test_configS3_NoAccessKey_Returns_Error(t *testing.T) {
+	os.Setenv("S3_ENDPOINT", "minio:9000")
+	defer unsetEnvVars()
+	err := configS3()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"S3_ACCESS_KEY\" not set. Cannot start", err.Error())
+}
+
+func Test_configS3_NoSecretKey_Returns_Error(t *testing.T) {
+	os.Setenv("S3_ENDPOINT", "minio:9000")
+	os.Setenv("S3_ACCESS_KEY", "access")
+	defer unsetEnvVars()
+	err := configS3()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"S3_SECRET_KEY\" not set. Cannot start", err.Error())
+}
+
+func Test_configS3_WithEnvVars_SetsValues(t *testing.T) {
+	os.Setenv("S3_ENDPOINT", "minio:9000")
+	os.Setenv("S3_ACCESS_KEY", "access")
+	os.Setenv("S3_SECRET_KEY", "secret")
+	os.Setenv("S3_REGION", "eu-central-1")
+	os.Setenv("S3_USE_SSL", "false")
+	os.Setenv("S3_PATH_STYLE", "true")
+	defer unsetEnvVars()
+	err := configS3()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "minio:9000", S3Endpoint)
+	assert.EqualValues(t, "access", S3AccessKey)
+	assert.EqualValues(t, "secret", S3SecretKey)
+	assert.EqualValues(t, "eu-central-1", S3Region)
+	assert.False(t, S3UseSsl)
+	assert.True(t, S3PathStyle)
+	S3UseSsl = true
+	S3PathStyle = false
+}
+
+func Test_configJobRepository_NoEnvVars_SetsMemory(t *testing.T) {
+	err := configJobRepository()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "memory", JobRepository)
+	assert.EqualValues(t, "", DbDsn)
+}
+
+func Test_configJobRepository_UnknownType_Returns_Error(t *testing.T) {
+	os.Setenv("JOB_REPOSITORY", "oracle")
+	defer unsetEnvVars()
+	err := configJobRepository()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"JOB_REPOSITORY\" has unknown value oracle. Cannot start", err.Error())
+}
+
+func Test_configJobRepository_Sqlite_SetsDefaultDsn(t *testing.T) {
+	os.Setenv("JOB_REPOSITORY", "sqlite")
+	defer unsetEnvVars()
+	err := configJobRepository()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "sqlite", JobRepository)
+	assert.EqualValues(t, "probesvc.db", DbDsn)
+}
+
+func Test_configJobRepository_Sqlite_SetsDsn(t *testing.T) {
+	os.Setenv("JOB_REPOSITORY", "SQLite")
+	os.Setenv("DB_DSN", "/data/jobs.db")
+	defer unsetEnvVars()
+	err := configJobRepository()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "sqlite", JobRepository)
+	assert.EqualValues(t, "/data/jobs.db", DbDsn)
+}
+
+func Test_configJobRepository_PostgresNoDsn_Returns_Error(t *testing.T) {
+	os.Setenv("JOB_REPOSITORY", "postgres")
+	defer unsetEnvVars()
+	err := configJobRepository()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"DB_DSN\" not set. Cannot start", err.Error())
+}
+
+func Test_configJobRepository_Postgres_SetsDsn(t *testing.T) {
+	os.Setenv("JOB_REPOSITORY", "postgres")
+	os.Setenv("DB_DSN", "postgres://probesvc@db/probesvc")
+	defer unsetEnvVars()
+	err := configJobRepository()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "postgres", JobRepository)
+	assert.EqualValues(t, "postgres://probesvc@db/probesvc", DbDsn)
+}
+
+func Test_configWorkers_NoEnvVars_SetsNumCpu(t *testing.T) {
+	err := configWorkers()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, runtime.NumCPU(), WorkerCount)
+}
+
+func Test_configWorkers_Auto_SetsNumCpu(t *testing.T) {
+	os.Setenv("WORKER_COUNT", "auto")
+	defer unsetEnvVars()
+	err := configWorkers()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, runtime.NumCPU(), WorkerCount)
+}
+
+func Test_configWorkers_InvalidCount_Returns_Error(t *testing.T) {
+	os.Setenv("WORKER_COUNT", "0")
+	defer unsetEnvVars()
+	err := configWorkers()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"WORKER_COUNT\" is not a valid worker count. Cannot start", err.Error())
+}
+
+func Test_configWorkers_WithEnvVar_SetsCount(t *testing.T) {
+	os.Setenv("WORKER_COUNT", "4")
+	defer unsetEnvVars()
+	err := configWorkers()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 4, WorkerCount)
+}
+
+func Test_configShutdown_InvalidTime_Returns_Error(t *testing.T) {
+	os.Setenv("SHUTDOWN_GRACE_TIME", "soon")
+	defer unsetEnvVars()
+	err := configShutdown()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"SHUTDOWN_GRACE_TIME\" is not a valid number of seconds. Cannot start", err.Error())
+}
+
+func Test_configShutdown_WithEnvVar_SetsTime(t *testing.T) {
+	os.Setenv("SHUTDOWN_GRACE_TIME", "120")
+	defer unsetEnvVars()
+	err := configShutdown()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 120, ShutdownGraceTime)
+}
+
+func Test_configJobTimeout_InvalidTime_Returns_Error(t *testing.T) {
+	os.Setenv("JOB_TIMEOUT", "-1")
+	defer unsetEnvVars()
+	err := configJobTimeout()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"JOB_TIMEOUT\" is not a valid number of seconds. Cannot start", err.Error())
+}
+
+func Test_configJobTimeout_WithEnvVar_SetsTimeout(t *testing.T) {
+	os.Setenv("JOB_TIMEOUT", "600")
+	defer unsetEnvVars()
+	err := configJobTimeout()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 600, JobTimeout)
+}
+
+func Test_configCancelPollInterval_InvalidInterval_Returns_Error(t *testing.T) {
+	os.Setenv("CANCEL_POLL_INTERVAL", "0")
+	defer unsetEnvVars()
+	err := configCancelPollInterval()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"CANCEL_POLL_INTERVAL\" is not a valid number of seconds. Cannot start", err.Error())
+}
+
+func Test_configCancelPollInterval_WithEnvVar_SetsInterval(t *testing.T) {
+	os.Setenv("CANCEL_POLL_INTERVAL", "10")
+	defer unsetEnvVars()
+	defer func() { CancelPollInterval = 5 }()
+	err := configCancelPollInterval()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 10, CancelPollInterval)
+}
+
+func Test_configRetries_InvalidAttempts_Returns_Error(t *testing.T) {
+	os.Setenv("RETRY_MAX_ATTEMPTS", "0")
+	defer unsetEnvVars()
+	err := configRetries()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"RETRY_MAX_ATTEMPTS\" is not a valid number. Cannot start", err.Error())
+}
+
+func Test_configRetries_WithEnvVars_SetsValues(t *testing.T) {
+	os.Setenv("RETRY_MAX_ATTEMPTS", "5")
+	os.Setenv("RETRY_BASE_DELAY", "10")
+	os.Setenv("RETRY_MAX_DELAY", "600")
+	defer unsetEnvVars()
+	err := configRetries()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 5, RetryMaxAttempts)
+	assert.EqualValues(t, 10, RetryBaseDelay)
+	assert.EqualValues(t, 600, RetryMaxDelay)
+}
+
+func Test_configProbe_InvalidTimeout_Returns_Error(t *testing.T) {
+	os.Setenv("PROBE_TIMEOUT", "0")
+	defer unsetEnvVars()
+	err := configProbe()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"PROBE_TIMEOUT\" is not a valid number of seconds. Cannot start", err.Error())
+}
+
+func Test_configProbe_InvalidMaxSize_Returns_Error(t *testing.T) {
+	os.Setenv("PROBE_MAX_SIZE", "1GB")
+	defer unsetEnvVars()
+	err := configProbe()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"PROBE_MAX_SIZE\" is not a valid size in bytes. Cannot start", err.Error())
+}
+
+func Test_configProbe_WithEnvVars_SetsValues(t *testing.T) {
+	os.Setenv("PROBE_TIMEOUT", "10")
+	os.Setenv("PROBE_MAX_SIZE", "1048576")
+	os.Setenv("UPLOAD_MAX_SIZE", "2097152")
+	os.Setenv("NATIVE_PROBE", "true")
+	defer unsetEnvVars()
+	defer func() { NativeProbe = false }()
+	err := configProbe()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 10, ProbeTimeout)
+	assert.EqualValues(t, 1048576, ProbeMaxSize)
+	assert.EqualValues(t, 2097152, UploadMaxSize)
+	assert.True(t, NativeProbe)
+}
+
+func Test_configProbe_InvalidUploadMaxSize_Returns_Error(t *testing.T) {
+	os.Setenv("UPLOAD_MAX_SIZE", "0")
+	defer unsetEnvVars()
+	err := configProbe()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"UPLOAD_MAX_SIZE\" is not a valid size in bytes. Cannot start", err.Error())
+}
+
+func Test_configProbeProfiles_NoEnvVar_Returns_NoProfiles(t *testing.T) {
+	err := configProbeProfiles()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 0, len(ProbeProfiles))
+}
+
+func Test_configProbeProfiles_WithEnvVar_SetsProfiles(t *testing.T) {
+	os.Setenv("PROBE_PROFILES", `{"chapters": ["-show_chapters", "-show_programs"], "ts": ["-analyzeduration", "20M", "-probesize", "50000000"], "codecs": ["-show_entries", "stream=index,codec_name:format=duration"]}`)
+	defer unsetEnvVars()
+	err := configProbeProfiles()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 3, len(ProbeProfiles))
+	assert.EqualValues(t, []string{"-analyzeduration", "20M", "-probesize", "50000000"}, ProbeProfiles["ts"])
+}
+
+func Test_configProbeProfiles_InvalidJson_Returns_Error(t *testing.T) {
+	os.Setenv("PROBE_PROFILES", `["-show_chapters"]`)
+	defer unsetEnvVars()
+	err := configProbeProfiles()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"PROBE_PROFILES\" is not valid JSON. Cannot start", err.Error())
+}
+
+func Test_configProbeProfiles_OptionNotAllowed_Returns_Error(t *testing.T) {
+	os.Setenv("PROBE_PROFILES", `{"dump": ["-show_frames", "-o", "/etc/passwd"]}`)
+	defer unsetEnvVars()
+	err := configProbeProfiles()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"PROBE_PROFILES\" has invalid profile dump: option -show_frames is not allowed. Cannot start", err.Error())
+	assert.EqualValues(t, 0, len(ProbeProfiles))
+}
+
+func Test_configProbeProfiles_InvalidValue_Returns_Error(t *testing.T) {
+	os.Setenv("PROBE_PROFILES", `{"ts": ["-probesize", "-i"]}`)
+	defer unsetEnvVars()
+	err := configProbeProfiles()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"PROBE_PROFILES\" has invalid profile ts: option -probesize has no valid value. Cannot start", err.Error())
+}
+
+func Test_configProbeProfiles_MissingValue_Returns_Error(t *testing.T) {
+	os.Setenv("PROBE_PROFILES", `{"entries": ["-show_entries"]}`)
+	defer unsetEnvVars()
+	err := configProbeProfiles()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"PROBE_PROFILES\" has invalid profile entries: option -show_entries has no valid value. Cannot start", err.Error())
+}
+
+func Test_configProbeProfiles_InvalidName_Returns_Error(t *testing.T) {
+	os.Setenv("PROBE_PROFILES", `{"Chapters List": ["-show_chapters"]}`)
+	defer unsetEnvVars()
+	err := configProbeProfiles()
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "name may only contain lower case letters, digits, - and _")
+}
+
+func Test_configSeek_NoEnvVars_Sets_Defaults(t *testing.T) {
+	unsetEnvVars()
+	SpoolMaxSize = 4 * 1024 * 1024 * 1024
+	err := configSeek()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "auto", SeekMode)
+	assert.EqualValues(t, "", SpoolDir)
+	assert.EqualValues(t, 4*1024*1024*1024, SpoolMaxSize)
+}
+
+func Test_configSeek_WithEnvVars_Sets_Values(t *testing.T) {
+	os.Setenv("SEEK_MODE", "Seekable")
+	os.Setenv("SPOOL_DIR", "/spool")
+	os.Setenv("SPOOL_MAX_SIZE", "0")
+	defer unsetEnvVars()
+	err := configSeek()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "seekable", SeekMode)
+	assert.EqualValues(t, "/spool", SpoolDir)
+	assert.EqualValues(t, 0, SpoolMaxSize)
+}
+
+func Test_configSeek_UnknownMode_Returns_Error(t *testing.T) {
+	os.Setenv("SEEK_MODE", "random")
+	defer unsetEnvVars()
+	err := configSeek()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"SEEK_MODE\" has unknown value random. Cannot start", err.Error())
+}
+
+func Test_configSeek_InvalidSpoolSize_Returns_Error(t *testing.T) {
+	os.Setenv("SPOOL_MAX_SIZE", "big")
+	defer unsetEnvVars()
+	err := configSeek()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "environment variable \"SPOOL_MAX_SIZE\" is not a valid size in bytes. Cannot start", err.Error())
+}
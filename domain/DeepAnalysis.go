@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/johannes-kuhfuss/probesvc/dto"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+// DeepAnalysis holds the statistics a deep job gathers from every packet and
+// frame of the source, per stream.
+type DeepAnalysis struct {
+	Streams []StreamAnalysis `json:"streams"`
+}
+
+type StreamAnalysis struct {
+	Index      int              `json:"index"`
+	CodecType  string           `json:"codec_type"`
+	Packets    int64            `json:"packets"`
+	Bytes      int64            `json:"bytes"`
+	Frames     int64            `json:"frames"`
+	KeyFrames  int64            `json:"key_frames"`
+	FrameTypes map[string]int64 `json:"frame_types,omitempty"`
+	Gop        *GopAnalysis     `json:"gop,omitempty"`
+	AvgBitrate int64            `json:"avg_bitrate"`
+	MaxBitrate int64            `json:"max_bitrate"`
+	// BitrateSeries holds the bits per second for each second of the stream.
+	BitrateSeries []int64 `json:"bitrate_series"`
+}
+
+// GopAnalysis describes the groups of pictures of a video stream, in frames,
+// and the distance between their key frames, in seconds.
+type GopAnalysis struct {
+	Count               int64   `json:"count"`
+	MinLength           int64   `json:"min_length"`
+	MaxLength           int64   `json:"max_length"`
+	AvgLength           float64 `json:"avg_length"`
+	MinKeyFrameInterval float64 `json:"min_key_frame_interval"`
+	MaxKeyFrameInterval float64 `json:"max_key_frame_interval"`
+	AvgKeyFrameInterval float64 `json:"avg_key_frame_interval"`
+}
+
+func ParseDeepAnalysis(data string) (*DeepAnalysis, api_error.ApiErr) {
+	var analysis DeepAnalysis
+	if err := json.Unmarshal([]byte(data), &analysis); err != nil {
+		return nil, api_error.NewValidationError(fmt.Sprintf("result is not a valid deep analysis: %v", err))
+	}
+	return &analysis, nil
+}
+
+func (a DeepAnalysis) Value() (driver.Value, error) {
+	return jsonValue(a)
+}
+
+func (a *DeepAnalysis) Scan(src interface{}) error {
+	return jsonScan(src, a)
+}
+
+func (a DeepAnalysis) ToDto() dto.DeepAnalysisResponse {
+	streams := make([]dto.StreamAnalysisResponse, 0, len(a.Streams))
+	for _, stream := range a.Streams {
+		response := dto.StreamAnalysisResponse{
+			Index:         stream.Index,
+			CodecType:     stream.CodecType,
+			Packets:       stream.Packets,
+			Bytes:         stream.Bytes,
+			Frames:        stream.Frames,
+			KeyFrames:     stream.KeyFrames,
+			FrameTypes:    stream.FrameTypes,
+			AvgBitrate:    stream.AvgBitrate,
+			MaxBitrate:    stream.MaxBitrate,
+			BitrateSeries: stream.BitrateSeries,
+		}
+		if stream.Gop != nil {
+			response.Gop = &dto.GopAnalysisResponse{
+				Count:               stream.Gop.Count,
+				MinLength:           stream.Gop.MinLength,
+				MaxLength:           stream.Gop.MaxLength,
+				AvgLength:           stream.Gop.AvgLength,
+				MinKeyFrameInterval: stream.Gop.MinKeyFrameInterval,
+				MaxKeyFrameInterval: stream.Gop.MaxKeyFrameInterval,
+				AvgKeyFrameInterval: stream.Gop.AvgKeyFrameInterval,
+			}
+		}
+		streams = append(streams, response)
+	}
+	return dto.DeepAnalysisResponse{Streams: streams}
+}
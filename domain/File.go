@@ -1,8 +1,19 @@
 package domain
 
-import "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+import (
+	"context"
+	"io"
+
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+type SourceFile struct {
+	Reader      io.ReadCloser
+	Size        int64
+	ContentType string
+}
 
 //go:generate mockgen -destination=../mocks/domain/mockFileRepository.go -package=domain github.com/johannes-kuhfuss/probesvc/domain FileRepository
 type FileRepository interface {
-	GetClient() *azblob.ServiceClient
+	GetReader(context.Context, string) (*SourceFile, api_error.ApiErr)
 }
@@ -1,13 +1,40 @@
 package domain
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/logger"
 )
 
 type FileRepositoryAzure struct {
 	serviceClient *azblob.ServiceClient
 }
 
+// azureRangeReader reads a blob from the start and downloads it again from
+// the new position after a seek.
+type azureRangeReader struct {
+	ctx    context.Context
+	blob   azblob.BlobClient
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+type azureBlobLocation struct {
+	container string
+	blob      string
+	snapshot  string
+	versionId string
+}
+
 func NewFileRepositoryAzure(client *azblob.ServiceClient) FileRepositoryAzure {
 	return FileRepositoryAzure{client}
 }
@@ -15,3 +42,157 @@ func NewFileRepositoryAzure(client *azblob.ServiceClient) FileRepositoryAzure {
 func (fra FileRepositoryAzure) GetClient() *azblob.ServiceClient {
 	return fra.serviceClient
 }
+
+func (fra FileRepositoryAzure) GetReader(ctx context.Context, srcUrl string) (*SourceFile, api_error.ApiErr) {
+	location, parseErr := parseAzureBlobUrl(srcUrl)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	container := fra.serviceClient.NewContainerClient(location.container)
+	blob := container.NewBlobClient(location.blob)
+	if location.snapshot != "" {
+		blob = blob.WithSnapshot(location.snapshot)
+	}
+	if location.versionId != "" {
+		blob = blob.WithVersionID(location.versionId).BlobClient
+	}
+
+	get, err := blob.Download(ctx, nil)
+	if err != nil {
+		logger.Error("Cannot access file on storage account", err)
+		return nil, azureError(srcUrl, err)
+	}
+	srcFile := SourceFile{
+		Reader: get.Body(azblob.RetryReaderOptions{}),
+		Size:   -1,
+	}
+	if get.ContentLength != nil {
+		srcFile.Size = *get.ContentLength
+		srcFile.Reader = &azureRangeReader{
+			ctx:  ctx,
+			blob: blob,
+			size: srcFile.Size,
+			body: srcFile.Reader,
+		}
+	}
+	if get.ContentType != nil {
+		srcFile.ContentType = *get.ContentType
+	}
+	return &srcFile, nil
+}
+
+func (arr *azureRangeReader) Read(p []byte) (int, error) {
+	if arr.offset >= arr.size {
+		return 0, io.EOF
+	}
+	if arr.body == nil {
+		offset := arr.offset
+		get, err := arr.blob.Download(arr.ctx, &azblob.DownloadBlobOptions{Offset: &offset})
+		if err != nil {
+			return 0, err
+		}
+		arr.body = get.Body(azblob.RetryReaderOptions{})
+	}
+	n, err := arr.body.Read(p)
+	arr.offset += int64(n)
+	if err == io.EOF && arr.offset < arr.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (arr *azureRangeReader) Seek(offset int64, whence int) (int64, error) {
+	var newOffset int64
+	switch whence {
+	case io.SeekStart:
+		newOffset = offset
+	case io.SeekCurrent:
+		newOffset = arr.offset + offset
+	case io.SeekEnd:
+		newOffset = arr.size + offset
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if newOffset < 0 {
+		return 0, fmt.Errorf("negative position %d", newOffset)
+	}
+	if newOffset != arr.offset && arr.body != nil {
+		arr.body.Close()
+		arr.body = nil
+	}
+	arr.offset = newOffset
+	return newOffset, nil
+}
+
+func (arr *azureRangeReader) Close() error {
+	if arr.body == nil {
+		return nil
+	}
+	err := arr.body.Close()
+	arr.body = nil
+	return err
+}
+
+// azureError classifies a failed download by the status the storage account
+// answered with. Errors without a response are transport failures.
+func azureError(srcUrl string, err error) api_error.ApiErr {
+	msg := fmt.Sprintf("Cannot access file %v on storage account", srcUrl)
+	status := 0
+	var storageErr *azblob.StorageError
+	var respErr azblob.ResponseError
+	switch {
+	case errors.As(err, &storageErr) && storageErr.Response() != nil:
+		status = storageErr.Response().StatusCode
+	case errors.As(err, &respErr) && respErr.RawResponse() != nil:
+		status = respErr.RawResponse().StatusCode
+	}
+	switch {
+	case status == http.StatusNotFound:
+		return api_error.NewNotFoundError(msg)
+	case status == http.StatusUnauthorized:
+		return api_error.NewUnauthenticatedError(msg)
+	case status == http.StatusForbidden:
+		return api_error.NewUnauthorizedError(msg)
+	case status == http.StatusBadRequest:
+		return api_error.NewBadRequestError(msg)
+	default:
+		return api_error.NewInternalServerError(msg, err)
+	}
+}
+
+func parseAzureBlobUrl(srcUrl string) (*azureBlobLocation, api_error.ApiErr) {
+	parsedUrl, err := url.Parse(srcUrl)
+	if err != nil {
+		return nil, api_error.NewBadRequestError(fmt.Sprintf("Cannot parse blob URL %v", srcUrl))
+	}
+	var container, blob string
+	switch strings.ToLower(parsedUrl.Scheme) {
+	case "az":
+		container = parsedUrl.Host
+		blob = strings.TrimPrefix(parsedUrl.Path, "/")
+	case "http", "https":
+		segments := strings.SplitN(strings.TrimPrefix(parsedUrl.Path, "/"), "/", 2)
+		container = segments[0]
+		if len(segments) == 2 {
+			blob = segments[1]
+		}
+	default:
+		return nil, api_error.NewBadRequestError(fmt.Sprintf("Cannot parse blob URL %v", srcUrl))
+	}
+	if container == "" || blob == "" || strings.HasSuffix(blob, "/") {
+		return nil, api_error.NewBadRequestError(fmt.Sprintf("Blob URL %v must contain container and blob name", srcUrl))
+	}
+	location := azureBlobLocation{
+		container: container,
+		blob:      blob,
+	}
+	for key, values := range parsedUrl.Query() {
+		switch strings.ToLower(key) {
+		case "snapshot":
+			location.snapshot = values[0]
+		case "versionid":
+			location.versionId = values[0]
+		}
+	}
+	return &location, nil
+}
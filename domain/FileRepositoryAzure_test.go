@@ -1,9 +1,17 @@
 package domain
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/johannes-kuhfuss/probesvc/config"
 	"github.com/johannes-kuhfuss/services_utils/api_error"
@@ -58,3 +66,184 @@ func Test_GetClient_Returns_AzureClient(t *testing.T) {
 	assert.NotNil(t, myClient)
 	assert.IsType(t, azureClient, myClient)
 }
+
+func Test_parseAzureBlobUrl(t *testing.T) {
+	tests := []struct {
+		name     string
+		srcUrl   string
+		expected *azureBlobLocation
+		errMsg   string
+	}{
+		{
+			name:     "blob in container root",
+			srcUrl:   "https://acct.blob.core.windows.net/media/ep1.mxf",
+			expected: &azureBlobLocation{container: "media", blob: "ep1.mxf"},
+		},
+		{
+			name:     "blob in nested virtual directories",
+			srcUrl:   "https://acct.blob.core.windows.net/media/2024/show/ep1.mxf",
+			expected: &azureBlobLocation{container: "media", blob: "2024/show/ep1.mxf"},
+		},
+		{
+			name:     "url encoded blob name",
+			srcUrl:   "https://acct.blob.core.windows.net/media/2024/my%20show/ep%231.mxf",
+			expected: &azureBlobLocation{container: "media", blob: "2024/my show/ep#1.mxf"},
+		},
+		{
+			name:     "snapshot",
+			srcUrl:   "https://acct.blob.core.windows.net/media/2024/ep1.mxf?snapshot=2024-01-01T00:00:00.0000000Z",
+			expected: &azureBlobLocation{container: "media", blob: "2024/ep1.mxf", snapshot: "2024-01-01T00:00:00.0000000Z"},
+		},
+		{
+			name:     "version id",
+			srcUrl:   "https://acct.blob.core.windows.net/media/2024/ep1.mxf?versionId=2024-01-01T00:00:00.0000000Z",
+			expected: &azureBlobLocation{container: "media", blob: "2024/ep1.mxf", versionId: "2024-01-01T00:00:00.0000000Z"},
+		},
+		{
+			name:     "sas token is ignored",
+			srcUrl:   "https://acct.blob.core.windows.net/media/ep1.mxf?sv=2020-08-04&sig=abc",
+			expected: &azureBlobLocation{container: "media", blob: "ep1.mxf"},
+		},
+		{
+			name:     "az shorthand",
+			srcUrl:   "az://media/2024/show/ep1.mxf",
+			expected: &azureBlobLocation{container: "media", blob: "2024/show/ep1.mxf"},
+		},
+		{
+			name:     "az shorthand with version id",
+			srcUrl:   "az://media/ep1.mxf?versionid=v1",
+			expected: &azureBlobLocation{container: "media", blob: "ep1.mxf", versionId: "v1"},
+		},
+		{
+			name:   "container only",
+			srcUrl: "https://acct.blob.core.windows.net/media",
+			errMsg: "Blob URL https://acct.blob.core.windows.net/media must contain container and blob name",
+		},
+		{
+			name:   "virtual directory",
+			srcUrl: "az://media/2024/show/",
+			errMsg: "Blob URL az://media/2024/show/ must contain container and blob name",
+		},
+		{
+			name:   "unsupported scheme",
+			srcUrl: "s3://media/ep1.mxf",
+			errMsg: "Cannot parse blob URL s3://media/ep1.mxf",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			location, err := parseAzureBlobUrl(tc.srcUrl)
+
+			if tc.errMsg != "" {
+				assert.Nil(t, location)
+				assert.NotNil(t, err)
+				assert.EqualValues(t, tc.errMsg, err.Message())
+				assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+			} else {
+				assert.Nil(t, err)
+				assert.EqualValues(t, tc.expected, location)
+			}
+		})
+	}
+}
+
+func Test_AzureGetReader_NestedBlob_Returns_Reader(t *testing.T) {
+	content := "azure blob content"
+	var requestedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+		w.Header().Set("ETag", "\"0x8D9\"")
+		w.Header().Set("Content-Type", "application/mxf")
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+	cred, _ := azblob.NewSharedKeyCredential("acct", "a2V5")
+	client, err := azblob.NewServiceClientWithSharedKey(server.URL, cred, nil)
+	assert.Nil(t, err)
+	repo := NewFileRepositoryAzure(&client)
+
+	srcFile, getErr := repo.GetReader(context.Background(), "az://media/2024/my%20show/ep1.mxf")
+
+	assert.Nil(t, getErr)
+	defer srcFile.Reader.Close()
+	data, _ := io.ReadAll(srcFile.Reader)
+	assert.EqualValues(t, "/media/2024/my show/ep1.mxf", requestedPath)
+	assert.EqualValues(t, content, string(data))
+	assert.EqualValues(t, len(content), srcFile.Size)
+	assert.EqualValues(t, "application/mxf", srcFile.ContentType)
+}
+
+func Test_AzureGetReader_Seek_Downloads_FromOffset(t *testing.T) {
+	content := "0123456789"
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("x-ms-range"))
+		r.Header.Set("Range", r.Header.Get("x-ms-range"))
+		w.Header().Set("ETag", "\"0x8D9\"")
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+	cred, _ := azblob.NewSharedKeyCredential("acct", "a2V5")
+	client, _ := azblob.NewServiceClientWithSharedKey(server.URL, cred, nil)
+	repo := NewFileRepositoryAzure(&client)
+
+	srcFile, getErr := repo.GetReader(context.Background(), "az://media/ep1.mxf")
+	assert.Nil(t, getErr)
+	defer srcFile.Reader.Close()
+	seeker, ok := srcFile.Reader.(io.ReadSeeker)
+	assert.True(t, ok)
+	seeker.Seek(6, io.SeekStart)
+	data, readErr := io.ReadAll(seeker)
+
+	assert.Nil(t, readErr)
+	assert.EqualValues(t, "6789", string(data))
+	assert.EqualValues(t, []string{"", "bytes=6-"}, ranges)
+}
+
+func azureStatusRepo(t *testing.T, status int, errorCode string) (FileRepositoryAzure, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-ms-error-code", errorCode)
+		w.WriteHeader(status)
+	}))
+	cred, _ := azblob.NewSharedKeyCredential("acct", "a2V5")
+	client, err := azblob.NewServiceClientWithSharedKey(server.URL, cred, &azblob.ClientOptions{Retry: policy.RetryOptions{MaxRetries: -1}})
+	assert.Nil(t, err)
+	return NewFileRepositoryAzure(&client), server.Close
+}
+
+func Test_AzureGetReader_ErrorStatus_Returns_MatchingError(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		errorCode string
+		expected  int
+	}{
+		{"missing blob", http.StatusNotFound, "BlobNotFound", http.StatusNotFound},
+		{"invalid credentials", http.StatusForbidden, "AuthenticationFailed", http.StatusForbidden},
+		{"server busy", http.StatusServiceUnavailable, "ServerBusy", http.StatusInternalServerError},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, teardown := azureStatusRepo(t, tc.status, tc.errorCode)
+			defer teardown()
+
+			srcFile, err := repo.GetReader(context.Background(), "az://media/ep1.mxf")
+
+			assert.Nil(t, srcFile)
+			assert.NotNil(t, err)
+			assert.EqualValues(t, tc.expected, err.StatusCode())
+		})
+	}
+}
+
+func Test_AzureGetReader_Unreachable_Returns_InternalServerError(t *testing.T) {
+	repo, teardown := azureStatusRepo(t, http.StatusOK, "")
+	teardown()
+
+	srcFile, err := repo.GetReader(context.Background(), "az://media/ep1.mxf")
+
+	assert.Nil(t, srcFile)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusInternalServerError, err.StatusCode())
+}
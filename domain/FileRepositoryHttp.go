@@ -0,0 +1,217 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+const (
+	maxHttpRedirects = 10
+)
+
+type FileRepositoryHttp struct {
+	client      *http.Client
+	hostHeaders map[string]map[string]string
+	chunkSize   int64
+}
+
+type httpRangeReader struct {
+	ctx      context.Context
+	repo     FileRepositoryHttp
+	url      string
+	size     int64
+	offset   int64
+	body     io.ReadCloser
+	bodyRead int64
+}
+
+func NewFileRepositoryHttp(hostHeaders map[string]map[string]string, chunkSize int64) FileRepositoryHttp {
+	headers := make(map[string]map[string]string)
+	for host, hostHeader := range hostHeaders {
+		headers[strings.ToLower(host)] = hostHeader
+	}
+	repo := FileRepositoryHttp{
+		hostHeaders: headers,
+		chunkSize:   chunkSize,
+	}
+	repo.client = &http.Client{
+		CheckRedirect: repo.checkRedirect,
+	}
+	return repo
+}
+
+func (frh FileRepositoryHttp) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxHttpRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxHttpRedirects)
+	}
+	// The client copies the headers of the first request to every redirect,
+	// so the headers of a host must be dropped when leaving it.
+	if !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+		for name := range frh.hostHeaders[strings.ToLower(via[0].URL.Hostname())] {
+			req.Header.Del(name)
+		}
+	}
+	frh.addHostHeaders(req)
+	return nil
+}
+
+func (frh FileRepositoryHttp) addHostHeaders(req *http.Request) {
+	for name, value := range frh.hostHeaders[strings.ToLower(req.URL.Hostname())] {
+		req.Header.Set(name, value)
+	}
+}
+
+func (frh FileRepositoryHttp) get(ctx context.Context, srcUrl string, from int64, to int64) (*http.Response, api_error.ApiErr) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srcUrl, nil)
+	if err != nil {
+		return nil, api_error.NewBadRequestError(fmt.Sprintf("Cannot parse source URL %v", srcUrl))
+	}
+	frh.addHostHeaders(req)
+	if frh.chunkSize > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", from, to))
+	}
+	resp, err := frh.client.Do(req)
+	if err != nil {
+		logger.Error("Cannot access file via http", err)
+		return nil, api_error.NewInternalServerError(fmt.Sprintf("Cannot access file %v", srcUrl), err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusOK, resp.StatusCode == http.StatusPartialContent:
+		return resp, nil
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && from == 0 && frh.chunkSize > 0:
+		// An empty file has no first byte to start the range at.
+		return resp, nil
+	}
+	resp.Body.Close()
+	msg := fmt.Sprintf("Cannot access file %v - server responded with %v", srcUrl, resp.Status)
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusGone:
+		return nil, api_error.NewNotFoundError(msg)
+	case http.StatusUnauthorized:
+		return nil, api_error.NewUnauthenticatedError(msg)
+	case http.StatusForbidden:
+		return nil, api_error.NewUnauthorizedError(msg)
+	default:
+		return nil, api_error.NewError(msg, resp.StatusCode, nil)
+	}
+}
+
+func (frh FileRepositoryHttp) GetReader(ctx context.Context, srcUrl string) (*SourceFile, api_error.ApiErr) {
+	resp, err := frh.get(ctx, srcUrl, 0, frh.chunkSize-1)
+	if err != nil {
+		return nil, err
+	}
+	srcFile := SourceFile{
+		Reader:      resp.Body,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return &srcFile, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		return &SourceFile{Reader: http.NoBody, Size: 0}, nil
+	}
+	size, parseErr := parseContentRangeSize(resp.Header.Get("Content-Range"))
+	if parseErr != nil {
+		resp.Body.Close()
+		logger.Error("Cannot parse Content-Range header", parseErr)
+		return nil, api_error.NewInternalServerError(fmt.Sprintf("Cannot access file %v", srcUrl), parseErr)
+	}
+	srcFile.Size = size
+	srcFile.Reader = &httpRangeReader{
+		ctx:  ctx,
+		repo: frh,
+		url:  resp.Request.URL.String(),
+		size: size,
+		body: resp.Body,
+	}
+	return &srcFile, nil
+}
+
+func parseContentRangeSize(contentRange string) (int64, error) {
+	idx := strings.LastIndex(contentRange, "/")
+	if !strings.HasPrefix(contentRange, "bytes ") || idx < 0 {
+		return 0, fmt.Errorf("invalid Content-Range %q", contentRange)
+	}
+	return strconv.ParseInt(contentRange[idx+1:], 10, 64)
+}
+
+func (hrr *httpRangeReader) Read(p []byte) (int, error) {
+	for {
+		if hrr.offset >= hrr.size {
+			return 0, io.EOF
+		}
+		if hrr.body == nil {
+			to := hrr.offset + hrr.repo.chunkSize - 1
+			if to >= hrr.size {
+				to = hrr.size - 1
+			}
+			resp, err := hrr.repo.get(hrr.ctx, hrr.url, hrr.offset, to)
+			if err != nil {
+				return 0, err
+			}
+			if resp.StatusCode != http.StatusPartialContent {
+				resp.Body.Close()
+				return 0, fmt.Errorf("server did not honour range request for %v", hrr.url)
+			}
+			hrr.body = resp.Body
+			hrr.bodyRead = 0
+		}
+		n, err := hrr.body.Read(p)
+		hrr.offset += int64(n)
+		hrr.bodyRead += int64(n)
+		if err == io.EOF {
+			hrr.body.Close()
+			hrr.body = nil
+			if hrr.bodyRead == 0 {
+				return n, io.ErrUnexpectedEOF
+			}
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (hrr *httpRangeReader) Seek(offset int64, whence int) (int64, error) {
+	var newOffset int64
+	switch whence {
+	case io.SeekStart:
+		newOffset = offset
+	case io.SeekCurrent:
+		newOffset = hrr.offset + offset
+	case io.SeekEnd:
+		newOffset = hrr.size + offset
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if newOffset < 0 {
+		return 0, fmt.Errorf("negative position %d", newOffset)
+	}
+	if newOffset != hrr.offset && hrr.body != nil {
+		hrr.body.Close()
+		hrr.body = nil
+	}
+	hrr.offset = newOffset
+	return newOffset, nil
+}
+
+func (hrr *httpRangeReader) Close() error {
+	if hrr.body == nil {
+		return nil
+	}
+	err := hrr.body.Close()
+	hrr.body = nil
+	return err
+}
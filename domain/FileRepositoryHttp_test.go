@@ -0,0 +1,217 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	httpRepo      FileRepositoryHttp
+	httpServer    *httptest.Server
+	httpContent   []byte = []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	rangeRequests []string
+	rangeMu       sync.Mutex
+)
+
+func setupHttp(chunkSize int64) func() {
+	rangeRequests = make([]string, 0)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ranged/file.mxf", func(w http.ResponseWriter, r *http.Request) {
+		rangeMu.Lock()
+		rangeRequests = append(rangeRequests, r.Header.Get("Range"))
+		rangeMu.Unlock()
+		http.ServeContent(w, r, "file.mxf", time.Time{}, bytes.NewReader(httpContent))
+	})
+	mux.HandleFunc("/ranged/empty.mxf", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "empty.mxf", time.Time{}, bytes.NewReader(nil))
+	})
+	mux.HandleFunc("/plain/file.mxf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/mxf")
+		w.Write(httpContent)
+	})
+	mux.HandleFunc("/redirect/file.mxf", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ranged/file.mxf", http.StatusFound)
+	})
+	mux.HandleFunc("/protected/file.mxf", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write(httpContent)
+	})
+	httpServer = httptest.NewServer(mux)
+	httpRepo = NewFileRepositoryHttp(map[string]map[string]string{
+		"127.0.0.1": {"Authorization": "Bearer secret"},
+	}, chunkSize)
+	return func() {
+		httpServer.Close()
+		httpRepo = FileRepositoryHttp{}
+	}
+}
+
+func Test_HttpGetReader_NotFound_Returns_NotFoundError(t *testing.T) {
+	teardown := setupHttp(8)
+	defer teardown()
+
+	srcFile, err := httpRepo.GetReader(context.Background(), httpServer.URL+"/missing/file.mxf")
+
+	assert.Nil(t, srcFile)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+}
+
+func Test_HttpGetReader_MissingHeaders_Returns_UnauthorizedError(t *testing.T) {
+	teardown := setupHttp(8)
+	defer teardown()
+	httpRepo = NewFileRepositoryHttp(nil, 8)
+
+	srcFile, err := httpRepo.GetReader(context.Background(), httpServer.URL+"/protected/file.mxf")
+
+	assert.Nil(t, srcFile)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusForbidden, err.StatusCode())
+}
+
+func Test_HttpGetReader_WithHostHeaders_Returns_Reader(t *testing.T) {
+	teardown := setupHttp(8)
+	defer teardown()
+
+	srcFile, err := httpRepo.GetReader(context.Background(), httpServer.URL+"/protected/file.mxf")
+
+	assert.Nil(t, err)
+	defer srcFile.Reader.Close()
+	data, _ := io.ReadAll(srcFile.Reader)
+	assert.EqualValues(t, httpContent, data)
+}
+
+func Test_HttpGetReader_NoRangeSupport_Returns_FullStream(t *testing.T) {
+	teardown := setupHttp(8)
+	defer teardown()
+
+	srcFile, err := httpRepo.GetReader(context.Background(), httpServer.URL+"/plain/file.mxf")
+
+	assert.Nil(t, err)
+	defer srcFile.Reader.Close()
+	data, _ := io.ReadAll(srcFile.Reader)
+	assert.EqualValues(t, httpContent, data)
+	assert.EqualValues(t, len(httpContent), srcFile.Size)
+	assert.EqualValues(t, "application/mxf", srcFile.ContentType)
+}
+
+func Test_HttpGetReader_WithRanges_ReadsInChunks(t *testing.T) {
+	teardown := setupHttp(8)
+	defer teardown()
+
+	srcFile, err := httpRepo.GetReader(context.Background(), httpServer.URL+"/ranged/file.mxf")
+
+	assert.Nil(t, err)
+	defer srcFile.Reader.Close()
+	data, _ := io.ReadAll(srcFile.Reader)
+	assert.EqualValues(t, httpContent, data)
+	assert.EqualValues(t, len(httpContent), srcFile.Size)
+	assert.EqualValues(t, []string{"bytes=0-7", "bytes=8-15", "bytes=16-23", "bytes=24-31", "bytes=32-35"}, rangeRequests)
+}
+
+func Test_HttpGetReader_WithRanges_OnlyFetchesWhatIsRead(t *testing.T) {
+	teardown := setupHttp(8)
+	defer teardown()
+
+	srcFile, err := httpRepo.GetReader(context.Background(), httpServer.URL+"/ranged/file.mxf")
+
+	assert.Nil(t, err)
+	defer srcFile.Reader.Close()
+	header := make([]byte, 4)
+	io.ReadFull(srcFile.Reader, header)
+	assert.EqualValues(t, "0123", string(header))
+	assert.EqualValues(t, []string{"bytes=0-7"}, rangeRequests)
+}
+
+func Test_HttpGetReader_Seek_FetchesFromOffset(t *testing.T) {
+	teardown := setupHttp(8)
+	defer teardown()
+
+	srcFile, _ := httpRepo.GetReader(context.Background(), httpServer.URL+"/ranged/file.mxf")
+	defer srcFile.Reader.Close()
+	seeker := srcFile.Reader.(io.Seeker)
+	pos, err := seeker.Seek(-4, io.SeekEnd)
+	data, _ := io.ReadAll(srcFile.Reader)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 32, pos)
+	assert.EqualValues(t, "wxyz", string(data))
+	assert.EqualValues(t, []string{"bytes=0-7", "bytes=32-35"}, rangeRequests)
+}
+
+func Test_HttpGetReader_FollowsRedirect(t *testing.T) {
+	teardown := setupHttp(8)
+	defer teardown()
+
+	srcFile, err := httpRepo.GetReader(context.Background(), httpServer.URL+"/redirect/file.mxf")
+
+	assert.Nil(t, err)
+	defer srcFile.Reader.Close()
+	data, _ := io.ReadAll(srcFile.Reader)
+	assert.EqualValues(t, httpContent, data)
+	assert.True(t, strings.HasSuffix(srcFile.Reader.(*httpRangeReader).url, "/ranged/file.mxf"))
+}
+
+func Test_HttpGetReader_RedirectToOtherHost_Drops_HostHeaders(t *testing.T) {
+	var apiKey string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("X-Api-Key")
+		w.Write(httpContent)
+	}))
+	defer target.Close()
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL+"/file.mxf", http.StatusFound)
+	}))
+	defer origin.Close()
+	repo := NewFileRepositoryHttp(map[string]map[string]string{
+		"localhost": {"X-Api-Key": "secret"},
+	}, 0)
+	originUrl := strings.Replace(origin.URL, "127.0.0.1", "localhost", 1)
+
+	srcFile, err := repo.GetReader(context.Background(), originUrl+"/file.mxf")
+
+	assert.Nil(t, err)
+	defer srcFile.Reader.Close()
+	data, _ := io.ReadAll(srcFile.Reader)
+	assert.EqualValues(t, httpContent, data)
+	assert.EqualValues(t, "", apiKey)
+}
+
+func Test_HttpGetReader_EmptyFile_Returns_EmptyReader(t *testing.T) {
+	teardown := setupHttp(8)
+	defer teardown()
+
+	srcFile, err := httpRepo.GetReader(context.Background(), httpServer.URL+"/ranged/empty.mxf")
+
+	assert.Nil(t, err)
+	defer srcFile.Reader.Close()
+	data, readErr := io.ReadAll(srcFile.Reader)
+	assert.Nil(t, readErr)
+	assert.EqualValues(t, 0, srcFile.Size)
+	assert.EqualValues(t, 0, len(data))
+}
+
+func Test_parseContentRangeSize_Invalid_Returns_Error(t *testing.T) {
+	_, err := parseContentRangeSize("items 0-7/36")
+
+	assert.NotNil(t, err)
+}
+
+func Test_parseContentRangeSize_Returns_Size(t *testing.T) {
+	size, err := parseContentRangeSize("bytes 0-7/36")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 36, size)
+}
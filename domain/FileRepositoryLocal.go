@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type FileRepositoryLocal struct {
+	allowedRoots []string
+}
+
+var (
+	windowsDrivePath = regexp.MustCompile(`^/[a-zA-Z]:/`)
+)
+
+func NewFileRepositoryLocal(allowedRoots []string) FileRepositoryLocal {
+	roots := make([]string, 0)
+	for _, root := range allowedRoots {
+		if strings.TrimSpace(root) == "" {
+			continue
+		}
+		cleanRoot, err := filepath.Abs(root)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Cannot resolve allowed root directory %v", root), err)
+			continue
+		}
+		roots = append(roots, cleanRoot)
+		// Paths are checked as given and again with symlinks resolved, so a
+		// root that is itself a symlink is kept in both forms.
+		if realRoot, err := filepath.EvalSymlinks(cleanRoot); err == nil && realRoot != cleanRoot {
+			roots = append(roots, realRoot)
+		}
+	}
+	return FileRepositoryLocal{roots}
+}
+
+func (frl FileRepositoryLocal) GetReader(ctx context.Context, srcUrl string) (*SourceFile, api_error.ApiErr) {
+	path, err := localPathFromUrl(srcUrl)
+	if err != nil {
+		return nil, err
+	}
+	// Nothing outside the roots is looked at, so the response does not tell
+	// whether a file exists there.
+	if !frl.isAllowed(path) {
+		return nil, api_error.NewUnauthorizedError(fmt.Sprintf("Access to path %v is not allowed", path))
+	}
+	realPath, evalErr := filepath.EvalSymlinks(path)
+	if evalErr != nil {
+		if os.IsNotExist(evalErr) {
+			return nil, api_error.NewNotFoundError(fmt.Sprintf("File %v does not exist", path))
+		}
+		logger.Error("Cannot resolve local file path", evalErr)
+		return nil, api_error.NewInternalServerError(fmt.Sprintf("Cannot resolve path %v", path), evalErr)
+	}
+	if !frl.isAllowed(realPath) {
+		return nil, api_error.NewUnauthorizedError(fmt.Sprintf("Access to path %v is not allowed", path))
+	}
+	file, openErr := os.Open(realPath)
+	if openErr != nil {
+		logger.Error("Cannot open local file", openErr)
+		if os.IsPermission(openErr) {
+			return nil, api_error.NewUnauthorizedError(fmt.Sprintf("No permission to read file %v", path))
+		}
+		return nil, api_error.NewInternalServerError(fmt.Sprintf("Cannot open file %v", path), openErr)
+	}
+	info, statErr := file.Stat()
+	if statErr != nil {
+		file.Close()
+		logger.Error("Cannot stat local file", statErr)
+		return nil, api_error.NewInternalServerError(fmt.Sprintf("Cannot open file %v", path), statErr)
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, api_error.NewBadRequestError(fmt.Sprintf("Path %v is a directory", path))
+	}
+	return &SourceFile{
+		Reader:      file,
+		Size:        info.Size(),
+		ContentType: mime.TypeByExtension(filepath.Ext(realPath)),
+	}, nil
+}
+
+func localPathFromUrl(srcUrl string) (string, api_error.ApiErr) {
+	parsedUrl, err := url.Parse(srcUrl)
+	if err != nil || !strings.EqualFold(parsedUrl.Scheme, "file") {
+		return "", api_error.NewBadRequestError(fmt.Sprintf("Cannot parse file URL %v", srcUrl))
+	}
+	if parsedUrl.Host != "" && !strings.EqualFold(parsedUrl.Host, "localhost") {
+		return "", api_error.NewBadRequestError(fmt.Sprintf("File URL %v must not reference a remote host", srcUrl))
+	}
+	path := parsedUrl.Path
+	if windowsDrivePath.MatchString(path) {
+		path = path[1:]
+	}
+	if strings.TrimSpace(path) == "" {
+		return "", api_error.NewBadRequestError(fmt.Sprintf("File URL %v has no path", srcUrl))
+	}
+	absPath, absErr := filepath.Abs(filepath.FromSlash(path))
+	if absErr != nil {
+		return "", api_error.NewBadRequestError(fmt.Sprintf("Cannot parse file URL %v", srcUrl))
+	}
+	return absPath, nil
+}
+
+func (frl FileRepositoryLocal) isAllowed(path string) bool {
+	for _, root := range frl.allowedRoots {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			continue
+		}
+		if rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	localRepo    FileRepositoryLocal
+	allowedRoot  string
+	outsideRoot  string
+	allowedFile  string
+	outsideFile  string
+	localContent string = "local file content"
+)
+
+func fileUrl(path string) string {
+	return fmt.Sprintf("file://%s", filepath.ToSlash(path))
+}
+
+func setupLocal(t *testing.T) func() {
+	allowedRoot = t.TempDir()
+	outsideRoot = t.TempDir()
+	allowedFile = filepath.Join(allowedRoot, "clip.mxf")
+	outsideFile = filepath.Join(outsideRoot, "secret.txt")
+	checkTestErr(t, os.WriteFile(allowedFile, []byte(localContent), 0644))
+	checkTestErr(t, os.WriteFile(outsideFile, []byte("secret"), 0644))
+	localRepo = NewFileRepositoryLocal([]string{allowedRoot})
+	return func() {
+		localRepo = FileRepositoryLocal{}
+	}
+}
+
+func checkTestErr(t *testing.T, err error) {
+	if err != nil {
+		t.Fatalf("could not execute test preparation. Error: %s", err)
+	}
+}
+
+func Test_LocalGetReader_RemoteHost_Returns_BadRequestError(t *testing.T) {
+	teardown := setupLocal(t)
+	defer teardown()
+
+	srcFile, err := localRepo.GetReader(context.Background(), "file://nas01/media/clip.mxf")
+
+	assert.Nil(t, srcFile)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func Test_LocalGetReader_FileDoesNotExist_Returns_NotFoundError(t *testing.T) {
+	teardown := setupLocal(t)
+	defer teardown()
+
+	srcFile, err := localRepo.GetReader(context.Background(), fileUrl(filepath.Join(allowedRoot, "missing.mxf")))
+
+	assert.Nil(t, srcFile)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+}
+
+func Test_LocalGetReader_OutsideRoot_Returns_UnauthorizedError(t *testing.T) {
+	teardown := setupLocal(t)
+	defer teardown()
+
+	srcFile, err := localRepo.GetReader(context.Background(), fileUrl(outsideFile))
+
+	assert.Nil(t, srcFile)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, fmt.Sprintf("Access to path %v is not allowed", outsideFile), err.Message())
+	assert.EqualValues(t, http.StatusForbidden, err.StatusCode())
+}
+
+func Test_LocalGetReader_MissingFileOutsideRoot_Returns_UnauthorizedError(t *testing.T) {
+	teardown := setupLocal(t)
+	defer teardown()
+
+	srcFile, err := localRepo.GetReader(context.Background(), fileUrl(filepath.Join(outsideRoot, "missing.mxf")))
+
+	assert.Nil(t, srcFile)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusForbidden, err.StatusCode())
+}
+
+func Test_LocalGetReader_PathTraversal_Returns_UnauthorizedError(t *testing.T) {
+	teardown := setupLocal(t)
+	defer teardown()
+	relToOutside, _ := filepath.Rel(allowedRoot, outsideFile)
+	traversalUrl := fmt.Sprintf("%s/%s", fileUrl(allowedRoot), filepath.ToSlash(relToOutside))
+
+	srcFile, err := localRepo.GetReader(context.Background(), traversalUrl)
+
+	assert.Nil(t, srcFile)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusForbidden, err.StatusCode())
+}
+
+func Test_LocalGetReader_SymlinkOutsideRoot_Returns_UnauthorizedError(t *testing.T) {
+	teardown := setupLocal(t)
+	defer teardown()
+	link := filepath.Join(allowedRoot, "link.txt")
+	if err := os.Symlink(outsideFile, link); err != nil {
+		t.Skip("symlinks not supported")
+	}
+
+	srcFile, err := localRepo.GetReader(context.Background(), fileUrl(link))
+
+	assert.Nil(t, srcFile)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusForbidden, err.StatusCode())
+}
+
+func Test_LocalGetReader_SymlinkedRoot_Returns_Reader(t *testing.T) {
+	teardown := setupLocal(t)
+	defer teardown()
+	linkedRoot := filepath.Join(outsideRoot, "media")
+	if err := os.Symlink(allowedRoot, linkedRoot); err != nil {
+		t.Skip("symlinks not supported")
+	}
+	localRepo = NewFileRepositoryLocal([]string{linkedRoot})
+
+	srcFile, err := localRepo.GetReader(context.Background(), fileUrl(filepath.Join(linkedRoot, "clip.mxf")))
+
+	assert.Nil(t, err)
+	assert.NotNil(t, srcFile)
+	srcFile.Reader.Close()
+}
+
+func Test_LocalGetReader_NoRoots_Returns_UnauthorizedError(t *testing.T) {
+	teardown := setupLocal(t)
+	defer teardown()
+	localRepo = NewFileRepositoryLocal([]string{})
+
+	srcFile, err := localRepo.GetReader(context.Background(), fileUrl(allowedFile))
+
+	assert.Nil(t, srcFile)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusForbidden, err.StatusCode())
+}
+
+func Test_LocalGetReader_Directory_Returns_BadRequestError(t *testing.T) {
+	teardown := setupLocal(t)
+	defer teardown()
+
+	srcFile, err := localRepo.GetReader(context.Background(), fileUrl(allowedRoot))
+
+	assert.Nil(t, srcFile)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func Test_LocalGetReader_Returns_Reader(t *testing.T) {
+	teardown := setupLocal(t)
+	defer teardown()
+
+	srcFile, err := localRepo.GetReader(context.Background(), fileUrl(allowedFile))
+
+	assert.Nil(t, err)
+	assert.NotNil(t, srcFile)
+	defer srcFile.Reader.Close()
+	data, _ := io.ReadAll(srcFile.Reader)
+	assert.EqualValues(t, localContent, string(data))
+	assert.EqualValues(t, len(localContent), srcFile.Size)
+}
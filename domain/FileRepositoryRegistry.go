@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+type FileRepositoryRegistry struct {
+	schemes map[string]FileRepository
+	hosts   map[string]FileRepository
+}
+
+func NewFileRepositoryRegistry() FileRepositoryRegistry {
+	return FileRepositoryRegistry{
+		schemes: make(map[string]FileRepository),
+		hosts:   make(map[string]FileRepository),
+	}
+}
+
+func (frr FileRepositoryRegistry) RegisterScheme(scheme string, repo FileRepository) {
+	frr.schemes[strings.ToLower(scheme)] = repo
+}
+
+func (frr FileRepositoryRegistry) RegisterHost(host string, repo FileRepository) {
+	frr.hosts[strings.ToLower(host)] = repo
+}
+
+func (frr FileRepositoryRegistry) Resolve(srcUrl string) (FileRepository, api_error.ApiErr) {
+	parsedUrl, err := url.Parse(srcUrl)
+	if err != nil || parsedUrl.Scheme == "" {
+		return nil, api_error.NewBadRequestError(fmt.Sprintf("Cannot parse source URL %v", srcUrl))
+	}
+	if repo, ok := frr.hosts[strings.ToLower(parsedUrl.Host)]; ok {
+		return repo, nil
+	}
+	if repo, ok := frr.schemes[strings.ToLower(parsedUrl.Scheme)]; ok {
+		return repo, nil
+	}
+	return nil, api_error.NewBadRequestError(fmt.Sprintf("No storage backend for scheme %v", parsedUrl.Scheme))
+}
+
+func (frr FileRepositoryRegistry) GetReader(ctx context.Context, srcUrl string) (*SourceFile, api_error.ApiErr) {
+	repo, err := frr.Resolve(srcUrl)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetReader(ctx, srcUrl)
+}
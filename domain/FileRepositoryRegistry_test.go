@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubFileRepository struct {
+	name string
+}
+
+func (sfr stubFileRepository) GetReader(ctx context.Context, srcUrl string) (*SourceFile, api_error.ApiErr) {
+	return &SourceFile{
+		Reader:      io.NopCloser(strings.NewReader(sfr.name)),
+		Size:        int64(len(sfr.name)),
+		ContentType: "text/plain",
+	}, nil
+}
+
+var (
+	registry FileRepositoryRegistry
+)
+
+func setupRegistry() func() {
+	registry = NewFileRepositoryRegistry()
+	registry.RegisterScheme("https", stubFileRepository{"scheme"})
+	registry.RegisterHost("account.blob.core.windows.net", stubFileRepository{"host"})
+	return func() {
+		registry = FileRepositoryRegistry{}
+	}
+}
+
+func Test_Resolve_InvalidUrl_Returns_BadRequestError(t *testing.T) {
+	teardown := setupRegistry()
+	defer teardown()
+
+	repo, err := registry.Resolve("no-scheme-here")
+
+	assert.Nil(t, repo)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "Cannot parse source URL no-scheme-here", err.Message())
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func Test_Resolve_UnknownScheme_Returns_BadRequestError(t *testing.T) {
+	teardown := setupRegistry()
+	defer teardown()
+
+	repo, err := registry.Resolve("ftp://server/path/file.ext")
+
+	assert.Nil(t, repo)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "No storage backend for scheme ftp", err.Message())
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func Test_Resolve_ByScheme_Returns_Repo(t *testing.T) {
+	teardown := setupRegistry()
+	defer teardown()
+
+	repo, err := registry.Resolve("HTTPS://server/path/file.ext")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, stubFileRepository{"scheme"}, repo)
+}
+
+func Test_Resolve_ByHost_Returns_Repo(t *testing.T) {
+	teardown := setupRegistry()
+	defer teardown()
+
+	repo, err := registry.Resolve("https://account.blob.core.windows.net/container/file.ext")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, stubFileRepository{"host"}, repo)
+}
+
+func Test_GetReader_UnknownScheme_Returns_BadRequestError(t *testing.T) {
+	teardown := setupRegistry()
+	defer teardown()
+
+	srcFile, err := registry.GetReader(context.Background(), "ftp://server/path/file.ext")
+
+	assert.Nil(t, srcFile)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func Test_GetReader_Returns_ReaderFromBackend(t *testing.T) {
+	teardown := setupRegistry()
+	defer teardown()
+
+	srcFile, err := registry.GetReader(context.Background(), "https://account.blob.core.windows.net/container/file.ext")
+	data, _ := io.ReadAll(srcFile.Reader)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "host", string(data))
+	assert.EqualValues(t, 4, srcFile.Size)
+	assert.EqualValues(t, "text/plain", srcFile.ContentType)
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+	"github.com/minio/minio-go/v7"
+)
+
+type FileRepositoryS3 struct {
+	client *minio.Client
+}
+
+func NewFileRepositoryS3(client *minio.Client) FileRepositoryS3 {
+	return FileRepositoryS3{client}
+}
+
+func (frs FileRepositoryS3) GetReader(ctx context.Context, srcUrl string) (*SourceFile, api_error.ApiErr) {
+	bucket, key, versionId, err := parseS3Url(srcUrl)
+	if err != nil {
+		return nil, err
+	}
+	object, getErr := frs.client.GetObject(ctx, bucket, key, minio.GetObjectOptions{VersionID: versionId})
+	if getErr != nil {
+		logger.Error("Cannot access file on S3 storage", getErr)
+		return nil, s3Error(srcUrl, getErr)
+	}
+	info, statErr := object.Stat()
+	if statErr != nil {
+		object.Close()
+		logger.Error("Cannot access file on S3 storage", statErr)
+		return nil, s3Error(srcUrl, statErr)
+	}
+	return &SourceFile{
+		Reader:      object,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+	}, nil
+}
+
+func parseS3Url(srcUrl string) (bucket string, key string, versionId string, err api_error.ApiErr) {
+	parsedUrl, parseErr := url.Parse(srcUrl)
+	if parseErr != nil || !strings.EqualFold(parsedUrl.Scheme, "s3") {
+		return "", "", "", api_error.NewBadRequestError(fmt.Sprintf("Cannot parse S3 URL %v", srcUrl))
+	}
+	bucket = parsedUrl.Host
+	key = strings.TrimPrefix(parsedUrl.Path, "/")
+	if bucket == "" || key == "" {
+		return "", "", "", api_error.NewBadRequestError(fmt.Sprintf("S3 URL %v must contain bucket and key", srcUrl))
+	}
+	return bucket, key, parsedUrl.Query().Get("versionId"), nil
+}
+
+func s3Error(srcUrl string, err error) api_error.ApiErr {
+	errResp := minio.ToErrorResponse(err)
+	msg := fmt.Sprintf("Cannot access file %v on S3 storage", srcUrl)
+	switch {
+	case errResp.Code == "NoSuchKey" || errResp.Code == "NoSuchBucket" || errResp.StatusCode == http.StatusNotFound:
+		return api_error.NewNotFoundError(msg)
+	case errResp.StatusCode == http.StatusUnauthorized:
+		return api_error.NewUnauthenticatedError(msg)
+	case errResp.Code == "AccessDenied" || errResp.StatusCode == http.StatusForbidden:
+		return api_error.NewUnauthorizedError(msg)
+	default:
+		return api_error.NewInternalServerError(msg, err)
+	}
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	s3Repo    FileRepositoryS3
+	s3Server  *httptest.Server
+	s3Content []byte = []byte("s3 object content")
+)
+
+func fakeS3Handler(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/media/2024/show/ep1.mxf":
+		w.Header().Set("ETag", "\"d41d8cd98f00b204e9800998ecf8427e\"")
+		w.Header().Set("Content-Type", "application/mxf")
+		http.ServeContent(w, r, "ep1.mxf", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), bytes.NewReader(s3Content))
+	case "/media/denied.mxf":
+		writeS3Error(w, r, http.StatusForbidden, "AccessDenied")
+	default:
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchKey")
+	}
+}
+
+func writeS3Error(w http.ResponseWriter, r *http.Request, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message><Resource>%s</Resource></Error>", code, code, r.URL.Path)
+	}
+}
+
+func setupS3(t *testing.T) func() {
+	s3Server = httptest.NewServer(http.HandlerFunc(fakeS3Handler))
+	client, err := minio.New(strings.TrimPrefix(s3Server.URL, "http://"), &minio.Options{
+		Creds:        credentials.NewStaticV4("access", "secret", ""),
+		Secure:       false,
+		Region:       "us-east-1",
+		BucketLookup: minio.BucketLookupPath,
+	})
+	checkTestErr(t, err)
+	s3Repo = NewFileRepositoryS3(client)
+	return func() {
+		s3Server.Close()
+		s3Repo = FileRepositoryS3{}
+	}
+}
+
+func Test_parseS3Url_WrongScheme_Returns_BadRequestError(t *testing.T) {
+	_, _, _, err := parseS3Url("https://media/file.mxf")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func Test_parseS3Url_NoKey_Returns_BadRequestError(t *testing.T) {
+	_, _, _, err := parseS3Url("s3://media/")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "S3 URL s3://media/ must contain bucket and key", err.Message())
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func Test_parseS3Url_Returns_BucketAndKey(t *testing.T) {
+	bucket, key, versionId, err := parseS3Url("s3://media/2024/show/ep%201.mxf?versionId=v42")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "media", bucket)
+	assert.EqualValues(t, "2024/show/ep 1.mxf", key)
+	assert.EqualValues(t, "v42", versionId)
+}
+
+func Test_S3GetReader_NoSuchKey_Returns_NotFoundError(t *testing.T) {
+	teardown := setupS3(t)
+	defer teardown()
+
+	srcFile, err := s3Repo.GetReader(context.Background(), "s3://media/missing.mxf")
+
+	assert.Nil(t, srcFile)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+}
+
+func Test_S3GetReader_AccessDenied_Returns_UnauthorizedError(t *testing.T) {
+	teardown := setupS3(t)
+	defer teardown()
+
+	srcFile, err := s3Repo.GetReader(context.Background(), "s3://media/denied.mxf")
+
+	assert.Nil(t, srcFile)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusForbidden, err.StatusCode())
+}
+
+func Test_S3GetReader_Returns_Reader(t *testing.T) {
+	teardown := setupS3(t)
+	defer teardown()
+
+	srcFile, err := s3Repo.GetReader(context.Background(), "s3://media/2024/show/ep1.mxf")
+
+	assert.Nil(t, err)
+	assert.NotNil(t, srcFile)
+	defer srcFile.Reader.Close()
+	data, _ := io.ReadAll(srcFile.Reader)
+	assert.EqualValues(t, s3Content, data)
+	assert.EqualValues(t, len(s3Content), srcFile.Size)
+	assert.EqualValues(t, "application/mxf", srcFile.ContentType)
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -14,30 +15,46 @@ import (
 type JobStatus string
 
 const (
-	JobStatusCreated  JobStatus = "created"
-	JobStatusQueued   JobStatus = "queued"
-	JobStatusRunning  JobStatus = "running"
-	JobStatusPaused   JobStatus = "paused"
-	JobStatusFinished JobStatus = "finished"
-	JobStatusFailed   JobStatus = "failed"
+	JobStatusCreated    JobStatus = "created"
+	JobStatusQueued     JobStatus = "queued"
+	JobStatusRunning    JobStatus = "running"
+	JobStatusPaused     JobStatus = "paused"
+	JobStatusFinished   JobStatus = "finished"
+	JobStatusFailed     JobStatus = "failed"
+	JobStatusCancelled  JobStatus = "cancelled"
+	JobStatusTimedOut   JobStatus = "timed_out"
+	JobStatusDeadLetter JobStatus = "dead_letter"
 )
 
 type Job struct {
-	Id         ksuid.KSUID `db:"job_id"`
-	Name       string      `db:"name"`
-	CreatedAt  time.Time   `db:"created_at"`
-	CreatedBy  string      `db:"created_by"`
-	ModifiedAt time.Time   `db:"modified_at"`
-	ModifiedBy string      `db:"modified_by"`
-	SrcUrl     string      `db:"src_url"`
-	Status     JobStatus   `db:"status"`
-	ErrorMsg   string      `db:"error_msg"`
-	TechInfo   string      `db:"tech_info"`
+	Id            ksuid.KSUID      `db:"job_id"`
+	Name          string           `db:"name"`
+	CreatedAt     time.Time        `db:"created_at"`
+	CreatedBy     string           `db:"created_by"`
+	ModifiedAt    time.Time        `db:"modified_at"`
+	ModifiedBy    string           `db:"modified_by"`
+	SrcUrl        string           `db:"src_url"`
+	Status        JobStatus        `db:"status"`
+	ErrorCode     JobErrorCode     `db:"error_code"`
+	ErrorMsg      string           `db:"error_msg"`
+	TechInfo      string           `db:"tech_info"`
+	ProbeResult   *ProbeResult     `db:"probe_result"`
+	MediaInfo     *MediaInfoResult `db:"media_info"`
+	Engines       ProbeEngines     `db:"engines"`
+	Profile       string           `db:"profile"`
+	Mode          JobMode          `db:"mode"`
+	DeepAnalysis  *DeepAnalysis    `db:"deep_analysis"`
+	ClaimedBy     string           `db:"claimed_by"`
+	Attempts      int              `db:"attempts"`
+	NextAttemptAt time.Time        `db:"next_attempt_at"`
+	ErrorHistory  JobErrors        `db:"error_history"`
+	StatusHistory JobTransitions   `db:"status_history"`
 }
 
 type JobStatusUpdate struct {
 	newStatus JobStatus
 	errMsg    string
+	changedBy string
 }
 
 //go:generate mockgen -destination=../mocks/domain/mockJobRepository.go -package=domain github.com/johannes-kuhfuss/probesvc/domain JobRepository
@@ -45,10 +62,24 @@ type JobRepository interface {
 	FindAll(string) (*[]Job, api_error.ApiErr)
 	FindById(string) (*Job, api_error.ApiErr)
 	Save(Job) api_error.ApiErr
+	Update(Job, JobStatus) api_error.ApiErr
 	DeleteById(string) api_error.ApiErr
-	GetNext() (*Job, api_error.ApiErr)
+	ClaimNext(string) (*Job, api_error.ApiErr)
 	SetStatus(string, JobStatusUpdate) api_error.ApiErr
-	SetResult(string, string) api_error.ApiErr
+	SetResult(string, string, ProbeResult) api_error.ApiErr
+	SetMediaInfoResult(string, MediaInfoResult) api_error.ApiErr
+	SetDeepAnalysis(string, DeepAnalysis) api_error.ApiErr
+	SetProcessingPaused(bool) api_error.ApiErr
+	IsProcessingPaused() (bool, api_error.ApiErr)
+}
+
+func processingPausedError() api_error.ApiErr {
+	return api_error.NewError("processing is paused", http.StatusServiceUnavailable, nil)
+}
+
+// NotRunningError is returned when a result is set for a job that is not running.
+func NotRunningError(id string) api_error.ApiErr {
+	return api_error.NewProcessingConflictError(fmt.Sprintf("Job with id %v is not running, cannot set its result", id))
 }
 
 func createJobName(name string) string {
@@ -67,37 +98,124 @@ func NewJob(name string, srcurl string) (*Job, api_error.ApiErr) {
 		return nil, api_error.NewBadRequestError("Job must have a source URL")
 	}
 
+	now := date.GetNowUtc()
 	return &Job{
-		Id:         ksuid.New(),
-		Name:       createJobName(name),
-		CreatedAt:  date.GetNowUtc(),
-		CreatedBy:  "",
-		ModifiedAt: date.GetNowUtc(),
-		ModifiedBy: "",
-		SrcUrl:     srcurl,
-		Status:     JobStatusCreated,
-		ErrorMsg:   "",
-		TechInfo:   "",
+		Id:            ksuid.New(),
+		Name:          createJobName(name),
+		CreatedAt:     now,
+		CreatedBy:     "",
+		ModifiedAt:    now,
+		ModifiedBy:    "",
+		SrcUrl:        srcurl,
+		Status:        JobStatusCreated,
+		ErrorCode:     ErrorCodeNone,
+		ErrorMsg:      "",
+		TechInfo:      "",
+		ProbeResult:   nil,
+		MediaInfo:     nil,
+		Engines:       ProbeEngines{EngineFfprobe},
+		Profile:       "",
+		Mode:          JobModeStandard,
+		DeepAnalysis:  nil,
+		ClaimedBy:     "",
+		Attempts:      0,
+		NextAttemptAt: now,
+		ErrorHistory:  JobErrors{},
+		StatusHistory: JobTransitions{},
 	}, nil
 }
 
+// ScheduleAt holds a new job back until startAt. Jobs with a start time in the
+// future are queued instead of created and cannot be claimed before then.
+func (job *Job) ScheduleAt(startAt time.Time) {
+	if startAt.After(date.GetNowUtc()) {
+		job.Status = JobStatusQueued
+		job.NextAttemptAt = startAt.UTC()
+	}
+}
+
+func (job Job) IsClaimable(now time.Time) bool {
+	return (job.Status == JobStatusCreated || job.Status == JobStatusQueued) && !job.NextAttemptAt.After(now)
+}
+
+// HasResults reports whether the job holds a result from each of its engines
+// and, in deep mode, the deep analysis. Only then can it be finished.
+func (job Job) HasResults() bool {
+	engines := job.Engines
+	if len(engines) == 0 {
+		engines = ProbeEngines{EngineFfprobe}
+	}
+	for _, engine := range engines {
+		switch engine {
+		case EngineMediaInfo:
+			if job.MediaInfo == nil {
+				return false
+			}
+		default:
+			if job.TechInfo == "" {
+				return false
+			}
+		}
+	}
+	return job.Mode != JobModeDeep || job.DeepAnalysis != nil
+}
+
+func (status JobStatus) IsFinal() bool {
+	switch status {
+	case JobStatusFinished, JobStatusFailed, JobStatusCancelled, JobStatusTimedOut, JobStatusDeadLetter:
+		return true
+	default:
+		return false
+	}
+}
+
 func (job Job) ToDto() dto.JobResponse {
+	var techInfo *dto.ProbeResultResponse
+	var summary *dto.MediaSummaryResponse
+	if job.ProbeResult != nil {
+		result := job.ProbeResult.ToDto()
+		techInfo = &result
+		mediaSummary := job.ProbeResult.Summary().ToDto()
+		summary = &mediaSummary
+	}
+	var mediaInfo *dto.MediaInfoResponse
+	if job.MediaInfo != nil {
+		result := job.MediaInfo.ToDto()
+		mediaInfo = &result
+	}
+	var deepAnalysis *dto.DeepAnalysisResponse
+	if job.DeepAnalysis != nil {
+		analysis := job.DeepAnalysis.ToDto()
+		deepAnalysis = &analysis
+	}
 	return dto.JobResponse{
-		Id:         job.Id.String(),
-		Name:       job.Name,
-		CreatedAt:  job.CreatedAt,
-		CreatedBy:  job.CreatedBy,
-		ModifiedAt: job.ModifiedAt,
-		ModifiedBy: job.ModifiedBy,
-		SrcUrl:     job.SrcUrl,
-		Status:     string(job.Status),
-		ErrorMsg:   job.ErrorMsg,
-		TechInfo:   job.TechInfo,
+		Id:            job.Id.String(),
+		Name:          job.Name,
+		CreatedAt:     job.CreatedAt,
+		CreatedBy:     job.CreatedBy,
+		ModifiedAt:    job.ModifiedAt,
+		ModifiedBy:    job.ModifiedBy,
+		SrcUrl:        job.SrcUrl,
+		Status:        string(job.Status),
+		ErrorCode:     string(job.ErrorCode),
+		ErrorMsg:      job.ErrorMsg,
+		TechInfo:      techInfo,
+		Summary:       summary,
+		MediaInfo:     mediaInfo,
+		Engines:       job.Engines.ToDto(),
+		Profile:       job.Profile,
+		Mode:          string(job.Mode),
+		DeepAnalysis:  deepAnalysis,
+		ClaimedBy:     job.ClaimedBy,
+		Attempts:      job.Attempts,
+		NextAttemptAt: job.NextAttemptAt,
+		ErrorHistory:  job.ErrorHistory.ToDto(),
+		StatusHistory: job.StatusHistory.ToDto(),
 	}
 }
 
 func ParseStatusRequest(newStatus dto.JobStatusUpdateRequest) (*JobStatusUpdate, api_error.ApiErr) {
-	jobStatusUpdate := JobStatusUpdate{}
+	jobStatusUpdate := JobStatusUpdate{changedBy: newStatus.ChangedBy}
 	switch strings.ToLower(newStatus.Status) {
 	case "created":
 		jobStatusUpdate.newStatus = JobStatusCreated
@@ -112,6 +230,15 @@ func ParseStatusRequest(newStatus dto.JobStatusUpdateRequest) (*JobStatusUpdate,
 	case "failed":
 		jobStatusUpdate.newStatus = JobStatusFailed
 		jobStatusUpdate.errMsg = newStatus.ErrMsg
+	case "cancelled":
+		jobStatusUpdate.newStatus = JobStatusCancelled
+		jobStatusUpdate.errMsg = newStatus.ErrMsg
+	case "timed_out":
+		jobStatusUpdate.newStatus = JobStatusTimedOut
+		jobStatusUpdate.errMsg = newStatus.ErrMsg
+	case "dead_letter":
+		jobStatusUpdate.newStatus = JobStatusDeadLetter
+		jobStatusUpdate.errMsg = newStatus.ErrMsg
 	default:
 		return nil, api_error.NewBadRequestError(fmt.Sprintf("Could not parse status value %v", newStatus.Status))
 	}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+type JobErrorCode string
+
+const (
+	ErrorCodeNone             JobErrorCode = ""
+	ErrorCodeInvalidSource    JobErrorCode = "invalid_source"
+	ErrorCodeStorageNotFound  JobErrorCode = "storage_not_found"
+	ErrorCodeStorageAuth      JobErrorCode = "storage_auth"
+	ErrorCodeNetwork          JobErrorCode = "network"
+	ErrorCodeTimeout          JobErrorCode = "timeout"
+	ErrorCodeTooLarge         JobErrorCode = "too_large"
+	ErrorCodeUnsupportedMedia JobErrorCode = "unsupported_media"
+	ErrorCodeProbeCrash       JobErrorCode = "probe_crash"
+	ErrorCodeInternal         JobErrorCode = "internal"
+)
+
+// IsRetryable reports whether a failure with this code may go away on its
+// own, so that trying the job again later makes sense.
+func (code JobErrorCode) IsRetryable() bool {
+	return code == ErrorCodeNetwork
+}
+
+// ParseJobErrorCode validates an error code reported from outside, such as
+// by a remote worker. An empty code is ErrorCodeNone.
+func ParseJobErrorCode(code string) (JobErrorCode, api_error.ApiErr) {
+	switch errorCode := JobErrorCode(strings.ToLower(strings.TrimSpace(code))); errorCode {
+	case ErrorCodeNone, ErrorCodeInvalidSource, ErrorCodeStorageNotFound, ErrorCodeStorageAuth, ErrorCodeNetwork,
+		ErrorCodeTimeout, ErrorCodeTooLarge, ErrorCodeUnsupportedMedia, ErrorCodeProbeCrash, ErrorCodeInternal:
+		return errorCode, nil
+	default:
+		return "", api_error.NewBadRequestError(fmt.Sprintf("Unknown error code %v", code))
+	}
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsRetryable_OnlyForNetworkErrors(t *testing.T) {
+	assert.True(t, ErrorCodeNetwork.IsRetryable())
+	assert.False(t, ErrorCodeStorageNotFound.IsRetryable())
+	assert.False(t, ErrorCodeStorageAuth.IsRetryable())
+	assert.False(t, ErrorCodeTimeout.IsRetryable())
+	assert.False(t, ErrorCodeUnsupportedMedia.IsRetryable())
+	assert.False(t, ErrorCodeProbeCrash.IsRetryable())
+}
+
+func Test_ParseJobErrorCode_Known_Returns_Code(t *testing.T) {
+	code, err := ParseJobErrorCode("Network")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, ErrorCodeNetwork, code)
+}
+
+func Test_ParseJobErrorCode_Empty_Returns_None(t *testing.T) {
+	code, err := ParseJobErrorCode("")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, ErrorCodeNone, code)
+}
+
+func Test_ParseJobErrorCode_Unknown_Returns_BadRequestError(t *testing.T) {
+	code, err := ParseJobErrorCode("disk_full")
+
+	assert.EqualValues(t, "", code)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "Unknown error code disk_full", err.Message())
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
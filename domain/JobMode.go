@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+type JobMode string
+
+const (
+	JobModeStandard JobMode = "standard"
+	JobModeDeep     JobMode = "deep"
+)
+
+// ParseJobMode validates the mode requested for a job. Deep jobs analyse
+// every packet and frame on top of the regular probe.
+func ParseJobMode(mode string) (JobMode, api_error.ApiErr) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", string(JobModeStandard):
+		return JobModeStandard, nil
+	case string(JobModeDeep):
+		return JobModeDeep, nil
+	default:
+		return "", api_error.NewBadRequestError(fmt.Sprintf("Unknown job mode %v", mode))
+	}
+}
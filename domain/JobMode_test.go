@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseJobMode_NoMode_Returns_Standard(t *testing.T) {
+	mode, err := ParseJobMode("")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, JobModeStandard, mode)
+}
+
+func Test_ParseJobMode_Deep_Returns_Deep(t *testing.T) {
+	mode, err := ParseJobMode("Deep")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, JobModeDeep, mode)
+}
+
+func Test_ParseJobMode_UnknownMode_Returns_BadRequestError(t *testing.T) {
+	mode, err := ParseJobMode("full")
+
+	assert.EqualValues(t, "", mode)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "Unknown job mode full", err.Message())
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func Test_ParseDeepAnalysis_InvalidData_Returns_ValidationError(t *testing.T) {
+	analysis, err := ParseDeepAnalysis("packets")
+
+	assert.Nil(t, analysis)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusUnprocessableEntity, err.StatusCode())
+}
+
+func Test_DeepAnalysis_ToDto_Returns_Gop(t *testing.T) {
+	analysis := DeepAnalysis{Streams: []StreamAnalysis{{Index: 0, CodecType: "video", Gop: &GopAnalysis{Count: 2, MaxLength: 25}}}}
+
+	response := analysis.ToDto()
+
+	assert.EqualValues(t, 1, len(response.Streams))
+	assert.EqualValues(t, "video", response.Streams[0].CodecType)
+	assert.EqualValues(t, 25, response.Streams[0].Gop.MaxLength)
+}
@@ -12,13 +12,14 @@ import (
 
 type JobRepositoryMem struct {
 	jobList map[string]Job
+	paused  *bool
 	mu      *sync.Mutex
 }
 
 func NewJobRepositoryMem() JobRepositoryMem {
 	jList := make(map[string]Job)
 	m := sync.Mutex{}
-	return JobRepositoryMem{jList, &m}
+	return JobRepositoryMem{jList, new(bool), &m}
 }
 
 func (jrm JobRepositoryMem) FindAll(status string) (*[]Job, api_error.ApiErr) {
@@ -82,6 +83,23 @@ func (csm JobRepositoryMem) Save(job Job) api_error.ApiErr {
 	return nil
 }
 
+// Update stores a job that was read and changed, unless its status has been
+// changed since it was read.
+func (csm JobRepositoryMem) Update(job Job, oldStatus JobStatus) api_error.ApiErr {
+	csm.mu.Lock()
+	defer csm.mu.Unlock()
+	stored, err := filterById(csm.jobList, job.Id.String())
+	if err != nil {
+		return err
+	}
+	if stored.Status != oldStatus {
+		return api_error.NewProcessingConflictError(fmt.Sprintf("Status of job %v was changed concurrently", job.Id))
+	}
+	job.ModifiedAt = date.GetNowUtc()
+	csm.jobList[job.Id.String()] = job
+	return nil
+}
+
 func (csm JobRepositoryMem) DeleteById(id string) api_error.ApiErr {
 	csm.mu.Lock()
 	defer csm.mu.Unlock()
@@ -96,19 +114,23 @@ func (csm JobRepositoryMem) DeleteById(id string) api_error.ApiErr {
 	return nil
 }
 
-func (csm JobRepositoryMem) GetNext() (*Job, api_error.ApiErr) {
+func (csm JobRepositoryMem) ClaimNext(workerId string) (*Job, api_error.ApiErr) {
 	var nextJobId string = ""
-	var nextJobDate time.Time = date.GetNowUtc().Add(1 * time.Second)
+	var now time.Time = date.GetNowUtc()
+	var nextJobDate time.Time = now.Add(1 * time.Second)
 
 	csm.mu.Lock()
 	defer csm.mu.Unlock()
 
+	if *csm.paused {
+		return nil, processingPausedError()
+	}
 	if len(csm.jobList) == 0 {
 		err := api_error.NewNotFoundError("no jobs in joblist")
 		return nil, err
 	}
 	for _, job := range csm.jobList {
-		if job.Status == JobStatusCreated {
+		if job.IsClaimable(now) {
 			if job.CreatedAt.Before(nextJobDate) {
 				nextJobDate = job.CreatedAt
 				nextJobId = job.Id.String()
@@ -119,27 +141,86 @@ func (csm JobRepositoryMem) GetNext() (*Job, api_error.ApiErr) {
 		err := api_error.NewNotFoundError("no jobs with status created in joblist")
 		return nil, err
 	}
-	job, _ := filterById(csm.jobList, nextJobId)
-	return job, nil
+	job := csm.jobList[nextJobId]
+	if err := job.TransitionTo(JobStatusRunning, workerId); err != nil {
+		return nil, err
+	}
+	job.ClaimedBy = workerId
+	job.Attempts++
+	job.ModifiedAt = now
+	csm.jobList[nextJobId] = job
+	return &job, nil
 }
 
 func (csm JobRepositoryMem) SetStatus(id string, newStatus JobStatusUpdate) api_error.ApiErr {
-	job, err := csm.FindById(id)
+	csm.mu.Lock()
+	defer csm.mu.Unlock()
+	if len(csm.jobList) == 0 {
+		return api_error.NewNotFoundError("no jobs in joblist")
+	}
+	job, err := filterById(csm.jobList, id)
 	if err != nil {
 		return err
 	}
-	job.Status = newStatus.newStatus
+	if err := job.TransitionTo(newStatus.newStatus, newStatus.changedBy); err != nil {
+		return err
+	}
+	job.ErrorCode = ErrorCodeNone
 	job.ErrorMsg = newStatus.errMsg
-	csm.Save(*job)
+	job.ModifiedAt = date.GetNowUtc()
+	csm.jobList[id] = *job
 	return nil
 }
 
-func (csm JobRepositoryMem) SetResult(id string, data string) api_error.ApiErr {
-	job, err := csm.FindById(id)
+// setJobResult changes a running job under the lock, so that a status change
+// made at the same time is not overwritten with a stale copy of the job.
+func (csm JobRepositoryMem) setJobResult(id string, change func(*Job)) api_error.ApiErr {
+	csm.mu.Lock()
+	defer csm.mu.Unlock()
+	if len(csm.jobList) == 0 {
+		return api_error.NewNotFoundError("no jobs in joblist")
+	}
+	job, err := filterById(csm.jobList, id)
 	if err != nil {
 		return err
 	}
-	job.TechInfo = data
-	csm.Save(*job)
+	if job.Status != JobStatusRunning {
+		return NotRunningError(id)
+	}
+	change(job)
+	job.ModifiedAt = date.GetNowUtc()
+	csm.jobList[id] = *job
+	return nil
+}
+
+func (csm JobRepositoryMem) SetMediaInfoResult(id string, result MediaInfoResult) api_error.ApiErr {
+	return csm.setJobResult(id, func(job *Job) {
+		job.MediaInfo = &result
+	})
+}
+
+func (csm JobRepositoryMem) SetDeepAnalysis(id string, analysis DeepAnalysis) api_error.ApiErr {
+	return csm.setJobResult(id, func(job *Job) {
+		job.DeepAnalysis = &analysis
+	})
+}
+
+func (csm JobRepositoryMem) SetResult(id string, data string, result ProbeResult) api_error.ApiErr {
+	return csm.setJobResult(id, func(job *Job) {
+		job.TechInfo = data
+		job.ProbeResult = &result
+	})
+}
+
+func (csm JobRepositoryMem) SetProcessingPaused(paused bool) api_error.ApiErr {
+	csm.mu.Lock()
+	defer csm.mu.Unlock()
+	*csm.paused = paused
 	return nil
 }
+
+func (csm JobRepositoryMem) IsProcessingPaused() (bool, api_error.ApiErr) {
+	csm.mu.Lock()
+	defer csm.mu.Unlock()
+	return *csm.paused, nil
+}
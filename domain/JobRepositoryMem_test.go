@@ -172,11 +172,11 @@ func Test_DeleteById_Returns_NoError(t *testing.T) {
 	assert.Equal(t, 1, len(jobRepo.jobList))
 }
 
-func Test_GetNext_NoJobs_Returns_NotFoundError(t *testing.T) {
+func Test_ClaimNext_NoJobs_Returns_NotFoundError(t *testing.T) {
 	teardown := setupJob()
 	defer teardown()
 
-	job, err := jobRepo.GetNext()
+	job, err := jobRepo.ClaimNext("worker-1")
 
 	assert.Nil(t, job)
 	assert.NotNil(t, err)
@@ -184,17 +184,17 @@ func Test_GetNext_NoJobs_Returns_NotFoundError(t *testing.T) {
 	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
 }
 
-func Test_GetNext_NoCreatedJobs_Returns_NotFoundError(t *testing.T) {
+func Test_ClaimNext_NoCreatedJobs_Returns_NotFoundError(t *testing.T) {
 	teardown := setupJob()
 	defer teardown()
 	createdId := fillJobList()
 	newStatus := JobStatusUpdate{
-		newStatus: JobStatusFailed,
-		errMsg:    "why-did-I-fail",
+		newStatus: JobStatusCancelled,
+		errMsg:    "why-was-I-cancelled",
 	}
 	jobRepo.SetStatus(createdId, newStatus)
 
-	job, err := jobRepo.GetNext()
+	job, err := jobRepo.ClaimNext("worker-1")
 
 	assert.Nil(t, job)
 	assert.NotNil(t, err)
@@ -202,12 +202,12 @@ func Test_GetNext_NoCreatedJobs_Returns_NotFoundError(t *testing.T) {
 	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
 }
 
-func Test_GetNext_Returns_NoError(t *testing.T) {
+func Test_ClaimNext_Returns_NoError(t *testing.T) {
 	teardown := setupJob()
 	defer teardown()
 	createdId := fillJobList()
 
-	job, err := jobRepo.GetNext()
+	job, err := jobRepo.ClaimNext("worker-1")
 
 	assert.NotNil(t, job)
 	assert.Nil(t, err)
@@ -230,8 +230,8 @@ func Test_SetStatus_Returns_NoError(t *testing.T) {
 	defer teardown()
 	id := fillJobList()
 	newStatus := JobStatusUpdate{
-		newStatus: JobStatusFailed,
-		errMsg:    "why-did-I-fail",
+		newStatus: JobStatusCancelled,
+		errMsg:    "why-was-I-cancelled",
 	}
 	err := jobRepo.SetStatus(id, newStatus)
 	job, _ := jobRepo.FindById(id)
@@ -244,7 +244,7 @@ func Test_SetStatus_Returns_NoError(t *testing.T) {
 func Test_SetResult_NoJob_Returns_NotFoundError(t *testing.T) {
 	teardown := setupJob()
 	defer teardown()
-	err := jobRepo.SetResult("", "new data")
+	err := jobRepo.SetResult("", "new data", ProbeResult{})
 
 	assert.NotNil(t, err)
 	assert.EqualValues(t, "no jobs in joblist", err.Message())
@@ -254,10 +254,16 @@ func Test_SetResult_NoJob_Returns_NotFoundError(t *testing.T) {
 func Test_SetResult_Returns_NoError(t *testing.T) {
 	teardown := setupJob()
 	defer teardown()
-	id := fillJobList()
-	err := jobRepo.SetResult(id, "new data")
+	fillJobList()
+	running, _ := NewJob("job 3", "url 3")
+	running.Status = JobStatusRunning
+	jobRepo.Save(*running)
+	id := running.Id.String()
+	result := ProbeResult{Format: ProbeFormat{FormatName: "wav"}}
+	err := jobRepo.SetResult(id, "new data", result)
 	job, _ := jobRepo.FindById(id)
 
 	assert.Nil(t, err)
 	assert.EqualValues(t, "new data", job.TechInfo)
+	assert.EqualValues(t, "wav", job.ProbeResult.Format.FormatName)
 }
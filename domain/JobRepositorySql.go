@@ -0,0 +1,333 @@
+package domain
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/date"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+const (
+	settingProcessingPaused = "processing_paused"
+	// dbTimeFormat has a fixed number of fraction digits, so that times
+	// stored as text compare in the order of the times themselves.
+	dbTimeFormat = "2006-01-02 15:04:05.000000000-07:00"
+	jobColumns   = "job_id, name, created_at, created_by, modified_at, modified_by, src_url, status, error_code, error_msg, tech_info, probe_result, media_info, engines, profile, mode, deep_analysis, claimed_by, attempts, next_attempt_at, error_history, status_history"
+)
+
+// dbTime binds a time in UTC and in dbTimeFormat. SQLite stores times as
+// text and compares them as text, so they all have to be written alike.
+type dbTime time.Time
+
+func (t dbTime) Value() (driver.Value, error) {
+	return time.Time(t).UTC().Format(dbTimeFormat), nil
+}
+
+// jobRow binds the times of a job as dbTime. The shadowing fields take
+// precedence over the ones of the embedded job.
+type jobRow struct {
+	Job
+	CreatedAt     dbTime `db:"created_at"`
+	ModifiedAt    dbTime `db:"modified_at"`
+	NextAttemptAt dbTime `db:"next_attempt_at"`
+}
+
+func newJobRow(job Job) jobRow {
+	return jobRow{job, dbTime(job.CreatedAt), dbTime(job.ModifiedAt), dbTime(job.NextAttemptAt)}
+}
+
+type JobRepositorySql struct {
+	db     *sqlx.DB
+	driver string
+}
+
+func NewJobRepositorySql(db *sqlx.DB) (JobRepositorySql, api_error.ApiErr) {
+	if err := migrateJobDb(db, db.DriverName()); err != nil {
+		return JobRepositorySql{}, err
+	}
+	return JobRepositorySql{db, db.DriverName()}, nil
+}
+
+func dbError(msg string, err error) api_error.ApiErr {
+	logger.Error(msg, err)
+	return api_error.NewInternalServerError(msg, err)
+}
+
+func (jrs JobRepositorySql) isEmpty() (bool, api_error.ApiErr) {
+	var count int
+	if err := jrs.db.Get(&count, "SELECT COUNT(*) FROM jobs"); err != nil {
+		return false, dbError("Database error while counting jobs", err)
+	}
+	return count == 0, nil
+}
+
+func (jrs JobRepositorySql) FindAll(status string) (*[]Job, api_error.ApiErr) {
+	empty, err := jrs.isEmpty()
+	if err != nil {
+		return nil, err
+	}
+	if empty {
+		return nil, api_error.NewNotFoundError("no jobs in joblist")
+	}
+	jobs := make([]Job, 0)
+	if strings.TrimSpace(status) == "" {
+		if err := jrs.db.Select(&jobs, fmt.Sprintf("SELECT %s FROM jobs ORDER BY created_at", jobColumns)); err != nil {
+			return nil, dbError("Database error while getting jobs", err)
+		}
+		return &jobs, nil
+	}
+	query := jrs.db.Rebind(fmt.Sprintf("SELECT %s FROM jobs WHERE status = ? ORDER BY created_at", jobColumns))
+	if err := jrs.db.Select(&jobs, query, status); err != nil {
+		return nil, dbError("Database error while getting jobs", err)
+	}
+	if len(jobs) == 0 {
+		return nil, api_error.NewNotFoundError(fmt.Sprintf("no jobs with status %v in joblist", status))
+	}
+	return &jobs, nil
+}
+
+func (jrs JobRepositorySql) FindById(id string) (*Job, api_error.ApiErr) {
+	empty, err := jrs.isEmpty()
+	if err != nil {
+		return nil, err
+	}
+	if empty {
+		return nil, api_error.NewNotFoundError("no jobs in joblist")
+	}
+	var job Job
+	query := jrs.db.Rebind(fmt.Sprintf("SELECT %s FROM jobs WHERE job_id = ?", jobColumns))
+	if err := jrs.db.Get(&job, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, api_error.NewNotFoundError(fmt.Sprintf("no job with id %v in joblist", id))
+		}
+		return nil, dbError("Database error while getting job", err)
+	}
+	return &job, nil
+}
+
+func (jrs JobRepositorySql) Save(job Job) api_error.ApiErr {
+	job.ModifiedAt = date.GetNowUtc()
+	query := fmt.Sprintf(`INSERT INTO jobs (%s)
+		VALUES (:job_id, :name, :created_at, :created_by, :modified_at, :modified_by, :src_url, :status, :error_code, :error_msg, :tech_info, :probe_result, :media_info, :engines, :profile, :mode, :deep_analysis, :claimed_by, :attempts, :next_attempt_at, :error_history, :status_history)
+		ON CONFLICT (job_id) DO UPDATE SET
+			name = excluded.name,
+			modified_at = excluded.modified_at,
+			modified_by = excluded.modified_by,
+			src_url = excluded.src_url,
+			status = excluded.status,
+			error_code = excluded.error_code,
+			error_msg = excluded.error_msg,
+			tech_info = excluded.tech_info,
+			probe_result = excluded.probe_result,
+			media_info = excluded.media_info,
+			engines = excluded.engines,
+			profile = excluded.profile,
+			mode = excluded.mode,
+			deep_analysis = excluded.deep_analysis,
+			claimed_by = excluded.claimed_by,
+			attempts = excluded.attempts,
+			next_attempt_at = excluded.next_attempt_at,
+			error_history = excluded.error_history,
+			status_history = excluded.status_history`, jobColumns)
+	if _, err := jrs.db.NamedExec(query, newJobRow(job)); err != nil {
+		return dbError("Database error while saving job", err)
+	}
+	return nil
+}
+
+// Update stores a job that was read and changed, unless its status has been
+// changed since it was read.
+func (jrs JobRepositorySql) Update(job Job, oldStatus JobStatus) api_error.ApiErr {
+	job.ModifiedAt = date.GetNowUtc()
+	update := struct {
+		jobRow
+		OldStatus JobStatus `db:"old_status"`
+	}{newJobRow(job), oldStatus}
+	query := `UPDATE jobs SET
+			name = :name,
+			modified_at = :modified_at,
+			modified_by = :modified_by,
+			src_url = :src_url,
+			status = :status,
+			error_code = :error_code,
+			error_msg = :error_msg,
+			tech_info = :tech_info,
+			probe_result = :probe_result,
+			media_info = :media_info,
+			engines = :engines,
+			profile = :profile,
+			mode = :mode,
+			deep_analysis = :deep_analysis,
+			claimed_by = :claimed_by,
+			attempts = :attempts,
+			next_attempt_at = :next_attempt_at,
+			error_history = :error_history,
+			status_history = :status_history
+		WHERE job_id = :job_id AND status = :old_status`
+	result, dbErr := jrs.db.NamedExec(query, update)
+	if dbErr != nil {
+		return dbError("Database error while updating job", dbErr)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		if _, err := jrs.FindById(job.Id.String()); err != nil {
+			return err
+		}
+		return api_error.NewProcessingConflictError(fmt.Sprintf("Status of job %v was changed concurrently", job.Id))
+	}
+	return nil
+}
+
+func (jrs JobRepositorySql) DeleteById(id string) api_error.ApiErr {
+	empty, err := jrs.isEmpty()
+	if err != nil {
+		return err
+	}
+	if empty {
+		return api_error.NewNotFoundError("no jobs in joblist")
+	}
+	result, dbErr := jrs.db.Exec(jrs.db.Rebind("DELETE FROM jobs WHERE job_id = ?"), id)
+	if dbErr != nil {
+		return dbError("Database error while deleting job", dbErr)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return api_error.NewNotFoundError(fmt.Sprintf("no job with id %v in joblist", id))
+	}
+	return nil
+}
+
+func (jrs JobRepositorySql) ClaimNext(workerId string) (*Job, api_error.ApiErr) {
+	empty, err := jrs.isEmpty()
+	if err != nil {
+		return nil, err
+	}
+	if empty {
+		return nil, api_error.NewNotFoundError("no jobs in joblist")
+	}
+	lockClause, settingsLockClause := "", ""
+	if jrs.driver == "postgres" {
+		lockClause = "FOR UPDATE SKIP LOCKED"
+		// Pausing waits for claims that are under way.
+		settingsLockClause = "FOR SHARE"
+	}
+	tx, dbErr := jrs.db.Beginx()
+	if dbErr != nil {
+		return nil, dbError("Database error while getting next job", dbErr)
+	}
+	defer tx.Rollback()
+
+	var paused string
+	pausedQuery := tx.Rebind(fmt.Sprintf("SELECT value FROM settings WHERE name = ? %s", settingsLockClause))
+	if err := tx.Get(&paused, pausedQuery, settingProcessingPaused); err != nil {
+		return nil, dbError("Database error while getting next job", err)
+	}
+	if paused == "true" {
+		return nil, processingPausedError()
+	}
+	var job Job
+	now := date.GetNowUtc()
+	query := tx.Rebind(fmt.Sprintf(`SELECT %s FROM jobs
+		WHERE status IN (?, ?) AND next_attempt_at <= ? ORDER BY created_at LIMIT 1 %s`, jobColumns, lockClause))
+	if err := tx.Get(&job, query, JobStatusCreated, JobStatusQueued, dbTime(now)); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, api_error.NewNotFoundError("no jobs with status created in joblist")
+		}
+		return nil, dbError("Database error while getting next job", err)
+	}
+	oldStatus := job.Status
+	if err := job.TransitionTo(JobStatusRunning, workerId); err != nil {
+		return nil, err
+	}
+	job.ClaimedBy = workerId
+	job.Attempts++
+	job.ModifiedAt = now
+	update := tx.Rebind(`UPDATE jobs SET status = ?, claimed_by = ?, attempts = ?, status_history = ?, modified_at = ?
+		WHERE job_id = ? AND status = ?`)
+	result, dbErr := tx.Exec(update, job.Status, job.ClaimedBy, job.Attempts, job.StatusHistory, dbTime(job.ModifiedAt), job.Id.String(), oldStatus)
+	if dbErr != nil {
+		return nil, dbError("Database error while getting next job", dbErr)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, api_error.NewNotFoundError("no jobs with status created in joblist")
+	}
+	if dbErr := tx.Commit(); dbErr != nil {
+		return nil, dbError("Database error while getting next job", dbErr)
+	}
+	return &job, nil
+}
+
+func (jrs JobRepositorySql) SetStatus(id string, newStatus JobStatusUpdate) api_error.ApiErr {
+	job, err := jrs.FindById(id)
+	if err != nil {
+		return err
+	}
+	oldStatus := job.Status
+	if err := job.TransitionTo(newStatus.newStatus, newStatus.changedBy); err != nil {
+		return err
+	}
+	// Only update if nobody changed the status since it was read.
+	query := jrs.db.Rebind(`UPDATE jobs SET status = ?, error_code = ?, error_msg = ?, status_history = ?, modified_at = ?
+		WHERE job_id = ? AND status = ?`)
+	result, dbErr := jrs.db.Exec(query, job.Status, ErrorCodeNone, newStatus.errMsg, job.StatusHistory, dbTime(date.GetNowUtc()), id, oldStatus)
+	if dbErr != nil {
+		return dbError("Database error while setting job status", dbErr)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return api_error.NewProcessingConflictError(fmt.Sprintf("Status of job %v was changed concurrently", id))
+	}
+	return nil
+}
+
+// setJobResult sets the given result columns, but only while the job is
+// running. The status check is part of the update so that a job finished
+// or cancelled in the meantime keeps its state.
+func (jrs JobRepositorySql) setJobResult(id string, columns string, values ...interface{}) api_error.ApiErr {
+	query := jrs.db.Rebind(fmt.Sprintf("UPDATE jobs SET %v, modified_at = ? WHERE job_id = ? AND status = ?", columns))
+	args := append(values, dbTime(date.GetNowUtc()), id, JobStatusRunning)
+	result, dbErr := jrs.db.Exec(query, args...)
+	if dbErr != nil {
+		return dbError("Database error while setting job result", dbErr)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		if _, err := jrs.FindById(id); err != nil {
+			return err
+		}
+		return NotRunningError(id)
+	}
+	return nil
+}
+
+func (jrs JobRepositorySql) SetResult(id string, data string, result ProbeResult) api_error.ApiErr {
+	return jrs.setJobResult(id, "tech_info = ?, probe_result = ?", data, result)
+}
+
+func (jrs JobRepositorySql) SetMediaInfoResult(id string, result MediaInfoResult) api_error.ApiErr {
+	return jrs.setJobResult(id, "media_info = ?", result)
+}
+
+func (jrs JobRepositorySql) SetDeepAnalysis(id string, analysis DeepAnalysis) api_error.ApiErr {
+	return jrs.setJobResult(id, "deep_analysis = ?", analysis)
+}
+
+func (jrs JobRepositorySql) SetProcessingPaused(paused bool) api_error.ApiErr {
+	query := jrs.db.Rebind("UPDATE settings SET value = ? WHERE name = ?")
+	if _, err := jrs.db.Exec(query, strconv.FormatBool(paused), settingProcessingPaused); err != nil {
+		return dbError("Database error while setting processing status", err)
+	}
+	return nil
+}
+
+func (jrs JobRepositorySql) IsProcessingPaused() (bool, api_error.ApiErr) {
+	var paused string
+	query := jrs.db.Rebind("SELECT value FROM settings WHERE name = ?")
+	if err := jrs.db.Get(&paused, query, settingProcessingPaused); err != nil {
+		return false, dbError("Database error while getting processing status", err)
+	}
+	return paused == "true", nil
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/date"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+const (
+	migrationLockId = 4711
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+type migration struct {
+	version int
+	name    string
+	script  string
+}
+
+func loadMigrations(driver string) ([]migration, error) {
+	dir := path.Join("migrations", driver)
+	entries, err := migrationFiles.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]migration, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		version, err := strconv.Atoi(strings.SplitN(entry.Name(), "_", 2)[0])
+		if err != nil {
+			return nil, fmt.Errorf("migration %v has no numeric version prefix", entry.Name())
+		}
+		script, err := migrationFiles.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{version, entry.Name(), string(script)})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
+
+func migrateJobDb(db *sqlx.DB, driver string) api_error.ApiErr {
+	migrations, err := loadMigrations(driver)
+	if err != nil {
+		logger.Error("Cannot load database migrations", err)
+		return api_error.NewInternalServerError("Cannot load database migrations", err)
+	}
+	ctx := context.Background()
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		logger.Error("Cannot connect to job database", err)
+		return api_error.NewInternalServerError("Cannot connect to job database", err)
+	}
+	defer conn.Close()
+	if driver == "postgres" {
+		if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockId); err != nil {
+			logger.Error("Cannot lock job database for migration", err)
+			return api_error.NewInternalServerError("Cannot lock job database for migration", err)
+		}
+		defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockId)
+	}
+	_, err = conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, applied_at TIMESTAMP NOT NULL)")
+	if err != nil {
+		logger.Error("Cannot create migration table", err)
+		return api_error.NewInternalServerError("Cannot create migration table", err)
+	}
+	var current int
+	err = conn.GetContext(ctx, &current, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations")
+	if err != nil {
+		logger.Error("Cannot read schema version", err)
+		return api_error.NewInternalServerError("Cannot read schema version", err)
+	}
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		logger.Info(fmt.Sprintf("Applying database migration %v", m.name))
+		tx, err := conn.BeginTxx(ctx, nil)
+		if err != nil {
+			logger.Error("Cannot start migration transaction", err)
+			return api_error.NewInternalServerError("Cannot start migration transaction", err)
+		}
+		if _, err = tx.Exec(m.script); err == nil {
+			_, err = tx.Exec(db.Rebind("INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)"), m.version, date.GetNowUtc())
+		}
+		if err != nil {
+			tx.Rollback()
+			logger.Error(fmt.Sprintf("Cannot apply database migration %v", m.name), err)
+			return api_error.NewInternalServerError(fmt.Sprintf("Cannot apply database migration %v", m.name), err)
+		}
+		if err = tx.Commit(); err != nil {
+			logger.Error(fmt.Sprintf("Cannot commit database migration %v", m.name), err)
+			return api_error.NewInternalServerError(fmt.Sprintf("Cannot commit database migration %v", m.name), err)
+		}
+	}
+	return nil
+}
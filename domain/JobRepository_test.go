@@ -0,0 +1,569 @@
+package domain
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/ksuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func fillRepo(t *testing.T, repo JobRepository) (createdId string) {
+	job1, _ := NewJob("job 1", "url 1")
+	job2, _ := NewJob("job 2", "url 2")
+	job2.CreatedAt = job1.CreatedAt.Add(1 * time.Millisecond)
+	job2.Status = JobStatusRunning
+	assert.Nil(t, repo.Save(*job1))
+	assert.Nil(t, repo.Save(*job2))
+	return job1.Id.String()
+}
+
+func fillRunningJob(t *testing.T, repo JobRepository) (runningId string) {
+	job, _ := NewJob("job 3", "url 3")
+	job.Status = JobStatusRunning
+	assert.Nil(t, repo.Save(*job))
+	return job.Id.String()
+}
+
+func runJobRepositoryBehaviour(t *testing.T, newRepo func(t *testing.T) JobRepository) {
+	t.Run("FindAll_NoJobs_Returns_NotFoundError", func(t *testing.T) {
+		repo := newRepo(t)
+
+		jList, err := repo.FindAll("")
+
+		assert.Nil(t, jList)
+		assert.NotNil(t, err)
+		assert.EqualValues(t, "no jobs in joblist", err.Message())
+		assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	})
+	t.Run("FindAll_NoJobsAfterFilter_Returns_NotFoundError", func(t *testing.T) {
+		repo := newRepo(t)
+		fillRepo(t, repo)
+
+		jList, err := repo.FindAll("finished")
+
+		assert.Nil(t, jList)
+		assert.NotNil(t, err)
+		assert.EqualValues(t, "no jobs with status finished in joblist", err.Message())
+		assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	})
+	t.Run("FindAll_NoFilter_Returns_NoError", func(t *testing.T) {
+		repo := newRepo(t)
+		fillRepo(t, repo)
+
+		jList, err := repo.FindAll("")
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 2, len(*jList))
+	})
+	t.Run("FindAll_WithFilter_Returns_NoError", func(t *testing.T) {
+		repo := newRepo(t)
+		fillRepo(t, repo)
+
+		jList, err := repo.FindAll("running")
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 1, len(*jList))
+		assert.EqualValues(t, JobStatusRunning, (*jList)[0].Status)
+	})
+	t.Run("FindById_NoJobs_Returns_NotFoundError", func(t *testing.T) {
+		repo := newRepo(t)
+
+		job, err := repo.FindById("")
+
+		assert.Nil(t, job)
+		assert.NotNil(t, err)
+		assert.EqualValues(t, "no jobs in joblist", err.Message())
+		assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	})
+	t.Run("FindById_UnknownId_Returns_NotFoundError", func(t *testing.T) {
+		repo := newRepo(t)
+		fillRepo(t, repo)
+		id := ksuid.New().String()
+
+		job, err := repo.FindById(id)
+
+		assert.Nil(t, job)
+		assert.NotNil(t, err)
+		assert.EqualValues(t, fmt.Sprintf("no job with id %v in joblist", id), err.Message())
+		assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	})
+	t.Run("FindById_Returns_Job", func(t *testing.T) {
+		repo := newRepo(t)
+		job, _ := NewJob("job 1", "https://server/path/file.ext")
+		job.ErrorMsg = "some error"
+		job.TechInfo = "{\"format\": {}}"
+		repo.Save(*job)
+
+		found, err := repo.FindById(job.Id.String())
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, job.Id, found.Id)
+		assert.EqualValues(t, job.Name, found.Name)
+		assert.EqualValues(t, job.SrcUrl, found.SrcUrl)
+		assert.EqualValues(t, job.Status, found.Status)
+		assert.EqualValues(t, job.ErrorMsg, found.ErrorMsg)
+		assert.EqualValues(t, job.TechInfo, found.TechInfo)
+		assert.True(t, job.CreatedAt.Equal(found.CreatedAt))
+	})
+	t.Run("Save_ExistingJob_Updates_Job", func(t *testing.T) {
+		repo := newRepo(t)
+		job, _ := NewJob("job 1", "url 1")
+		repo.Save(*job)
+		job.Name = "renamed"
+
+		err := repo.Save(*job)
+		jList, _ := repo.FindAll("")
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 1, len(*jList))
+		assert.EqualValues(t, "renamed", (*jList)[0].Name)
+	})
+	t.Run("DeleteById_NoJobs_Returns_NotFoundError", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.DeleteById("")
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, "no jobs in joblist", err.Message())
+		assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	})
+	t.Run("DeleteById_UnknownId_Returns_NotFoundError", func(t *testing.T) {
+		repo := newRepo(t)
+		fillRepo(t, repo)
+		id := ksuid.New().String()
+
+		err := repo.DeleteById(id)
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, fmt.Sprintf("no job with id %v in joblist", id), err.Message())
+		assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	})
+	t.Run("DeleteById_Returns_NoError", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRepo(t, repo)
+
+		deleteErr := repo.DeleteById(id)
+		job, findErr := repo.FindById(id)
+		jList, _ := repo.FindAll("")
+
+		assert.Nil(t, deleteErr)
+		assert.NotNil(t, findErr)
+		assert.Nil(t, job)
+		assert.EqualValues(t, 1, len(*jList))
+	})
+	t.Run("ClaimNext_NoJobs_Returns_NotFoundError", func(t *testing.T) {
+		repo := newRepo(t)
+
+		job, err := repo.ClaimNext("worker-1")
+
+		assert.Nil(t, job)
+		assert.NotNil(t, err)
+		assert.EqualValues(t, "no jobs in joblist", err.Message())
+		assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	})
+	t.Run("ClaimNext_NoCreatedJobs_Returns_NotFoundError", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRepo(t, repo)
+		repo.SetStatus(id, JobStatusUpdate{newStatus: JobStatusCancelled, errMsg: "why-was-I-cancelled"})
+
+		job, err := repo.ClaimNext("worker-1")
+
+		assert.Nil(t, job)
+		assert.NotNil(t, err)
+		assert.EqualValues(t, "no jobs with status created in joblist", err.Message())
+		assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	})
+	t.Run("ClaimNext_Returns_OldestCreatedJob", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRepo(t, repo)
+		newer, _ := NewJob("job 3", "url 3")
+		newer.CreatedAt = newer.CreatedAt.Add(1 * time.Second)
+		repo.Save(*newer)
+
+		job, err := repo.ClaimNext("worker-1")
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, id, job.Id.String())
+	})
+	t.Run("ClaimNext_Marks_JobRunningAndClaimed", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRepo(t, repo)
+
+		job, err := repo.ClaimNext("worker-1")
+		stored, _ := repo.FindById(id)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, JobStatusRunning, job.Status)
+		assert.EqualValues(t, "worker-1", job.ClaimedBy)
+		assert.EqualValues(t, JobStatusRunning, stored.Status)
+		assert.EqualValues(t, "worker-1", stored.ClaimedBy)
+	})
+	t.Run("ClaimNext_ClaimedJob_IsNotClaimedAgain", func(t *testing.T) {
+		repo := newRepo(t)
+		fillRepo(t, repo)
+
+		first, firstErr := repo.ClaimNext("worker-1")
+		second, secondErr := repo.ClaimNext("worker-2")
+
+		assert.NotNil(t, first)
+		assert.Nil(t, firstErr)
+		assert.Nil(t, second)
+		assert.NotNil(t, secondErr)
+		assert.EqualValues(t, "no jobs with status created in joblist", secondErr.Message())
+	})
+	t.Run("ClaimNext_ProcessingPaused_Returns_UnavailableError", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRepo(t, repo)
+		repo.SetProcessingPaused(true)
+
+		job, err := repo.ClaimNext("worker-1")
+		stored, _ := repo.FindById(id)
+
+		assert.Nil(t, job)
+		assert.NotNil(t, err)
+		assert.EqualValues(t, "processing is paused", err.Message())
+		assert.EqualValues(t, http.StatusServiceUnavailable, err.StatusCode())
+		assert.EqualValues(t, JobStatusCreated, stored.Status)
+	})
+	t.Run("ClaimNext_ProcessingResumed_Returns_Job", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRepo(t, repo)
+		repo.SetProcessingPaused(true)
+		repo.SetProcessingPaused(false)
+
+		job, err := repo.ClaimNext("worker-1")
+		paused, pausedErr := repo.IsProcessingPaused()
+
+		assert.Nil(t, err)
+		assert.Nil(t, pausedErr)
+		assert.False(t, paused)
+		assert.EqualValues(t, id, job.Id.String())
+	})
+	t.Run("ClaimNext_Increments_Attempts", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRepo(t, repo)
+
+		job, err := repo.ClaimNext("worker-1")
+		stored, _ := repo.FindById(id)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 1, job.Attempts)
+		assert.EqualValues(t, 1, stored.Attempts)
+	})
+	t.Run("ClaimNext_JobInBackoff_IsSkipped", func(t *testing.T) {
+		repo := newRepo(t)
+		waiting, _ := NewJob("job 1", "url 1")
+		waiting.NextAttemptAt = waiting.CreatedAt.Add(1 * time.Hour)
+		due, _ := NewJob("job 2", "url 2")
+		due.CreatedAt = waiting.CreatedAt.Add(1 * time.Millisecond)
+		repo.Save(*waiting)
+		repo.Save(*due)
+
+		first, firstErr := repo.ClaimNext("worker-1")
+		second, secondErr := repo.ClaimNext("worker-1")
+
+		assert.Nil(t, firstErr)
+		assert.EqualValues(t, due.Id, first.Id)
+		assert.Nil(t, second)
+		assert.NotNil(t, secondErr)
+		assert.EqualValues(t, "no jobs with status created in joblist", secondErr.Message())
+	})
+	t.Run("Save_Keeps_ErrorHistory", func(t *testing.T) {
+		repo := newRepo(t)
+		job, _ := NewJob("job 1", "url 1")
+		job.Status = JobStatusRunning
+		job.Attempts = 2
+		job.RecordFailure(ErrorCodeNetwork, "storage unreachable", RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: time.Minute})
+		repo.Save(*job)
+
+		stored, err := repo.FindById(job.Id.String())
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, JobStatusCreated, stored.Status)
+		assert.EqualValues(t, 2, stored.Attempts)
+		assert.EqualValues(t, 1, len(stored.ErrorHistory))
+		assert.EqualValues(t, ErrorCodeNetwork, stored.ErrorCode)
+		assert.EqualValues(t, "storage unreachable", stored.ErrorHistory[0].ErrorMsg)
+		assert.True(t, stored.NextAttemptAt.After(job.CreatedAt))
+	})
+	t.Run("Update_StatusUnchanged_Saves_Job", func(t *testing.T) {
+		repo := newRepo(t)
+		job, _ := NewJob("job 1", "url 1")
+		job.Status = JobStatusRunning
+		repo.Save(*job)
+		job.RecordFailure(ErrorCodeUnsupportedMedia, "no streams", RetryPolicy{MaxAttempts: 5})
+
+		err := repo.Update(*job, JobStatusRunning)
+		stored, _ := repo.FindById(job.Id.String())
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, JobStatusFailed, stored.Status)
+		assert.EqualValues(t, ErrorCodeUnsupportedMedia, stored.ErrorCode)
+	})
+	t.Run("Update_StatusChanged_Returns_ConflictError", func(t *testing.T) {
+		repo := newRepo(t)
+		job, _ := NewJob("job 1", "url 1")
+		job.Status = JobStatusCancelled
+		repo.Save(*job)
+		job.Status = JobStatusFailed
+
+		err := repo.Update(*job, JobStatusRunning)
+		stored, _ := repo.FindById(job.Id.String())
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+		assert.EqualValues(t, JobStatusCancelled, stored.Status)
+	})
+	t.Run("Update_NoJob_Returns_NotFoundError", func(t *testing.T) {
+		repo := newRepo(t)
+		job, _ := NewJob("job 1", "url 1")
+
+		err := repo.Update(*job, JobStatusRunning)
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	})
+	t.Run("ClaimNext_ConcurrentWorkers_ClaimEachJobOnce", func(t *testing.T) {
+		repo := newRepo(t)
+		jobCount := 20
+		for i := 0; i < jobCount; i++ {
+			job, _ := NewJob(fmt.Sprintf("job %d", i), "url")
+			repo.Save(*job)
+		}
+		claimed := make(chan string, jobCount*2)
+		var wg sync.WaitGroup
+		for w := 0; w < 5; w++ {
+			wg.Add(1)
+			go func(workerId string) {
+				defer wg.Done()
+				for {
+					job, err := repo.ClaimNext(workerId)
+					if err != nil {
+						return
+					}
+					claimed <- job.Id.String()
+				}
+			}(fmt.Sprintf("worker-%d", w))
+		}
+		wg.Wait()
+		close(claimed)
+
+		seen := make(map[string]bool)
+		for id := range claimed {
+			assert.False(t, seen[id], "job %v claimed twice", id)
+			seen[id] = true
+		}
+		running, _ := repo.FindAll(string(JobStatusRunning))
+		assert.EqualValues(t, jobCount, len(seen))
+		assert.EqualValues(t, jobCount, len(*running))
+	})
+	t.Run("SetStatus_NoJob_Returns_NotFoundError", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.SetStatus("", JobStatusUpdate{})
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, "no jobs in joblist", err.Message())
+		assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	})
+	t.Run("SetStatus_Returns_NoError", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRepo(t, repo)
+		newStatus := JobStatusUpdate{newStatus: JobStatusCancelled, errMsg: "why-was-I-cancelled", changedBy: "10.0.0.1"}
+
+		err := repo.SetStatus(id, newStatus)
+		job, _ := repo.FindById(id)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, newStatus.newStatus, job.Status)
+		assert.EqualValues(t, newStatus.errMsg, job.ErrorMsg)
+		assert.EqualValues(t, 1, len(job.StatusHistory))
+		assert.EqualValues(t, JobStatusCreated, job.StatusHistory[0].From)
+		assert.EqualValues(t, JobStatusCancelled, job.StatusHistory[0].To)
+		assert.EqualValues(t, "10.0.0.1", job.StatusHistory[0].ChangedBy)
+	})
+	t.Run("SetStatus_IllegalTransition_Returns_ConflictError", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRepo(t, repo)
+		repo.SetStatus(id, JobStatusUpdate{newStatus: JobStatusCancelled})
+
+		err := repo.SetStatus(id, JobStatusUpdate{newStatus: JobStatusFinished})
+		job, _ := repo.FindById(id)
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+		assert.EqualValues(t, fmt.Sprintf("Cannot change status of job %v from cancelled to finished", id), err.Message())
+		assert.EqualValues(t, JobStatusCancelled, job.Status)
+	})
+	t.Run("ClaimNext_Records_Transition", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRepo(t, repo)
+
+		repo.ClaimNext("worker-1")
+		job, _ := repo.FindById(id)
+
+		assert.EqualValues(t, 1, len(job.StatusHistory))
+		assert.EqualValues(t, JobStatusRunning, job.StatusHistory[0].To)
+		assert.EqualValues(t, "worker-1", job.StatusHistory[0].ChangedBy)
+	})
+	t.Run("ClaimNext_QueuedJob_IsClaimedOnceDue", func(t *testing.T) {
+		repo := newRepo(t)
+		job, _ := NewJob("job 1", "url 1")
+		job.Status = JobStatusQueued
+		job.NextAttemptAt = job.CreatedAt.Add(-1 * time.Second)
+		repo.Save(*job)
+
+		claimed, err := repo.ClaimNext("worker-1")
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, job.Id, claimed.Id)
+		assert.EqualValues(t, JobStatusQueued, claimed.StatusHistory[0].From)
+		assert.EqualValues(t, JobStatusRunning, claimed.StatusHistory[0].To)
+	})
+	t.Run("ClaimNext_ScheduledJob_IsSkipped", func(t *testing.T) {
+		repo := newRepo(t)
+		job, _ := NewJob("job 1", "url 1")
+		job.ScheduleAt(job.CreatedAt.Add(1 * time.Hour))
+		repo.Save(*job)
+
+		claimed, err := repo.ClaimNext("worker-1")
+
+		assert.Nil(t, claimed)
+		assert.NotNil(t, err)
+		assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	})
+	t.Run("ClaimNext_PausedJob_IsSkipped", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRepo(t, repo)
+		repo.SetStatus(id, JobStatusUpdate{newStatus: JobStatusPaused})
+
+		claimed, err := repo.ClaimNext("worker-1")
+
+		assert.Nil(t, claimed)
+		assert.NotNil(t, err)
+		assert.EqualValues(t, "no jobs with status created in joblist", err.Message())
+	})
+	t.Run("SetMediaInfoResult_Returns_NoError", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRunningJob(t, repo)
+		result, _ := ParseMediaInfoResult(testMediaInfoOutput)
+
+		err := repo.SetMediaInfoResult(id, *result)
+		job, _ := repo.FindById(id)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, result, job.MediaInfo)
+		assert.Nil(t, job.ProbeResult)
+	})
+	t.Run("SetDeepAnalysis_Returns_NoError", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRunningJob(t, repo)
+		analysis := DeepAnalysis{Streams: []StreamAnalysis{{Index: 0, CodecType: "video", Packets: 2, BitrateSeries: []int64{800}}}}
+
+		err := repo.SetDeepAnalysis(id, analysis)
+		job, _ := repo.FindById(id)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, &analysis, job.DeepAnalysis)
+	})
+	t.Run("SetDeepAnalysis_NoJob_Returns_NotFoundError", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.SetDeepAnalysis("", DeepAnalysis{})
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	})
+	t.Run("Save_Keeps_Engines", func(t *testing.T) {
+		repo := newRepo(t)
+		job, _ := NewJob("job 1", "url 1")
+		job.Engines = ProbeEngines{EngineFfprobe, EngineMediaInfo}
+		job.Profile = "chapters"
+		job.Mode = JobModeDeep
+		repo.Save(*job)
+
+		stored, err := repo.FindById(job.Id.String())
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, job.Engines, stored.Engines)
+		assert.EqualValues(t, "chapters", stored.Profile)
+		assert.EqualValues(t, JobModeDeep, stored.Mode)
+		assert.Nil(t, stored.DeepAnalysis)
+	})
+	t.Run("SetResult_NoJob_Returns_NotFoundError", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.SetResult("", "new data", ProbeResult{})
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, "no jobs in joblist", err.Message())
+		assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	})
+	t.Run("SetResult_NotRunning_Returns_ConflictError", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRepo(t, repo)
+
+		result, _ := ParseProbeResult(testProbeOutput)
+		err := repo.SetResult(id, testProbeOutput, *result)
+		job, _ := repo.FindById(id)
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+		assert.EqualValues(t, "", job.TechInfo)
+		assert.Nil(t, job.ProbeResult)
+	})
+	t.Run("SetResult_Finished_Returns_ConflictError", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRunningJob(t, repo)
+		repo.SetStatus(id, JobStatusUpdate{newStatus: JobStatusFinished})
+
+		err := repo.SetResult(id, testProbeOutput, ProbeResult{})
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+	})
+	t.Run("SetMediaInfoResult_NotRunning_Returns_ConflictError", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRepo(t, repo)
+
+		err := repo.SetMediaInfoResult(id, MediaInfoResult{})
+		job, _ := repo.FindById(id)
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+		assert.Nil(t, job.MediaInfo)
+	})
+	t.Run("SetDeepAnalysis_NotRunning_Returns_ConflictError", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRepo(t, repo)
+
+		err := repo.SetDeepAnalysis(id, DeepAnalysis{})
+		job, _ := repo.FindById(id)
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+		assert.Nil(t, job.DeepAnalysis)
+	})
+	t.Run("SetResult_Returns_NoError", func(t *testing.T) {
+		repo := newRepo(t)
+		id := fillRunningJob(t, repo)
+
+		result, _ := ParseProbeResult(testProbeOutput)
+		err := repo.SetResult(id, testProbeOutput, *result)
+		job, _ := repo.FindById(id)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, testProbeOutput, job.TechInfo)
+		assert.EqualValues(t, result, job.ProbeResult)
+	})
+}
+
+func Test_JobRepositoryMem_Behaviour(t *testing.T) {
+	runJobRepositoryBehaviour(t, func(t *testing.T) JobRepository {
+		return NewJobRepositoryMem()
+	})
+}
@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/johannes-kuhfuss/probesvc/dto"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/date"
+)
+
+type JobError struct {
+	Attempt    int          `json:"attempt"`
+	OccurredAt time.Time    `json:"occurred_at"`
+	ErrorCode  JobErrorCode `json:"error_code"`
+	ErrorMsg   string       `json:"error_msg"`
+}
+
+// JobErrors is stored as a JSON array in a single column.
+type JobErrors []JobError
+
+func (e JobErrors) Value() (driver.Value, error) {
+	return jsonValue(e)
+}
+
+func (e *JobErrors) Scan(src interface{}) error {
+	return jsonScan(src, e)
+}
+
+func jsonValue(v interface{}) (driver.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		return "[]", nil
+	}
+	return string(data), nil
+}
+
+func jsonScan(src interface{}, dest interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	case []byte:
+		return json.Unmarshal(v, dest)
+	default:
+		return fmt.Errorf("cannot scan type %T into %T", src, dest)
+	}
+}
+
+func (e JobErrors) ToDto() []dto.JobErrorResponse {
+	response := make([]dto.JobErrorResponse, 0, len(e))
+	for _, jobErr := range e {
+		response = append(response, dto.JobErrorResponse{
+			Attempt:    jobErr.Attempt,
+			OccurredAt: jobErr.OccurredAt,
+			ErrorCode:  string(jobErr.ErrorCode),
+			ErrorMsg:   jobErr.ErrorMsg,
+		})
+	}
+	return response
+}
+
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Delay returns the backoff before the next attempt, doubling with every
+// attempt already made and capped at MaxDelay.
+func (p RetryPolicy) Delay(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// RecordFailure adds the error to the job's history and decides how to go on:
+// retryable errors put the job back into the queue after a backoff until
+// MaxAttempts is reached, after which the job is dead-lettered. Timeouts end
+// the job as timed out, all other errors fail it right away.
+func (job *Job) RecordFailure(code JobErrorCode, errMsg string, policy RetryPolicy) api_error.ApiErr {
+	var next JobStatus
+	switch {
+	case code == ErrorCodeTimeout:
+		next = JobStatusTimedOut
+	case !code.IsRetryable():
+		next = JobStatusFailed
+	case job.Attempts >= policy.MaxAttempts:
+		next = JobStatusDeadLetter
+	default:
+		next = JobStatusCreated
+	}
+	if err := job.TransitionTo(next, job.ClaimedBy); err != nil {
+		return err
+	}
+	now := date.GetNowUtc()
+	job.ErrorHistory = append(job.ErrorHistory, JobError{
+		Attempt:    job.Attempts,
+		OccurredAt: now,
+		ErrorCode:  code,
+		ErrorMsg:   errMsg,
+	})
+	job.ErrorCode = code
+	job.ErrorMsg = errMsg
+	if next == JobStatusCreated {
+		job.NextAttemptAt = now.Add(policy.Delay(job.Attempts))
+	}
+	return nil
+}
+
+func (job *Job) ResetAttempts(changedBy string) api_error.ApiErr {
+	if err := job.TransitionTo(JobStatusCreated, changedBy); err != nil {
+		return err
+	}
+	job.Attempts = 0
+	job.NextAttemptAt = date.GetNowUtc()
+	job.ErrorCode = ErrorCodeNone
+	job.ErrorMsg = ""
+	return nil
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Second, MaxDelay: 30 * time.Second}
+)
+
+func runningJob() *Job {
+	job, _ := NewJob("job 1", "url 1")
+	job.Status = JobStatusRunning
+	job.ClaimedBy = "worker-1"
+	return job
+}
+
+func Test_Delay_Doubles_UpToMaxDelay(t *testing.T) {
+	assert.EqualValues(t, 10*time.Second, testPolicy.Delay(1))
+	assert.EqualValues(t, 20*time.Second, testPolicy.Delay(2))
+	assert.EqualValues(t, 30*time.Second, testPolicy.Delay(3))
+	assert.EqualValues(t, 30*time.Second, testPolicy.Delay(50))
+}
+
+func Test_RecordFailure_NotRetryable_FailsJob(t *testing.T) {
+	job := runningJob()
+	job.Attempts = 1
+
+	job.RecordFailure(ErrorCodeStorageNotFound, "file not found", testPolicy)
+
+	assert.EqualValues(t, JobStatusFailed, job.Status)
+	assert.EqualValues(t, ErrorCodeStorageNotFound, job.ErrorCode)
+	assert.EqualValues(t, "file not found", job.ErrorMsg)
+	assert.EqualValues(t, 1, len(job.ErrorHistory))
+	assert.EqualValues(t, 1, job.ErrorHistory[0].Attempt)
+}
+
+func Test_RecordFailure_Retryable_RequeuesWithBackoff(t *testing.T) {
+	job := runningJob()
+	job.Attempts = 2
+	before := time.Now().UTC()
+
+	job.RecordFailure(ErrorCodeNetwork, "connection reset", testPolicy)
+
+	assert.EqualValues(t, JobStatusCreated, job.Status)
+	assert.True(t, job.NextAttemptAt.After(before.Add(19*time.Second)))
+	assert.EqualValues(t, 1, len(job.ErrorHistory))
+}
+
+func Test_RecordFailure_Timeout_TimesOutJob(t *testing.T) {
+	job := runningJob()
+
+	job.RecordFailure(ErrorCodeTimeout, "took too long", testPolicy)
+
+	assert.EqualValues(t, JobStatusTimedOut, job.Status)
+	assert.EqualValues(t, ErrorCodeTimeout, job.ErrorHistory[0].ErrorCode)
+}
+
+func Test_RecordFailure_AttemptsExhausted_DeadLettersJob(t *testing.T) {
+	job := runningJob()
+	job.Attempts = 3
+
+	job.RecordFailure(ErrorCodeNetwork, "connection reset", testPolicy)
+
+	assert.EqualValues(t, JobStatusDeadLetter, job.Status)
+}
+
+func Test_ResetAttempts_Requeues_Job(t *testing.T) {
+	job := runningJob()
+	job.Attempts = 3
+	job.RecordFailure(ErrorCodeNetwork, "connection reset", testPolicy)
+
+	err := job.ResetAttempts("10.0.0.1")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, JobStatusCreated, job.Status)
+	assert.EqualValues(t, 0, job.Attempts)
+	assert.EqualValues(t, ErrorCodeNone, job.ErrorCode)
+	assert.EqualValues(t, "", job.ErrorMsg)
+	assert.EqualValues(t, 1, len(job.ErrorHistory))
+}
+
+func Test_JobErrors_ValueAndScan_RoundTrip(t *testing.T) {
+	errs := JobErrors{{Attempt: 1, OccurredAt: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC), ErrorCode: ErrorCodeNetwork, ErrorMsg: "boom"}}
+
+	value, err := errs.Value()
+	var scanned JobErrors
+	scanErr := scanned.Scan(value)
+
+	assert.Nil(t, err)
+	assert.Nil(t, scanErr)
+	assert.EqualValues(t, errs, scanned)
+}
+
+func Test_RecordFailure_FinishedJob_Returns_ConflictError(t *testing.T) {
+	job := runningJob()
+	job.Status = JobStatusFinished
+
+	err := job.RecordFailure(ErrorCodeNetwork, "connection reset", testPolicy)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+	assert.EqualValues(t, JobStatusFinished, job.Status)
+	assert.EqualValues(t, 0, len(job.ErrorHistory))
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/johannes-kuhfuss/probesvc/dto"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/date"
+)
+
+// allowedTransitions lists for every status the statuses a job may move to.
+// Jobs that ended other than finished can be put back into the queue.
+var allowedTransitions = map[JobStatus][]JobStatus{
+	JobStatusCreated:    {JobStatusQueued, JobStatusRunning, JobStatusPaused, JobStatusCancelled},
+	JobStatusQueued:     {JobStatusCreated, JobStatusRunning, JobStatusPaused, JobStatusCancelled},
+	JobStatusRunning:    {JobStatusCreated, JobStatusFinished, JobStatusFailed, JobStatusCancelled, JobStatusTimedOut, JobStatusDeadLetter},
+	JobStatusPaused:     {JobStatusCreated, JobStatusQueued, JobStatusCancelled},
+	JobStatusFinished:   {},
+	JobStatusFailed:     {JobStatusCreated},
+	JobStatusCancelled:  {JobStatusCreated},
+	JobStatusTimedOut:   {JobStatusCreated},
+	JobStatusDeadLetter: {JobStatusCreated},
+}
+
+type JobTransition struct {
+	From      JobStatus `json:"from"`
+	To        JobStatus `json:"to"`
+	ChangedBy string    `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// JobTransitions is stored as a JSON array in a single column.
+type JobTransitions []JobTransition
+
+func (t JobTransitions) Value() (driver.Value, error) {
+	return jsonValue(t)
+}
+
+func (t *JobTransitions) Scan(src interface{}) error {
+	return jsonScan(src, t)
+}
+
+func (t JobTransitions) ToDto() []dto.JobTransitionResponse {
+	response := make([]dto.JobTransitionResponse, 0, len(t))
+	for _, transition := range t {
+		response = append(response, dto.JobTransitionResponse{
+			From:      string(transition.From),
+			To:        string(transition.To),
+			ChangedBy: transition.ChangedBy,
+			ChangedAt: transition.ChangedAt,
+		})
+	}
+	return response
+}
+
+func (from JobStatus) CanTransitionTo(to JobStatus) bool {
+	for _, allowed := range allowedTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTo moves the job to the new status and records the change. It
+// returns a conflict error if the current status does not allow the change.
+func (job *Job) TransitionTo(to JobStatus, changedBy string) api_error.ApiErr {
+	if !job.Status.CanTransitionTo(to) {
+		return api_error.NewProcessingConflictError(fmt.Sprintf("Cannot change status of job %v from %v to %v", job.Id, job.Status, to))
+	}
+	job.StatusHistory = append(job.StatusHistory, JobTransition{
+		From:      job.Status,
+		To:        to,
+		ChangedBy: changedBy,
+		ChangedAt: date.GetNowUtc(),
+	})
+	job.Status = to
+	return nil
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CanTransitionTo_Returns_AllowedTransitions(t *testing.T) {
+	assert.True(t, JobStatusCreated.CanTransitionTo(JobStatusRunning))
+	assert.True(t, JobStatusRunning.CanTransitionTo(JobStatusFinished))
+	assert.True(t, JobStatusFailed.CanTransitionTo(JobStatusCreated))
+	assert.False(t, JobStatusFinished.CanTransitionTo(JobStatusRunning))
+	assert.False(t, JobStatusFailed.CanTransitionTo(JobStatusFinished))
+	assert.False(t, JobStatusCreated.CanTransitionTo(JobStatusFinished))
+	assert.False(t, JobStatusRunning.CanTransitionTo(JobStatusRunning))
+}
+
+func Test_TransitionTo_IllegalTransition_Returns_ConflictError(t *testing.T) {
+	job, _ := NewJob("job 1", "url 1")
+	job.Status = JobStatusFinished
+
+	err := job.TransitionTo(JobStatusRunning, "worker-1")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+	assert.EqualValues(t, JobStatusFinished, job.Status)
+	assert.EqualValues(t, 0, len(job.StatusHistory))
+}
+
+func Test_TransitionTo_Records_Transition(t *testing.T) {
+	job, _ := NewJob("job 1", "url 1")
+
+	err := job.TransitionTo(JobStatusRunning, "worker-1")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, JobStatusRunning, job.Status)
+	assert.EqualValues(t, 1, len(job.StatusHistory))
+	assert.EqualValues(t, JobStatusCreated, job.StatusHistory[0].From)
+	assert.EqualValues(t, JobStatusRunning, job.StatusHistory[0].To)
+	assert.EqualValues(t, "worker-1", job.StatusHistory[0].ChangedBy)
+}
+
+func Test_JobTransitions_ValueAndScan_RoundTrip(t *testing.T) {
+	job, _ := NewJob("job 1", "url 1")
+	job.TransitionTo(JobStatusRunning, "worker-1")
+
+	value, err := job.StatusHistory.Value()
+	var scanned JobTransitions
+	scanErr := scanned.Scan(value)
+
+	assert.Nil(t, err)
+	assert.Nil(t, scanErr)
+	assert.EqualValues(t, job.StatusHistory, scanned)
+}
@@ -63,6 +63,7 @@ func Test_NewJob_NoName_Returns_NewJob(t *testing.T) {
 	assert.EqualValues(t, JobStatusCreated, newJob.Status)
 	assert.Empty(t, newJob.ErrorMsg)
 	assert.Empty(t, newJob.TechInfo)
+	assert.Empty(t, newJob.ClaimedBy)
 }
 
 func Test_NewJob_WithName_Returns_NewJob(t *testing.T) {
@@ -72,6 +73,28 @@ func Test_NewJob_WithName_Returns_NewJob(t *testing.T) {
 	assert.EqualValues(t, "my new job", newJob.Name)
 }
 
+func Test_ScheduleAt_FutureStart_Queues_Job(t *testing.T) {
+	job, _ := NewJob("job", "url")
+	startAt := job.CreatedAt.Add(1 * time.Hour)
+
+	job.ScheduleAt(startAt)
+
+	assert.EqualValues(t, JobStatusQueued, job.Status)
+	assert.EqualValues(t, startAt, job.NextAttemptAt)
+	assert.False(t, job.IsClaimable(job.CreatedAt))
+	assert.True(t, job.IsClaimable(startAt))
+}
+
+func Test_ScheduleAt_PastStart_Keeps_JobCreated(t *testing.T) {
+	job, _ := NewJob("job", "url")
+	createdAt := job.NextAttemptAt
+
+	job.ScheduleAt(job.CreatedAt.Add(-1 * time.Hour))
+
+	assert.EqualValues(t, JobStatusCreated, job.Status)
+	assert.EqualValues(t, createdAt, job.NextAttemptAt)
+}
+
 func Test_JobToDto_Returns_JobDto(t *testing.T) {
 	now := date.GetNowUtc()
 	newJob, _ := NewJob("my new job", validSrcUrl)
@@ -86,7 +109,9 @@ func Test_JobToDto_Returns_JobDto(t *testing.T) {
 	assert.EqualValues(t, validSrcUrl, newJobDto.SrcUrl)
 	assert.EqualValues(t, JobStatusCreated, newJobDto.Status)
 	assert.EqualValues(t, "", newJobDto.ErrorMsg)
-	assert.EqualValues(t, "", newJobDto.TechInfo)
+	assert.Nil(t, newJobDto.TechInfo)
+	assert.Nil(t, newJobDto.Summary)
+	assert.EqualValues(t, "", newJobDto.ClaimedBy)
 }
 
 func Test_ParseStatusRequest_WrongStatus_Returns_Badrequest(t *testing.T) {
@@ -179,3 +204,60 @@ func Test_ParseStatusRequest_Failed_Returns_NoError(t *testing.T) {
 	assert.EqualValues(t, JobStatusFailed, jobUpd.newStatus)
 	assert.EqualValues(t, request.ErrMsg, jobUpd.errMsg)
 }
+
+func Test_ParseStatusRequest_Cancelled_Returns_NoError(t *testing.T) {
+	request := dto.JobStatusUpdateRequest{
+		Status: "cancelled",
+		ErrMsg: "cancelled by user",
+	}
+	jobUpd, err := ParseStatusRequest(request)
+
+	assert.NotNil(t, jobUpd)
+	assert.Nil(t, err)
+	assert.EqualValues(t, JobStatusCancelled, jobUpd.newStatus)
+	assert.EqualValues(t, request.ErrMsg, jobUpd.errMsg)
+}
+
+func Test_ParseStatusRequest_TimedOut_Returns_NoError(t *testing.T) {
+	request := dto.JobStatusUpdateRequest{
+		Status: "timed_out",
+		ErrMsg: "took too long",
+	}
+	jobUpd, err := ParseStatusRequest(request)
+
+	assert.NotNil(t, jobUpd)
+	assert.Nil(t, err)
+	assert.EqualValues(t, JobStatusTimedOut, jobUpd.newStatus)
+	assert.EqualValues(t, request.ErrMsg, jobUpd.errMsg)
+}
+
+func Test_IsFinal_Returns_TrueForEndedStatus(t *testing.T) {
+	assert.False(t, JobStatusCreated.IsFinal())
+	assert.False(t, JobStatusRunning.IsFinal())
+	assert.True(t, JobStatusFinished.IsFinal())
+	assert.True(t, JobStatusFailed.IsFinal())
+	assert.True(t, JobStatusCancelled.IsFinal())
+	assert.True(t, JobStatusTimedOut.IsFinal())
+}
+
+func Test_HasResults_Requires_ResultOfEachEngine(t *testing.T) {
+	job, _ := NewJob("job 1", "url 1")
+	job.Engines = ProbeEngines{EngineFfprobe, EngineMediaInfo}
+	assert.False(t, job.HasResults())
+
+	job.TechInfo = "{}"
+	assert.False(t, job.HasResults())
+
+	job.MediaInfo = &MediaInfoResult{}
+	assert.True(t, job.HasResults())
+}
+
+func Test_HasResults_DeepMode_Requires_DeepAnalysis(t *testing.T) {
+	job, _ := NewJob("job 1", "url 1")
+	job.Mode = JobModeDeep
+	job.TechInfo = "{}"
+	assert.False(t, job.HasResults())
+
+	job.DeepAnalysis = &DeepAnalysis{}
+	assert.True(t, job.HasResults())
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/johannes-kuhfuss/probesvc/dto"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+// MediaInfoResult mirrors the JSON document the MediaInfo CLI prints for
+// --Output=JSON. MediaInfo reports every value as a string and the fields
+// present depend on the track type, so tracks keep all of their fields.
+type MediaInfoResult struct {
+	Media MediaInfoMedia `json:"media"`
+}
+
+type MediaInfoMedia struct {
+	Ref    string           `json:"@ref"`
+	Tracks []MediaInfoTrack `json:"track"`
+}
+
+type MediaInfoTrack map[string]interface{}
+
+func (t MediaInfoTrack) Type() string {
+	trackType, _ := t["@type"].(string)
+	return trackType
+}
+
+func ParseMediaInfoResult(data string) (*MediaInfoResult, api_error.ApiErr) {
+	var result MediaInfoResult
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		return nil, api_error.NewValidationError(fmt.Sprintf("result is not valid MediaInfo output: %v", err))
+	}
+	return &result, nil
+}
+
+func (r MediaInfoResult) Value() (driver.Value, error) {
+	return jsonValue(r)
+}
+
+func (r *MediaInfoResult) Scan(src interface{}) error {
+	return jsonScan(src, r)
+}
+
+func (r MediaInfoResult) ToDto() dto.MediaInfoResponse {
+	tracks := make([]map[string]interface{}, 0, len(r.Media.Tracks))
+	for _, track := range r.Media.Tracks {
+		tracks = append(tracks, track)
+	}
+	return dto.MediaInfoResponse{
+		Media: dto.MediaInfoMediaResponse{
+			Ref:    r.Media.Ref,
+			Tracks: tracks,
+		},
+	}
+}
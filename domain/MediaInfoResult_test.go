@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testMediaInfoOutput = `{
+    "creatingLibrary": {"name": "MediaLib", "version": "23.04"},
+    "media": {
+        "@ref": "",
+        "track": [
+            {"@type": "General", "Format": "MXF", "Format_Profile": "OP-1a", "Duration": "10.000"},
+            {"@type": "Video", "Format": "AVC", "Width": "1920", "Height": "1080", "extra": {"Origin": "Header"}},
+            {"@type": "Audio", "Format": "PCM", "Channels": "1", "Language": "de"}
+        ]
+    }
+}`
+
+func Test_ParseMediaInfoResult_InvalidOutput_Returns_ValidationError(t *testing.T) {
+	result, err := ParseMediaInfoResult(`{"media": {"track": {}}}`)
+
+	assert.Nil(t, result)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusUnprocessableEntity, err.StatusCode())
+}
+
+func Test_ParseMediaInfoResult_Returns_Tracks(t *testing.T) {
+	result, err := ParseMediaInfoResult(testMediaInfoOutput)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 3, len(result.Media.Tracks))
+	assert.EqualValues(t, "General", result.Media.Tracks[0].Type())
+	assert.EqualValues(t, "OP-1a", result.Media.Tracks[0]["Format_Profile"])
+	assert.EqualValues(t, "Header", result.Media.Tracks[1]["extra"].(map[string]interface{})["Origin"])
+}
+
+func Test_MediaInfoResult_ValueAndScan_RoundTrip(t *testing.T) {
+	result, _ := ParseMediaInfoResult(testMediaInfoOutput)
+	var scanned MediaInfoResult
+
+	value, _ := result.Value()
+	err := scanned.Scan(value)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, *result, scanned)
+	assert.EqualValues(t, "Audio", scanned.ToDto().Media.Tracks[2]["@type"])
+}
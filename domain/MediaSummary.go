@@ -0,0 +1,277 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/johannes-kuhfuss/probesvc/dto"
+)
+
+type MediaSummary struct {
+	Container        string
+	DurationSeconds  float64
+	DurationTimecode string
+	Video            *VideoSummary
+	Audio            []AudioSummary
+}
+
+type VideoSummary struct {
+	Codec              string
+	Profile            string
+	Width              int
+	Height             int
+	DisplayAspectRatio string
+	FrameRate          FrameRate
+	ScanType           string
+	FieldOrder         string
+	BitDepth           int
+	ColourPrimaries    string
+	HdrType            string
+}
+
+type FrameRate struct {
+	Rational string
+	Decimal  float64
+}
+
+type AudioSummary struct {
+	Index         int
+	Codec         string
+	Channels      int
+	ChannelLayout string
+	SampleRate    int
+	Language      string
+}
+
+// Summary condenses the probe output into the values downstream systems
+// need, without ffprobe's rationals, field order codes or "N/A" placeholders.
+func (r ProbeResult) Summary() MediaSummary {
+	summary := MediaSummary{
+		Container:       containerName(r.Format),
+		DurationSeconds: r.durationSeconds(),
+		Audio:           make([]AudioSummary, 0),
+	}
+	for _, stream := range r.Streams {
+		switch stream.CodecType {
+		case "video":
+			if summary.Video == nil && stream.Disposition.AttachedPic == 0 {
+				video := videoSummary(stream)
+				summary.Video = &video
+			}
+		case "audio":
+			summary.Audio = append(summary.Audio, audioSummary(stream))
+		}
+	}
+	var frameRate FrameRate
+	if summary.Video != nil {
+		frameRate = summary.Video.FrameRate
+	}
+	summary.DurationTimecode = timecode(summary.DurationSeconds, frameRate.Decimal)
+	return summary
+}
+
+func containerName(format ProbeFormat) string {
+	names := strings.Split(format.FormatName, ",")
+	switch {
+	case strings.HasPrefix(format.FormatName, "mov,mp4"):
+		if strings.TrimSpace(format.Tags["major_brand"]) == "qt" {
+			return "mov"
+		}
+		return "mp4"
+	case strings.HasPrefix(format.FormatName, "matroska"):
+		return "matroska"
+	default:
+		return names[0]
+	}
+}
+
+func (r ProbeResult) durationSeconds() float64 {
+	if duration, ok := parseFloat(r.Format.Duration); ok {
+		return duration
+	}
+	var longest float64
+	for _, stream := range r.Streams {
+		if duration, ok := parseFloat(stream.Duration); ok && duration > longest {
+			longest = duration
+		}
+	}
+	return longest
+}
+
+func videoSummary(stream ProbeStream) VideoSummary {
+	scanType, fieldOrder := scanType(stream.FieldOrder)
+	return VideoSummary{
+		Codec:              stream.CodecName,
+		Profile:            stream.Profile,
+		Width:              stream.Width,
+		Height:             stream.Height,
+		DisplayAspectRatio: displayAspectRatio(stream),
+		FrameRate:          frameRate(stream),
+		ScanType:           scanType,
+		FieldOrder:         fieldOrder,
+		BitDepth:           bitDepth(stream),
+		ColourPrimaries:    stream.ColorPrimaries,
+		HdrType:            hdrType(stream),
+	}
+}
+
+func audioSummary(stream ProbeStream) AudioSummary {
+	sampleRate, _ := strconv.Atoi(stream.SampleRate)
+	language := stream.Tags["language"]
+	if language == "und" {
+		language = ""
+	}
+	return AudioSummary{
+		Index:         stream.Index,
+		Codec:         stream.CodecName,
+		Channels:      stream.Channels,
+		ChannelLayout: stream.ChannelLayout,
+		SampleRate:    sampleRate,
+		Language:      language,
+	}
+}
+
+func displayAspectRatio(stream ProbeStream) string {
+	if rat, ok := parseRational(stream.DisplayAspectRatio); ok {
+		return rat.Num().String() + ":" + rat.Denom().String()
+	}
+	if stream.Width == 0 || stream.Height == 0 {
+		return ""
+	}
+	dar := big.NewRat(int64(stream.Width), int64(stream.Height))
+	if sar, ok := parseRational(stream.SampleAspectRatio); ok {
+		dar.Mul(dar, sar)
+	}
+	return dar.Num().String() + ":" + dar.Denom().String()
+}
+
+func frameRate(stream ProbeStream) FrameRate {
+	for _, rate := range []string{stream.AvgFrameRate, stream.RFrameRate} {
+		if rat, ok := parseRational(rate); ok {
+			decimal, _ := rat.Float64()
+			return FrameRate{
+				Rational: rat.Num().String() + "/" + rat.Denom().String(),
+				Decimal:  math.Round(decimal*1000) / 1000,
+			}
+		}
+	}
+	return FrameRate{}
+}
+
+// scanType maps ffprobe's field order codes, which name the coded field
+// first and the displayed field second, to a scan type and display order.
+func scanType(fieldOrder string) (string, string) {
+	switch fieldOrder {
+	case "progressive":
+		return "progressive", ""
+	case "tt", "bt":
+		return "interlaced", "top_field_first"
+	case "bb", "tb":
+		return "interlaced", "bottom_field_first"
+	default:
+		return "unknown", ""
+	}
+}
+
+func bitDepth(stream ProbeStream) int {
+	if depth, err := strconv.Atoi(stream.BitsPerRawSample); err == nil && depth > 0 {
+		return depth
+	}
+	for _, depth := range []int{16, 14, 12, 10, 9} {
+		if strings.Contains(stream.PixFmt, fmt.Sprintf("p%d", depth)) {
+			return depth
+		}
+	}
+	if stream.PixFmt != "" {
+		return 8
+	}
+	return 0
+}
+
+func hdrType(stream ProbeStream) string {
+	for _, sideData := range stream.SideData {
+		switch sideData.Type() {
+		case "DOVI configuration record":
+			return "Dolby Vision"
+		case "HDR Dynamic Metadata SMPTE2094-40 (HDR10+)":
+			return "HDR10+"
+		}
+	}
+	switch stream.ColorTransfer {
+	case "smpte2084":
+		return "HDR10"
+	case "arib-std-b67":
+		return "HLG"
+	default:
+		return "SDR"
+	}
+}
+
+// timecode renders a duration as HH:MM:SS:FF at the nominal frame rate, or
+// as HH:MM:SS.mmm when there is no video stream to count frames in.
+func timecode(seconds float64, fps float64) string {
+	whole := int(seconds)
+	hours, minutes, secs := whole/3600, (whole%3600)/60, whole%60
+	fraction := seconds - float64(whole)
+	if fps > 0 {
+		frames := int(fraction * math.Round(fps))
+		return fmt.Sprintf("%02d:%02d:%02d:%02d", hours, minutes, secs, frames)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", hours, minutes, secs, int(math.Round(fraction*1000)))
+}
+
+func parseFloat(value string) (float64, bool) {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, false
+	}
+	return number, true
+}
+
+func parseRational(value string) (*big.Rat, bool) {
+	value = strings.Replace(value, ":", "/", 1)
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok || rat.Sign() <= 0 {
+		return nil, false
+	}
+	return rat, true
+}
+
+func (s MediaSummary) ToDto() dto.MediaSummaryResponse {
+	response := dto.MediaSummaryResponse{
+		Container:        s.Container,
+		DurationSeconds:  s.DurationSeconds,
+		DurationTimecode: s.DurationTimecode,
+		Audio:            make([]dto.AudioSummaryResponse, 0, len(s.Audio)),
+	}
+	if s.Video != nil {
+		response.Video = &dto.VideoSummaryResponse{
+			Codec:              s.Video.Codec,
+			Profile:            s.Video.Profile,
+			Width:              s.Video.Width,
+			Height:             s.Video.Height,
+			DisplayAspectRatio: s.Video.DisplayAspectRatio,
+			FrameRate:          s.Video.FrameRate.Rational,
+			FrameRateDecimal:   s.Video.FrameRate.Decimal,
+			ScanType:           s.Video.ScanType,
+			FieldOrder:         s.Video.FieldOrder,
+			BitDepth:           s.Video.BitDepth,
+			ColourPrimaries:    s.Video.ColourPrimaries,
+			HdrType:            s.Video.HdrType,
+		}
+	}
+	for _, audio := range s.Audio {
+		response.Audio = append(response.Audio, dto.AudioSummaryResponse{
+			Index:         audio.Index,
+			Codec:         audio.Codec,
+			Channels:      audio.Channels,
+			ChannelLayout: audio.ChannelLayout,
+			SampleRate:    audio.SampleRate,
+			Language:      audio.Language,
+		})
+	}
+	return response
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Summary_Returns_NormalizedValues(t *testing.T) {
+	result, _ := ParseProbeResult(testProbeOutput)
+
+	summary := result.Summary()
+
+	assert.EqualValues(t, "mp4", summary.Container)
+	assert.EqualValues(t, 10.0, summary.DurationSeconds)
+	assert.EqualValues(t, "00:00:10:00", summary.DurationTimecode)
+	assert.EqualValues(t, "h264", summary.Video.Codec)
+	assert.EqualValues(t, "High", summary.Video.Profile)
+	assert.EqualValues(t, "16:9", summary.Video.DisplayAspectRatio)
+	assert.EqualValues(t, FrameRate{Rational: "25/1", Decimal: 25}, summary.Video.FrameRate)
+	assert.EqualValues(t, 8, summary.Video.BitDepth)
+	assert.EqualValues(t, "SDR", summary.Video.HdrType)
+	assert.EqualValues(t, 1, len(summary.Audio))
+	assert.EqualValues(t, AudioSummary{Index: 1, Codec: "aac", Channels: 2, ChannelLayout: "stereo", SampleRate: 48000}, summary.Audio[0])
+}
+
+func Test_Summary_InterlacedHdrVideo_Returns_NormalizedValues(t *testing.T) {
+	result := ProbeResult{
+		Format: ProbeFormat{FormatName: "matroska,webm", Duration: "N/A"},
+		Streams: []ProbeStream{
+			{CodecType: "video", Disposition: ProbeDisposition{AttachedPic: 1}, CodecName: "mjpeg"},
+			{
+				CodecType:         "video",
+				CodecName:         "hevc",
+				Width:             720,
+				Height:            576,
+				SampleAspectRatio: "64:45",
+				AvgFrameRate:      "0/0",
+				RFrameRate:        "30000/1001",
+				FieldOrder:        "tb",
+				PixFmt:            "yuv420p10le",
+				ColorPrimaries:    "bt2020",
+				ColorTransfer:     "smpte2084",
+				Duration:          "61.5",
+			},
+			{CodecType: "audio", CodecName: "ac3", Channels: 6, SampleRate: "48000", Tags: map[string]string{"language": "ger"}},
+		},
+	}
+
+	summary := result.Summary()
+
+	assert.EqualValues(t, "matroska", summary.Container)
+	assert.EqualValues(t, 61.5, summary.DurationSeconds)
+	assert.EqualValues(t, "00:01:01:15", summary.DurationTimecode)
+	assert.EqualValues(t, "hevc", summary.Video.Codec)
+	assert.EqualValues(t, "16:9", summary.Video.DisplayAspectRatio)
+	assert.EqualValues(t, FrameRate{Rational: "30000/1001", Decimal: 29.97}, summary.Video.FrameRate)
+	assert.EqualValues(t, "interlaced", summary.Video.ScanType)
+	assert.EqualValues(t, "bottom_field_first", summary.Video.FieldOrder)
+	assert.EqualValues(t, 10, summary.Video.BitDepth)
+	assert.EqualValues(t, "HDR10", summary.Video.HdrType)
+	assert.EqualValues(t, "ger", summary.Audio[0].Language)
+}
+
+func Test_Summary_AudioOnly_Returns_MillisecondTimecode(t *testing.T) {
+	result := ProbeResult{
+		Format:  ProbeFormat{FormatName: "wav", Duration: "3725.25"},
+		Streams: []ProbeStream{{CodecType: "audio", CodecName: "pcm_s24le", Channels: 2, SampleRate: "96000"}},
+	}
+
+	summary := result.Summary()
+
+	assert.EqualValues(t, "wav", summary.Container)
+	assert.Nil(t, summary.Video)
+	assert.EqualValues(t, "01:02:05.250", summary.DurationTimecode)
+	assert.Nil(t, summary.ToDto().Video)
+}
+
+func Test_hdrType_SideData_Returns_DynamicHdr(t *testing.T) {
+	dolbyVision := ProbeStream{ColorTransfer: "smpte2084", SideData: []ProbeSideData{{"side_data_type": "DOVI configuration record"}}}
+	hlg := ProbeStream{ColorTransfer: "arib-std-b67"}
+
+	assert.EqualValues(t, "Dolby Vision", hdrType(dolbyVision))
+	assert.EqualValues(t, "HLG", hdrType(hlg))
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+type ProbeEngine string
+
+const (
+	EngineFfprobe   ProbeEngine = "ffprobe"
+	EngineMediaInfo ProbeEngine = "mediainfo"
+)
+
+// ProbeEngines lists the engines a job runs, in order. It is stored as a JSON
+// array in a single column.
+type ProbeEngines []ProbeEngine
+
+func ParseProbeEngine(engine string) (ProbeEngine, api_error.ApiErr) {
+	switch strings.ToLower(strings.TrimSpace(engine)) {
+	case "", string(EngineFfprobe):
+		return EngineFfprobe, nil
+	case string(EngineMediaInfo):
+		return EngineMediaInfo, nil
+	default:
+		return "", api_error.NewBadRequestError(fmt.Sprintf("Unknown probe engine %v", engine))
+	}
+}
+
+// ParseProbeEngines validates the engines requested for a job. No engines
+// means ffprobe only; duplicates are dropped.
+func ParseProbeEngines(engines []string) (ProbeEngines, api_error.ApiErr) {
+	if len(engines) == 0 {
+		return ProbeEngines{EngineFfprobe}, nil
+	}
+	parsed := make(ProbeEngines, 0, len(engines))
+	for _, name := range engines {
+		engine, err := ParseProbeEngine(name)
+		if err != nil {
+			return nil, err
+		}
+		if !parsed.Contains(engine) {
+			parsed = append(parsed, engine)
+		}
+	}
+	return parsed, nil
+}
+
+func (e ProbeEngines) Contains(engine ProbeEngine) bool {
+	for _, existing := range e {
+		if existing == engine {
+			return true
+		}
+	}
+	return false
+}
+
+func (e ProbeEngines) Value() (driver.Value, error) {
+	return jsonValue(e)
+}
+
+func (e *ProbeEngines) Scan(src interface{}) error {
+	return jsonScan(src, e)
+}
+
+func (e ProbeEngines) ToDto() []string {
+	engines := make([]string, 0, len(e))
+	for _, engine := range e {
+		engines = append(engines, string(engine))
+	}
+	return engines
+}
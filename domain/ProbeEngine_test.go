@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseProbeEngines_NoEngines_Returns_Ffprobe(t *testing.T) {
+	engines, err := ParseProbeEngines(nil)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, ProbeEngines{EngineFfprobe}, engines)
+}
+
+func Test_ParseProbeEngines_BothEngines_Returns_UniqueEngines(t *testing.T) {
+	engines, err := ParseProbeEngines([]string{"MediaInfo", "ffprobe", "mediainfo"})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, ProbeEngines{EngineMediaInfo, EngineFfprobe}, engines)
+}
+
+func Test_ParseProbeEngines_UnknownEngine_Returns_BadRequestError(t *testing.T) {
+	engines, err := ParseProbeEngines([]string{"ffprobe", "exiftool"})
+
+	assert.Nil(t, engines)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "Unknown probe engine exiftool", err.Message())
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func Test_ProbeEngines_ValueAndScan_RoundTrip(t *testing.T) {
+	engines := ProbeEngines{EngineFfprobe, EngineMediaInfo}
+	var scanned ProbeEngines
+
+	value, _ := engines.Value()
+	err := scanned.Scan(value)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, engines, scanned)
+}
@@ -0,0 +1,263 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/johannes-kuhfuss/probesvc/dto"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+// ProbeResult mirrors the JSON document ffprobe prints for -show_format and
+// -show_streams, plus -show_chapters and -show_programs when a probe profile
+// asks for them. Numeric values ffprobe prints as strings (durations, bit
+// rates, sizes) are kept as strings, since ffprobe uses "N/A" for unknowns.
+type ProbeResult struct {
+	Streams  []ProbeStream  `json:"streams"`
+	Format   ProbeFormat    `json:"format"`
+	Chapters []ProbeChapter `json:"chapters,omitempty"`
+	Programs []ProbeProgram `json:"programs,omitempty"`
+}
+
+type ProbeChapter struct {
+	Id        int64             `json:"id"`
+	TimeBase  string            `json:"time_base"`
+	Start     int64             `json:"start"`
+	StartTime string            `json:"start_time"`
+	End       int64             `json:"end"`
+	EndTime   string            `json:"end_time"`
+	Tags      map[string]string `json:"tags,omitempty"`
+}
+
+type ProbeProgram struct {
+	ProgramId  int               `json:"program_id"`
+	ProgramNum int               `json:"program_num"`
+	NbStreams  int               `json:"nb_streams"`
+	PmtPid     int               `json:"pmt_pid"`
+	PcrPid     int               `json:"pcr_pid"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	Streams    []ProbeStream     `json:"streams,omitempty"`
+}
+
+type ProbeFormat struct {
+	Filename       string            `json:"filename"`
+	NbStreams      int               `json:"nb_streams"`
+	NbPrograms     int               `json:"nb_programs"`
+	FormatName     string            `json:"format_name"`
+	FormatLongName string            `json:"format_long_name"`
+	StartTime      string            `json:"start_time,omitempty"`
+	Duration       string            `json:"duration,omitempty"`
+	Size           string            `json:"size,omitempty"`
+	BitRate        string            `json:"bit_rate,omitempty"`
+	ProbeScore     int               `json:"probe_score"`
+	Tags           map[string]string `json:"tags,omitempty"`
+}
+
+type ProbeStream struct {
+	Index              int               `json:"index"`
+	Id                 string            `json:"id,omitempty"`
+	CodecName          string            `json:"codec_name,omitempty"`
+	CodecLongName      string            `json:"codec_long_name,omitempty"`
+	Profile            string            `json:"profile,omitempty"`
+	CodecType          string            `json:"codec_type"`
+	CodecTagString     string            `json:"codec_tag_string,omitempty"`
+	CodecTag           string            `json:"codec_tag,omitempty"`
+	Width              int               `json:"width,omitempty"`
+	Height             int               `json:"height,omitempty"`
+	CodedWidth         int               `json:"coded_width,omitempty"`
+	CodedHeight        int               `json:"coded_height,omitempty"`
+	HasBFrames         int               `json:"has_b_frames,omitempty"`
+	SampleAspectRatio  string            `json:"sample_aspect_ratio,omitempty"`
+	DisplayAspectRatio string            `json:"display_aspect_ratio,omitempty"`
+	PixFmt             string            `json:"pix_fmt,omitempty"`
+	Level              int               `json:"level,omitempty"`
+	ColorRange         string            `json:"color_range,omitempty"`
+	ColorSpace         string            `json:"color_space,omitempty"`
+	ColorTransfer      string            `json:"color_transfer,omitempty"`
+	ColorPrimaries     string            `json:"color_primaries,omitempty"`
+	ChromaLocation     string            `json:"chroma_location,omitempty"`
+	FieldOrder         string            `json:"field_order,omitempty"`
+	Refs               int               `json:"refs,omitempty"`
+	SampleFmt          string            `json:"sample_fmt,omitempty"`
+	SampleRate         string            `json:"sample_rate,omitempty"`
+	Channels           int               `json:"channels,omitempty"`
+	ChannelLayout      string            `json:"channel_layout,omitempty"`
+	BitsPerSample      int               `json:"bits_per_sample,omitempty"`
+	RFrameRate         string            `json:"r_frame_rate,omitempty"`
+	AvgFrameRate       string            `json:"avg_frame_rate,omitempty"`
+	TimeBase           string            `json:"time_base,omitempty"`
+	StartPts           int64             `json:"start_pts,omitempty"`
+	StartTime          string            `json:"start_time,omitempty"`
+	DurationTs         int64             `json:"duration_ts,omitempty"`
+	Duration           string            `json:"duration,omitempty"`
+	BitRate            string            `json:"bit_rate,omitempty"`
+	MaxBitRate         string            `json:"max_bit_rate,omitempty"`
+	BitsPerRawSample   string            `json:"bits_per_raw_sample,omitempty"`
+	NbFrames           string            `json:"nb_frames,omitempty"`
+	NbReadFrames       string            `json:"nb_read_frames,omitempty"`
+	NbReadPackets      string            `json:"nb_read_packets,omitempty"`
+	Disposition        ProbeDisposition  `json:"disposition"`
+	Tags               map[string]string `json:"tags,omitempty"`
+	SideData           []ProbeSideData   `json:"side_data_list,omitempty"`
+}
+
+type ProbeDisposition struct {
+	Default         int `json:"default"`
+	Dub             int `json:"dub"`
+	Original        int `json:"original"`
+	Comment         int `json:"comment"`
+	Lyrics          int `json:"lyrics"`
+	Karaoke         int `json:"karaoke"`
+	Forced          int `json:"forced"`
+	HearingImpaired int `json:"hearing_impaired"`
+	VisualImpaired  int `json:"visual_impaired"`
+	CleanEffects    int `json:"clean_effects"`
+	AttachedPic     int `json:"attached_pic"`
+	TimedThumbnails int `json:"timed_thumbnails"`
+}
+
+// ProbeSideData keeps every field of a side data entry, as the fields present
+// depend on the side data type (display matrix, stereo 3D, HDR metadata, ...).
+type ProbeSideData map[string]interface{}
+
+func (sd ProbeSideData) Type() string {
+	sideDataType, _ := sd["side_data_type"].(string)
+	return sideDataType
+}
+
+func ParseProbeResult(data string) (*ProbeResult, api_error.ApiErr) {
+	var result ProbeResult
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		return nil, api_error.NewValidationError(fmt.Sprintf("result is not valid ffprobe output: %v", err))
+	}
+	return &result, nil
+}
+
+func (r ProbeResult) Value() (driver.Value, error) {
+	return jsonValue(r)
+}
+
+func (r *ProbeResult) Scan(src interface{}) error {
+	return jsonScan(src, r)
+}
+
+func (r ProbeResult) ToDto() dto.ProbeResultResponse {
+	var chapters []dto.ProbeChapterResponse
+	for _, chapter := range r.Chapters {
+		chapters = append(chapters, dto.ProbeChapterResponse{
+			Id:        chapter.Id,
+			TimeBase:  chapter.TimeBase,
+			Start:     chapter.Start,
+			StartTime: chapter.StartTime,
+			End:       chapter.End,
+			EndTime:   chapter.EndTime,
+			Tags:      chapter.Tags,
+		})
+	}
+	var programs []dto.ProbeProgramResponse
+	for _, program := range r.Programs {
+		programs = append(programs, dto.ProbeProgramResponse{
+			ProgramId:  program.ProgramId,
+			ProgramNum: program.ProgramNum,
+			NbStreams:  program.NbStreams,
+			PmtPid:     program.PmtPid,
+			PcrPid:     program.PcrPid,
+			Tags:       program.Tags,
+			Streams:    streamsToDto(program.Streams),
+		})
+	}
+	return dto.ProbeResultResponse{
+		Streams:  streamsToDto(r.Streams),
+		Chapters: chapters,
+		Programs: programs,
+		Format: dto.ProbeFormatResponse{
+			Filename:       r.Format.Filename,
+			NbStreams:      r.Format.NbStreams,
+			NbPrograms:     r.Format.NbPrograms,
+			FormatName:     r.Format.FormatName,
+			FormatLongName: r.Format.FormatLongName,
+			StartTime:      r.Format.StartTime,
+			Duration:       r.Format.Duration,
+			Size:           r.Format.Size,
+			BitRate:        r.Format.BitRate,
+			ProbeScore:     r.Format.ProbeScore,
+			Tags:           r.Format.Tags,
+		},
+	}
+}
+
+func streamsToDto(streams []ProbeStream) []dto.ProbeStreamResponse {
+	response := make([]dto.ProbeStreamResponse, 0, len(streams))
+	for _, stream := range streams {
+		response = append(response, stream.ToDto())
+	}
+	return response
+}
+
+func (s ProbeStream) ToDto() dto.ProbeStreamResponse {
+	sideData := make([]map[string]interface{}, 0, len(s.SideData))
+	for _, entry := range s.SideData {
+		sideData = append(sideData, entry)
+	}
+	return dto.ProbeStreamResponse{
+		Index:              s.Index,
+		Id:                 s.Id,
+		CodecName:          s.CodecName,
+		CodecLongName:      s.CodecLongName,
+		Profile:            s.Profile,
+		CodecType:          s.CodecType,
+		CodecTagString:     s.CodecTagString,
+		CodecTag:           s.CodecTag,
+		Width:              s.Width,
+		Height:             s.Height,
+		CodedWidth:         s.CodedWidth,
+		CodedHeight:        s.CodedHeight,
+		HasBFrames:         s.HasBFrames,
+		SampleAspectRatio:  s.SampleAspectRatio,
+		DisplayAspectRatio: s.DisplayAspectRatio,
+		PixFmt:             s.PixFmt,
+		Level:              s.Level,
+		ColorRange:         s.ColorRange,
+		ColorSpace:         s.ColorSpace,
+		ColorTransfer:      s.ColorTransfer,
+		ColorPrimaries:     s.ColorPrimaries,
+		ChromaLocation:     s.ChromaLocation,
+		FieldOrder:         s.FieldOrder,
+		Refs:               s.Refs,
+		SampleFmt:          s.SampleFmt,
+		SampleRate:         s.SampleRate,
+		Channels:           s.Channels,
+		ChannelLayout:      s.ChannelLayout,
+		BitsPerSample:      s.BitsPerSample,
+		RFrameRate:         s.RFrameRate,
+		AvgFrameRate:       s.AvgFrameRate,
+		TimeBase:           s.TimeBase,
+		StartPts:           s.StartPts,
+		StartTime:          s.StartTime,
+		DurationTs:         s.DurationTs,
+		Duration:           s.Duration,
+		BitRate:            s.BitRate,
+		MaxBitRate:         s.MaxBitRate,
+		BitsPerRawSample:   s.BitsPerRawSample,
+		NbFrames:           s.NbFrames,
+		NbReadFrames:       s.NbReadFrames,
+		NbReadPackets:      s.NbReadPackets,
+		Disposition: dto.ProbeDispositionResponse{
+			Default:         s.Disposition.Default,
+			Dub:             s.Disposition.Dub,
+			Original:        s.Disposition.Original,
+			Comment:         s.Disposition.Comment,
+			Lyrics:          s.Disposition.Lyrics,
+			Karaoke:         s.Disposition.Karaoke,
+			Forced:          s.Disposition.Forced,
+			HearingImpaired: s.Disposition.HearingImpaired,
+			VisualImpaired:  s.Disposition.VisualImpaired,
+			CleanEffects:    s.Disposition.CleanEffects,
+			AttachedPic:     s.Disposition.AttachedPic,
+			TimedThumbnails: s.Disposition.TimedThumbnails,
+		},
+		Tags:     s.Tags,
+		SideData: sideData,
+	}
+}
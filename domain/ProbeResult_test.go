@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testProbeOutput = `{
+    "streams": [
+        {
+            "index": 0,
+            "codec_name": "h264",
+            "codec_long_name": "H.264 / AVC / MPEG-4 AVC / MPEG-4 part 10",
+            "profile": "High",
+            "codec_type": "video",
+            "codec_tag_string": "avc1",
+            "codec_tag": "0x31637661",
+            "width": 1920,
+            "height": 1080,
+            "has_b_frames": 2,
+            "pix_fmt": "yuv420p",
+            "level": 40,
+            "r_frame_rate": "25/1",
+            "avg_frame_rate": "25/1",
+            "time_base": "1/12800",
+            "duration": "10.000000",
+            "bit_rate": "4000000",
+            "nb_frames": "250",
+            "disposition": {
+                "default": 1,
+                "dub": 0,
+                "forced": 0
+            },
+            "tags": {
+                "language": "und",
+                "handler_name": "VideoHandler"
+            },
+            "side_data_list": [
+                {
+                    "side_data_type": "Display Matrix",
+                    "displaymatrix": "\n00000000:            0       65536           0\n",
+                    "rotation": -90
+                }
+            ]
+        },
+        {
+            "index": 1,
+            "codec_name": "aac",
+            "codec_type": "audio",
+            "sample_fmt": "fltp",
+            "sample_rate": "48000",
+            "channels": 2,
+            "channel_layout": "stereo",
+            "disposition": {
+                "default": 1
+            }
+        }
+    ],
+    "format": {
+        "filename": "pipe:",
+        "nb_streams": 2,
+        "nb_programs": 0,
+        "format_name": "mov,mp4,m4a,3gp,3g2,mj2",
+        "format_long_name": "QuickTime / MOV",
+        "duration": "10.000000",
+        "size": "5000000",
+        "bit_rate": "4000000",
+        "probe_score": 100,
+        "tags": {
+            "major_brand": "isom"
+        }
+    }
+}`
+
+func Test_ParseProbeResult_InvalidOutput_Returns_ValidationError(t *testing.T) {
+	result, err := ParseProbeResult(`{"streams": {}}`)
+
+	assert.Nil(t, result)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusUnprocessableEntity, err.StatusCode())
+}
+
+func Test_ParseProbeResult_Returns_TypedResult(t *testing.T) {
+	result, err := ParseProbeResult(testProbeOutput)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, len(result.Streams))
+	assert.EqualValues(t, "mov,mp4,m4a,3gp,3g2,mj2", result.Format.FormatName)
+	assert.EqualValues(t, 100, result.Format.ProbeScore)
+	assert.EqualValues(t, "isom", result.Format.Tags["major_brand"])
+	video := result.Streams[0]
+	assert.EqualValues(t, "video", video.CodecType)
+	assert.EqualValues(t, 1920, video.Width)
+	assert.EqualValues(t, 1, video.Disposition.Default)
+	assert.EqualValues(t, "und", video.Tags["language"])
+	assert.EqualValues(t, "Display Matrix", video.SideData[0].Type())
+	assert.EqualValues(t, -90, video.SideData[0]["rotation"])
+	assert.EqualValues(t, 2, result.Streams[1].Channels)
+}
+
+func Test_ProbeResult_ValueAndScan_RoundTrip(t *testing.T) {
+	result, _ := ParseProbeResult(testProbeOutput)
+	var scanned ProbeResult
+
+	value, valueErr := result.Value()
+	scanErr := scanned.Scan(value)
+
+	assert.Nil(t, valueErr)
+	assert.Nil(t, scanErr)
+	assert.EqualValues(t, *result, scanned)
+}
+
+func Test_ProbeResultToDto_Returns_Object(t *testing.T) {
+	result, _ := ParseProbeResult(testProbeOutput)
+
+	response := result.ToDto()
+	data, _ := json.Marshal(response)
+
+	assert.EqualValues(t, "h264", response.Streams[0].CodecName)
+	assert.EqualValues(t, "48000", response.Streams[1].SampleRate)
+	assert.EqualValues(t, "Display Matrix", response.Streams[0].SideData[0]["side_data_type"])
+	assert.Contains(t, string(data), `"format_name":"mov,mp4,m4a,3gp,3g2,mj2"`)
+}
+
+func Test_ParseProbeResult_WithChaptersAndPrograms_Returns_TypedResult(t *testing.T) {
+	output := `{
+    "programs": [{"program_id": 1, "program_num": 1, "nb_streams": 1, "pmt_pid": 4096, "pcr_pid": 256, "streams": [{"index": 0, "codec_type": "video", "nb_read_frames": "250"}]}],
+    "chapters": [{"id": 1, "time_base": "1/1000", "start": 0, "start_time": "0.000000", "end": 5000, "end_time": "5.000000", "tags": {"title": "Intro"}}],
+    "streams": [{"index": 0, "codec_type": "video", "nb_read_frames": "250"}],
+    "format": {"format_name": "mpegts"}
+}`
+
+	result, err := ParseProbeResult(output)
+	response := result.ToDto()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "Intro", response.Chapters[0].Tags["title"])
+	assert.EqualValues(t, "5.000000", response.Chapters[0].EndTime)
+	assert.EqualValues(t, 4096, response.Programs[0].PmtPid)
+	assert.EqualValues(t, "250", response.Programs[0].Streams[0].NbReadFrames)
+	assert.EqualValues(t, "250", response.Streams[0].NbReadFrames)
+}
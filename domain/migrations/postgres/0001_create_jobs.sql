@@ -0,0 +1,14 @@
+CREATE TABLE jobs (
+    job_id      TEXT PRIMARY KEY,
+    name        TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    created_by  TEXT NOT NULL DEFAULT '',
+    modified_at TIMESTAMPTZ NOT NULL,
+    modified_by TEXT NOT NULL DEFAULT '',
+    src_url     TEXT NOT NULL,
+    status      TEXT NOT NULL,
+    error_msg   TEXT NOT NULL DEFAULT '',
+    tech_info   TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_jobs_status_created_at ON jobs (status, created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE jobs ADD COLUMN probe_result TEXT;
+UPDATE jobs SET probe_result = tech_info WHERE tech_info <> '';
@@ -0,0 +1 @@
+ALTER TABLE jobs ADD COLUMN profile TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE jobs ADD COLUMN mode TEXT NOT NULL DEFAULT 'standard';
+ALTER TABLE jobs ADD COLUMN deep_analysis TEXT;
@@ -0,0 +1,7 @@
+ALTER TABLE jobs ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN next_attempt_at TIMESTAMP;
+ALTER TABLE jobs ADD COLUMN error_history TEXT NOT NULL DEFAULT '[]';
+
+UPDATE jobs SET next_attempt_at = created_at;
+
+CREATE INDEX idx_jobs_status_next_attempt_at ON jobs (status, next_attempt_at);
@@ -0,0 +1 @@
+ALTER TABLE jobs ADD COLUMN error_code TEXT NOT NULL DEFAULT '';
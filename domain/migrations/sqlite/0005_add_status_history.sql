@@ -0,0 +1 @@
+ALTER TABLE jobs ADD COLUMN status_history TEXT NOT NULL DEFAULT '[]';
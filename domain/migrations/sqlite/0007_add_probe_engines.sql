@@ -0,0 +1,2 @@
+ALTER TABLE jobs ADD COLUMN media_info TEXT;
+ALTER TABLE jobs ADD COLUMN engines TEXT NOT NULL DEFAULT '["ffprobe"]';
@@ -0,0 +1,6 @@
+CREATE TABLE settings (
+    name  TEXT PRIMARY KEY,
+    value TEXT NOT NULL
+);
+
+INSERT INTO settings (name, value) VALUES ('processing_paused', 'false');
@@ -0,0 +1,12 @@
+-- Times used to be stored as Go time strings, e.g.
+-- "2022-03-01 10:00:00.5 +0000 UTC", whose text order is not their time order.
+-- Rewrite them as "2022-03-01 10:00:00.500000000+00:00".
+UPDATE jobs SET created_at = substr(created_at, 1, 19) || '.' ||
+    substr(substr(substr(created_at, 20, instr(created_at, ' +0000 UTC') - 20), 2) || '000000000', 1, 9) || '+00:00'
+    WHERE created_at LIKE '% +0000 UTC';
+UPDATE jobs SET modified_at = substr(modified_at, 1, 19) || '.' ||
+    substr(substr(substr(modified_at, 20, instr(modified_at, ' +0000 UTC') - 20), 2) || '000000000', 1, 9) || '+00:00'
+    WHERE modified_at LIKE '% +0000 UTC';
+UPDATE jobs SET next_attempt_at = substr(next_attempt_at, 1, 19) || '.' ||
+    substr(substr(substr(next_attempt_at, 20, instr(next_attempt_at, ' +0000 UTC') - 20), 2) || '000000000', 1, 9) || '+00:00'
+    WHERE next_attempt_at LIKE '% +0000 UTC';
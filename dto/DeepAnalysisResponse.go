@@ -0,0 +1,29 @@
+package dto
+
+type DeepAnalysisResponse struct {
+	Streams []StreamAnalysisResponse `json:"streams"`
+}
+
+type StreamAnalysisResponse struct {
+	Index         int                  `json:"index"`
+	CodecType     string               `json:"codec_type"`
+	Packets       int64                `json:"packets"`
+	Bytes         int64                `json:"bytes"`
+	Frames        int64                `json:"frames"`
+	KeyFrames     int64                `json:"key_frames"`
+	FrameTypes    map[string]int64     `json:"frame_types,omitempty"`
+	Gop           *GopAnalysisResponse `json:"gop,omitempty"`
+	AvgBitrate    int64                `json:"avg_bitrate"`
+	MaxBitrate    int64                `json:"max_bitrate"`
+	BitrateSeries []int64              `json:"bitrate_series"`
+}
+
+type GopAnalysisResponse struct {
+	Count               int64   `json:"count"`
+	MinLength           int64   `json:"min_length"`
+	MaxLength           int64   `json:"max_length"`
+	AvgLength           float64 `json:"avg_length"`
+	MinKeyFrameInterval float64 `json:"min_key_frame_interval"`
+	MaxKeyFrameInterval float64 `json:"max_key_frame_interval"`
+	AvgKeyFrameInterval float64 `json:"avg_key_frame_interval"`
+}
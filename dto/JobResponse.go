@@ -5,14 +5,40 @@ import (
 )
 
 type JobResponse struct {
-	Id         string    `json:"job_id"`
-	Name       string    `json:"name"`
-	CreatedAt  time.Time `json:"created_at"`
-	CreatedBy  string    `json:"created_by"`
-	ModifiedAt time.Time `json:"modified_at"`
-	ModifiedBy string    `json:"modified_by"`
-	SrcUrl     string    `json:"src_url"`
-	Status     string    `json:"status"`
+	Id            string                  `json:"job_id"`
+	Name          string                  `json:"name"`
+	CreatedAt     time.Time               `json:"created_at"`
+	CreatedBy     string                  `json:"created_by"`
+	ModifiedAt    time.Time               `json:"modified_at"`
+	ModifiedBy    string                  `json:"modified_by"`
+	SrcUrl        string                  `json:"src_url"`
+	Status        string                  `json:"status"`
+	ErrorCode     string                  `json:"error_code"`
+	ErrorMsg      string                  `json:"error_msg"`
+	TechInfo      *ProbeResultResponse    `json:"tech_info"`
+	Summary       *MediaSummaryResponse   `json:"summary"`
+	MediaInfo     *MediaInfoResponse      `json:"media_info"`
+	Engines       []string                `json:"engines"`
+	Profile       string                  `json:"profile"`
+	Mode          string                  `json:"mode"`
+	DeepAnalysis  *DeepAnalysisResponse   `json:"deep_analysis"`
+	ClaimedBy     string                  `json:"claimed_by"`
+	Attempts      int                     `json:"attempts"`
+	NextAttemptAt time.Time               `json:"next_attempt_at"`
+	ErrorHistory  []JobErrorResponse      `json:"error_history"`
+	StatusHistory []JobTransitionResponse `json:"status_history"`
+}
+
+type JobTransitionResponse struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	ChangedBy string    `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type JobErrorResponse struct {
+	Attempt    int       `json:"attempt"`
+	OccurredAt time.Time `json:"occurred_at"`
+	ErrorCode  string    `json:"error_code"`
 	ErrorMsg   string    `json:"error_msg"`
-	TechInfo   string    `json:"tech_info"`
 }
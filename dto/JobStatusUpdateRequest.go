@@ -1,6 +1,8 @@
 package dto
 
 type JobStatusUpdateRequest struct {
-	Status string `json:"status"`
-	ErrMsg string `json:"err_msg"`
+	Status    string `json:"status"`
+	ErrMsg    string `json:"err_msg"`
+	ErrorCode string `json:"error_code"`
+	ChangedBy string `json:"changed_by"`
 }
@@ -0,0 +1,10 @@
+package dto
+
+type MediaInfoResponse struct {
+	Media MediaInfoMediaResponse `json:"media"`
+}
+
+type MediaInfoMediaResponse struct {
+	Ref    string                   `json:"@ref"`
+	Tracks []map[string]interface{} `json:"track"`
+}
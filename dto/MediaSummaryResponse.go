@@ -0,0 +1,33 @@
+package dto
+
+type MediaSummaryResponse struct {
+	Container        string                 `json:"container"`
+	DurationSeconds  float64                `json:"duration_seconds"`
+	DurationTimecode string                 `json:"duration_timecode"`
+	Video            *VideoSummaryResponse  `json:"video"`
+	Audio            []AudioSummaryResponse `json:"audio"`
+}
+
+type VideoSummaryResponse struct {
+	Codec              string  `json:"codec"`
+	Profile            string  `json:"profile"`
+	Width              int     `json:"width"`
+	Height             int     `json:"height"`
+	DisplayAspectRatio string  `json:"display_aspect_ratio"`
+	FrameRate          string  `json:"frame_rate"`
+	FrameRateDecimal   float64 `json:"frame_rate_decimal"`
+	ScanType           string  `json:"scan_type"`
+	FieldOrder         string  `json:"field_order,omitempty"`
+	BitDepth           int     `json:"bit_depth"`
+	ColourPrimaries    string  `json:"colour_primaries"`
+	HdrType            string  `json:"hdr_type"`
+}
+
+type AudioSummaryResponse struct {
+	Index         int    `json:"index"`
+	Codec         string `json:"codec"`
+	Channels      int    `json:"channels"`
+	ChannelLayout string `json:"channel_layout"`
+	SampleRate    int    `json:"sample_rate"`
+	Language      string `json:"language"`
+}
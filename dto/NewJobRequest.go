@@ -1,6 +1,12 @@
 package dto
 
+import "time"
+
 type NewJobRequest struct {
-	Name   string `json:"name"`
-	SrcUrl string `json:"src_url"`
+	Name    string    `json:"name"`
+	SrcUrl  string    `json:"src_url"`
+	StartAt time.Time `json:"start_at"`
+	Engines []string  `json:"engines"`
+	Profile string    `json:"profile"`
+	Mode    string    `json:"mode"`
 }
@@ -0,0 +1,10 @@
+package dto
+
+type ProbeRequest struct {
+	SrcUrl string `json:"src_url"`
+}
+
+type ProbeResponse struct {
+	TechInfo ProbeResultResponse  `json:"tech_info"`
+	Summary  MediaSummaryResponse `json:"summary"`
+}
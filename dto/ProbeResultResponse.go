@@ -0,0 +1,105 @@
+package dto
+
+type ProbeResultResponse struct {
+	Streams  []ProbeStreamResponse  `json:"streams"`
+	Format   ProbeFormatResponse    `json:"format"`
+	Chapters []ProbeChapterResponse `json:"chapters,omitempty"`
+	Programs []ProbeProgramResponse `json:"programs,omitempty"`
+}
+
+type ProbeChapterResponse struct {
+	Id        int64             `json:"id"`
+	TimeBase  string            `json:"time_base"`
+	Start     int64             `json:"start"`
+	StartTime string            `json:"start_time"`
+	End       int64             `json:"end"`
+	EndTime   string            `json:"end_time"`
+	Tags      map[string]string `json:"tags,omitempty"`
+}
+
+type ProbeProgramResponse struct {
+	ProgramId  int                   `json:"program_id"`
+	ProgramNum int                   `json:"program_num"`
+	NbStreams  int                   `json:"nb_streams"`
+	PmtPid     int                   `json:"pmt_pid"`
+	PcrPid     int                   `json:"pcr_pid"`
+	Tags       map[string]string     `json:"tags,omitempty"`
+	Streams    []ProbeStreamResponse `json:"streams,omitempty"`
+}
+
+type ProbeFormatResponse struct {
+	Filename       string            `json:"filename"`
+	NbStreams      int               `json:"nb_streams"`
+	NbPrograms     int               `json:"nb_programs"`
+	FormatName     string            `json:"format_name"`
+	FormatLongName string            `json:"format_long_name"`
+	StartTime      string            `json:"start_time,omitempty"`
+	Duration       string            `json:"duration,omitempty"`
+	Size           string            `json:"size,omitempty"`
+	BitRate        string            `json:"bit_rate,omitempty"`
+	ProbeScore     int               `json:"probe_score"`
+	Tags           map[string]string `json:"tags,omitempty"`
+}
+
+type ProbeStreamResponse struct {
+	Index              int                      `json:"index"`
+	Id                 string                   `json:"id,omitempty"`
+	CodecName          string                   `json:"codec_name,omitempty"`
+	CodecLongName      string                   `json:"codec_long_name,omitempty"`
+	Profile            string                   `json:"profile,omitempty"`
+	CodecType          string                   `json:"codec_type"`
+	CodecTagString     string                   `json:"codec_tag_string,omitempty"`
+	CodecTag           string                   `json:"codec_tag,omitempty"`
+	Width              int                      `json:"width,omitempty"`
+	Height             int                      `json:"height,omitempty"`
+	CodedWidth         int                      `json:"coded_width,omitempty"`
+	CodedHeight        int                      `json:"coded_height,omitempty"`
+	HasBFrames         int                      `json:"has_b_frames,omitempty"`
+	SampleAspectRatio  string                   `json:"sample_aspect_ratio,omitempty"`
+	DisplayAspectRatio string                   `json:"display_aspect_ratio,omitempty"`
+	PixFmt             string                   `json:"pix_fmt,omitempty"`
+	Level              int                      `json:"level,omitempty"`
+	ColorRange         string                   `json:"color_range,omitempty"`
+	ColorSpace         string                   `json:"color_space,omitempty"`
+	ColorTransfer      string                   `json:"color_transfer,omitempty"`
+	ColorPrimaries     string                   `json:"color_primaries,omitempty"`
+	ChromaLocation     string                   `json:"chroma_location,omitempty"`
+	FieldOrder         string                   `json:"field_order,omitempty"`
+	Refs               int                      `json:"refs,omitempty"`
+	SampleFmt          string                   `json:"sample_fmt,omitempty"`
+	SampleRate         string                   `json:"sample_rate,omitempty"`
+	Channels           int                      `json:"channels,omitempty"`
+	ChannelLayout      string                   `json:"channel_layout,omitempty"`
+	BitsPerSample      int                      `json:"bits_per_sample,omitempty"`
+	RFrameRate         string                   `json:"r_frame_rate,omitempty"`
+	AvgFrameRate       string                   `json:"avg_frame_rate,omitempty"`
+	TimeBase           string                   `json:"time_base,omitempty"`
+	StartPts           int64                    `json:"start_pts,omitempty"`
+	StartTime          string                   `json:"start_time,omitempty"`
+	DurationTs         int64                    `json:"duration_ts,omitempty"`
+	Duration           string                   `json:"duration,omitempty"`
+	BitRate            string                   `json:"bit_rate,omitempty"`
+	MaxBitRate         string                   `json:"max_bit_rate,omitempty"`
+	BitsPerRawSample   string                   `json:"bits_per_raw_sample,omitempty"`
+	NbFrames           string                   `json:"nb_frames,omitempty"`
+	NbReadFrames       string                   `json:"nb_read_frames,omitempty"`
+	NbReadPackets      string                   `json:"nb_read_packets,omitempty"`
+	Disposition        ProbeDispositionResponse `json:"disposition"`
+	Tags               map[string]string        `json:"tags,omitempty"`
+	SideData           []map[string]interface{} `json:"side_data_list,omitempty"`
+}
+
+type ProbeDispositionResponse struct {
+	Default         int `json:"default"`
+	Dub             int `json:"dub"`
+	Original        int `json:"original"`
+	Comment         int `json:"comment"`
+	Lyrics          int `json:"lyrics"`
+	Karaoke         int `json:"karaoke"`
+	Forced          int `json:"forced"`
+	HearingImpaired int `json:"hearing_impaired"`
+	VisualImpaired  int `json:"visual_impaired"`
+	CleanEffects    int `json:"clean_effects"`
+	AttachedPic     int `json:"attached_pic"`
+	TimedThumbnails int `json:"timed_thumbnails"`
+}
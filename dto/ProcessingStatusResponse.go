@@ -0,0 +1,5 @@
+package dto
+
+type ProcessingStatusResponse struct {
+	Paused bool `json:"paused"`
+}
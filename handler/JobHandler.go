@@ -1,9 +1,15 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/probesvc/domain"
 	"github.com/johannes-kuhfuss/probesvc/dto"
 	"github.com/johannes-kuhfuss/probesvc/service"
 	"github.com/johannes-kuhfuss/services_utils/api_error"
@@ -16,8 +22,14 @@ type JobHandlers struct {
 	Service service.JobService
 }
 
+const (
+	maxResultSize = 10 * 1024 * 1024
+)
+
 var (
 	policy *bluemonday.Policy
+	// srcUrlSchemes lists the schemes a source URL may use.
+	srcUrlSchemes = map[string]bool{"file": true, "http": true, "https": true, "s3": true, "az": true}
 )
 
 func init() {
@@ -34,6 +46,34 @@ func getJobId(jobIdParam string) (string, api_error.ApiErr) {
 	return jobId.String(), nil
 }
 
+// validateSrcUrl checks that a source URL can be parsed and uses a known
+// scheme. Source URLs are stored unchanged: escaping them would break signed
+// URLs with several query parameters.
+func validateSrcUrl(srcUrl string) api_error.ApiErr {
+	if strings.TrimSpace(srcUrl) == "" {
+		return nil
+	}
+	parsedUrl, err := url.Parse(srcUrl)
+	if err != nil {
+		return api_error.NewBadRequestError(fmt.Sprintf("Cannot parse source URL %v", srcUrl))
+	}
+	if !srcUrlSchemes[strings.ToLower(parsedUrl.Scheme)] {
+		return api_error.NewBadRequestError(fmt.Sprintf("Source URL scheme %v is not supported", parsedUrl.Scheme))
+	}
+	return nil
+}
+
+// callerId identifies who asked for a change: the worker named in the request
+// or, failing that, the client address.
+func callerId(c *gin.Context) string {
+	workerId, _ := c.GetQuery("worker")
+	workerId = policy.Sanitize(workerId)
+	if strings.TrimSpace(workerId) == "" {
+		return c.ClientIP()
+	}
+	return workerId
+}
+
 func (jh *JobHandlers) GetAllJobs(c *gin.Context) {
 	status, _ := c.GetQuery("status")
 	status = policy.Sanitize(status)
@@ -70,7 +110,10 @@ func (jh *JobHandlers) CreateJob(c *gin.Context) {
 		return
 	}
 	newJobReq.Name = policy.Sanitize(newJobReq.Name)
-	newJobReq.SrcUrl = policy.Sanitize(newJobReq.SrcUrl)
+	if err := validateSrcUrl(newJobReq.SrcUrl); err != nil {
+		c.JSON(err.StatusCode(), err)
+		return
+	}
 	result, err := jh.Service.CreateJob(newJobReq)
 	if err != nil {
 		logger.Error("Service error while creating job", err)
@@ -96,7 +139,7 @@ func (jh JobHandlers) DeleteJobById(c *gin.Context) {
 }
 
 func (jh JobHandlers) GetNextJob(c *gin.Context) {
-	result, err := jh.Service.GetNextJob()
+	result, err := jh.Service.ClaimNextJob(callerId(c))
 	if err != nil {
 		logger.Error("Service error while getting next job", err)
 		c.JSON(err.StatusCode(), err)
@@ -104,3 +147,179 @@ func (jh JobHandlers) GetNextJob(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, result)
 }
+
+func (jh JobHandlers) CancelJob(c *gin.Context) {
+	jobId, err := getJobId(c.Param("job_id"))
+	if err != nil {
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	result, err := jh.Service.CancelJob(jobId, callerId(c))
+	if err != nil {
+		logger.Error("Service error while cancelling job", err)
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (jh JobHandlers) PauseJob(c *gin.Context) {
+	jobId, err := getJobId(c.Param("job_id"))
+	if err != nil {
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	result, err := jh.Service.PauseJob(jobId, callerId(c))
+	if err != nil {
+		logger.Error("Service error while pausing job", err)
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (jh JobHandlers) ResumeJob(c *gin.Context) {
+	jobId, err := getJobId(c.Param("job_id"))
+	if err != nil {
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	result, err := jh.Service.ResumeJob(jobId, callerId(c))
+	if err != nil {
+		logger.Error("Service error while resuming job", err)
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (jh JobHandlers) GetProcessingStatus(c *gin.Context) {
+	paused, err := jh.Service.IsProcessingPaused()
+	if err != nil {
+		logger.Error("Service error while getting processing status", err)
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.ProcessingStatusResponse{Paused: paused})
+}
+
+func (jh JobHandlers) PauseProcessing(c *gin.Context) {
+	if err := jh.Service.PauseProcessing(); err != nil {
+		logger.Error("Service error while pausing processing", err)
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	logger.Info("Processing paused", logger.Field{Key: "changed_by", Value: callerId(c)})
+	c.JSON(http.StatusOK, dto.ProcessingStatusResponse{Paused: true})
+}
+
+func (jh JobHandlers) ResumeProcessing(c *gin.Context) {
+	if err := jh.Service.ResumeProcessing(); err != nil {
+		logger.Error("Service error while resuming processing", err)
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	logger.Info("Processing resumed", logger.Field{Key: "changed_by", Value: callerId(c)})
+	c.JSON(http.StatusOK, dto.ProcessingStatusResponse{Paused: false})
+}
+
+func (jh JobHandlers) RetryDeadLetterJobs(c *gin.Context) {
+	jobs, err := jh.Service.RetryDeadLetterJobs(callerId(c))
+	if err != nil {
+		logger.Error("Service error while retrying dead-lettered jobs", err)
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	c.JSON(http.StatusOK, jobs)
+}
+
+func (jh JobHandlers) SetStatus(c *gin.Context) {
+	jobId, err := getJobId(c.Param("job_id"))
+	if err != nil {
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	var statusReq dto.JobStatusUpdateRequest
+	if err := c.ShouldBindJSON(&statusReq); err != nil {
+		logger.Error("invalid JSON body in set status request", err)
+		apiErr := api_error.NewBadRequestError("invalid json body")
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	statusReq.Status = policy.Sanitize(statusReq.Status)
+	statusReq.ErrMsg = policy.Sanitize(statusReq.ErrMsg)
+	statusReq.ErrorCode = policy.Sanitize(statusReq.ErrorCode)
+	statusReq.ChangedBy = policy.Sanitize(statusReq.ChangedBy)
+	if strings.TrimSpace(statusReq.ChangedBy) == "" {
+		statusReq.ChangedBy = callerId(c)
+	}
+	// Jobs go back into line only through resume or the retry policy.
+	switch domain.JobStatus(strings.ToLower(statusReq.Status)) {
+	case domain.JobStatusCreated, domain.JobStatusQueued:
+		apiErr := api_error.NewBadRequestError(fmt.Sprintf("Status %v cannot be set through a status update", statusReq.Status))
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	err = jh.Service.SetStatus(jobId, statusReq)
+	if err != nil {
+		logger.Error("Service error while setting job status", err)
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
+
+func (jh JobHandlers) GetResult(c *gin.Context) {
+	jobId, err := getJobId(c.Param("job_id"))
+	if err != nil {
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	engine, err := domain.ParseProbeEngine(policy.Sanitize(c.Query("engine")))
+	if err != nil {
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	result, err := jh.Service.GetResult(jobId, engine)
+	if err != nil {
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(result))
+}
+
+func (jh JobHandlers) SetResult(c *gin.Context) {
+	jobId, err := getJobId(c.Param("job_id"))
+	if err != nil {
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	var body []byte
+	var readErr error
+	if c.Request.Body != nil {
+		body, readErr = io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxResultSize))
+	}
+	if readErr != nil {
+		logger.Error("could not read body of set result request", readErr)
+		apiErr := api_error.NewBadRequestError("could not read result body")
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	if len(body) == 0 || !json.Valid(body) {
+		apiErr := api_error.NewBadRequestError("result must be a valid json document")
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	engine, err := domain.ParseProbeEngine(policy.Sanitize(c.Query("engine")))
+	if err != nil {
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	err = jh.Service.SetResult(jobId, engine, string(body))
+	if err != nil {
+		logger.Error("Service error while setting job result", err)
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
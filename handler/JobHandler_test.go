@@ -141,7 +141,7 @@ func Test_GetJobById_Returns_NoError(t *testing.T) {
 		SrcUrl:     "",
 		Status:     "",
 		ErrorMsg:   "",
-		TechInfo:   "",
+		TechInfo:   nil,
 	}
 	bodyJson, _ := json.Marshal(newJob)
 	mockService.EXPECT().GetJobById(id.String()).Return(&newJob, nil)
@@ -188,6 +188,35 @@ func Test_CreateJob_Returns_ServiceError(t *testing.T) {
 	assert.EqualValues(t, errorJson, recorder.Body.String())
 }
 
+func Test_CreateJob_SignedUrl_Keeps_Query(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	jobReq := dto.NewJobRequest{
+		Name:   "my new job",
+		SrcUrl: "https://bucket.server/file.mxf?X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Expires=3600&X-Amz-Signature=abc%2Bdef",
+	}
+	jobReqJson, _ := json.Marshal(jobReq)
+	mockService.EXPECT().CreateJob(jobReq).Return(&dto.JobResponse{SrcUrl: jobReq.SrcUrl}, nil)
+	router.POST("/jobs", jh.CreateJob)
+	request, _ := http.NewRequest(http.MethodPost, "/jobs", strings.NewReader(string(jobReqJson)))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusCreated, recorder.Code)
+}
+
+func Test_CreateJob_UnknownScheme_Returns_BadRequestError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	router.POST("/jobs", jh.CreateJob)
+	request, _ := http.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"src_url": "javascript:alert(1)"}`))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "Source URL scheme javascript is not supported")
+}
+
 func Test_CreateJob_Returns_NoError(t *testing.T) {
 	teardown := setupTest(t)
 	defer teardown()
@@ -206,7 +235,7 @@ func Test_CreateJob_Returns_NoError(t *testing.T) {
 		SrcUrl:     jobReq.SrcUrl,
 		Status:     "created",
 		ErrorMsg:   "",
-		TechInfo:   "",
+		TechInfo:   nil,
 	}
 	bodyJson, _ := json.Marshal(jobResp)
 	mockService.EXPECT().CreateJob(jobReq).Return(&jobResp, nil)
@@ -267,9 +296,9 @@ func Test_GetNextJob_Returns_NotFoundError(t *testing.T) {
 	defer teardown()
 	apiError := api_error.NewNotFoundError("No next job found")
 	errorJson, _ := json.Marshal(apiError)
-	mockService.EXPECT().GetNextJob().Return(nil, apiError)
+	mockService.EXPECT().ClaimNextJob("worker-1").Return(nil, apiError)
 	router.GET("/jobs/next", jh.GetNextJob)
-	request, _ := http.NewRequest(http.MethodGet, "/jobs/next", nil)
+	request, _ := http.NewRequest(http.MethodGet, "/jobs/next?worker=worker-1", nil)
 
 	router.ServeHTTP(recorder, request)
 
@@ -288,17 +317,458 @@ func Test_GetNextJob_Returns_NoError(t *testing.T) {
 		ModifiedAt: date.GetNowUtc(),
 		ModifiedBy: "",
 		SrcUrl:     "http://server/path/file.ext",
-		Status:     "created",
+		Status:     "running",
 		ErrorMsg:   "",
-		TechInfo:   "",
+		TechInfo:   nil,
+		ClaimedBy:  "worker-1",
 	}
 	bodyJson, _ := json.Marshal(jobResp)
-	mockService.EXPECT().GetNextJob().Return(&jobResp, nil)
+	mockService.EXPECT().ClaimNextJob("worker-1").Return(&jobResp, nil)
+	router.GET("/jobs/next", jh.GetNextJob)
+	request, _ := http.NewRequest(http.MethodGet, "/jobs/next?worker=worker-1", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, bodyJson, recorder.Body.String())
+}
+
+func Test_GetNextJob_NoWorker_Claims_WithClientIp(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	apiError := api_error.NewNotFoundError("No next job found")
+	mockService.EXPECT().ClaimNextJob("10.0.0.1").Return(nil, apiError)
 	router.GET("/jobs/next", jh.GetNextJob)
 	request, _ := http.NewRequest(http.MethodGet, "/jobs/next", nil)
+	request.RemoteAddr = "10.0.0.1:12345"
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusNotFound, recorder.Code)
+}
+
+func Test_CancelJob_WrongId_Returns_BadRequestError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	router.POST("/jobs/:job_id/cancel", jh.CancelJob)
+	request, _ := http.NewRequest(http.MethodPost, "/jobs/wrong_id/cancel", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+}
+
+func Test_CancelJob_EndedJob_Returns_ConflictError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	id := ksuid.New()
+	apiError := api_error.NewProcessingConflictError(fmt.Sprintf("Job with id %v has already ended with status finished", id))
+	errorJson, _ := json.Marshal(apiError)
+	mockService.EXPECT().CancelJob(id.String(), gomock.Any()).Return(nil, apiError)
+	router.POST("/jobs/:job_id/cancel", jh.CancelJob)
+	request, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/jobs/%v/cancel", id), nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusConflict, recorder.Code)
+	assert.EqualValues(t, errorJson, recorder.Body.String())
+}
+
+func Test_CancelJob_Returns_NoError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	id := ksuid.New()
+	jobResp := dto.JobResponse{
+		Id:       id.String(),
+		Name:     "job 1",
+		SrcUrl:   "http://server/path/file.ext",
+		Status:   "cancelled",
+		ErrorMsg: "Job cancelled by user",
+	}
+	bodyJson, _ := json.Marshal(jobResp)
+	mockService.EXPECT().CancelJob(id.String(), gomock.Any()).Return(&jobResp, nil)
+	router.POST("/jobs/:job_id/cancel", jh.CancelJob)
+	request, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/jobs/%v/cancel", id), nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, bodyJson, recorder.Body.String())
+}
+
+func Test_RetryDeadLetterJobs_Returns_NotFoundError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	apiError := api_error.NewNotFoundError("no jobs with status dead_letter in joblist")
+	errorJson, _ := json.Marshal(apiError)
+	mockService.EXPECT().RetryDeadLetterJobs(gomock.Any()).Return(nil, apiError)
+	router.POST("/jobs/deadletter/retry", jh.RetryDeadLetterJobs)
+	request, _ := http.NewRequest(http.MethodPost, "/jobs/deadletter/retry", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusNotFound, recorder.Code)
+	assert.EqualValues(t, errorJson, recorder.Body.String())
+}
+
+func Test_RetryDeadLetterJobs_Returns_NoError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	jobs := []dto.JobResponse{
+		{Id: ksuid.New().String(), Name: "job 1", Status: "created"},
+		{Id: ksuid.New().String(), Name: "job 2", Status: "created"},
+	}
+	bodyJson, _ := json.Marshal(jobs)
+	mockService.EXPECT().RetryDeadLetterJobs(gomock.Any()).Return(&jobs, nil)
+	router.POST("/jobs/deadletter/retry", jh.RetryDeadLetterJobs)
+	request, _ := http.NewRequest(http.MethodPost, "/jobs/deadletter/retry", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, bodyJson, recorder.Body.String())
+}
+
+func Test_SetStatus_WrongId_Returns_BadRequestError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	router.PATCH("/jobs/:job_id/status", jh.SetStatus)
+	request, _ := http.NewRequest(http.MethodPatch, "/jobs/wrong_id/status", strings.NewReader(`{"status":"finished"}`))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+}
+
+func Test_SetStatus_Returns_InvalidJsonError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	apiError := api_error.NewBadRequestError("invalid json body")
+	errorJson, _ := json.Marshal(apiError)
+	router.PATCH("/jobs/:job_id/status", jh.SetStatus)
+	request, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/jobs/%v/status", ksuid.New()), strings.NewReader("not json"))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	assert.EqualValues(t, errorJson, recorder.Body.String())
+}
+
+func Test_SetStatus_Returns_ServiceError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	id := ksuid.New()
+	statusReq := dto.JobStatusUpdateRequest{Status: "done"}
+	apiError := api_error.NewBadRequestError("Could not parse status value done")
+	errorJson, _ := json.Marshal(apiError)
+	mockService.EXPECT().SetStatus(id.String(), statusReq).Return(apiError)
+	router.PATCH("/jobs/:job_id/status", jh.SetStatus)
+	request, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/jobs/%v/status", id), strings.NewReader(`{"status":"done"}`))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	assert.EqualValues(t, errorJson, recorder.Body.String())
+}
+
+func Test_SetStatus_Returns_NoError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	id := ksuid.New()
+	statusReq := dto.JobStatusUpdateRequest{Status: "failed", ErrMsg: "could not open file", ErrorCode: "storage_not_found"}
+	statusReqJson, _ := json.Marshal(statusReq)
+	mockService.EXPECT().SetStatus(id.String(), statusReq).Return(nil)
+	router.PATCH("/jobs/:job_id/status", jh.SetStatus)
+	request, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/jobs/%v/status", id), strings.NewReader(string(statusReqJson)))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+}
+
+func Test_SetStatus_Created_Returns_BadRequestError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	apiError := api_error.NewBadRequestError("Status created cannot be set through a status update")
+	errorJson, _ := json.Marshal(apiError)
+	router.PATCH("/jobs/:job_id/status", jh.SetStatus)
+	request, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/jobs/%v/status", ksuid.New()), strings.NewReader(`{"status":"created"}`))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	assert.EqualValues(t, errorJson, recorder.Body.String())
+}
+
+func Test_SetStatus_Queued_Returns_BadRequestError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	router.PATCH("/jobs/:job_id/status", jh.SetStatus)
+	request, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/jobs/%v/status", ksuid.New()), strings.NewReader(`{"status":"Queued"}`))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+}
+
+func Test_SetResult_NoBody_Returns_BadRequestError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	apiError := api_error.NewBadRequestError("result must be a valid json document")
+	errorJson, _ := json.Marshal(apiError)
+	router.PUT("/jobs/:job_id/result", jh.SetResult)
+	request, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/jobs/%v/result", ksuid.New()), nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	assert.EqualValues(t, errorJson, recorder.Body.String())
+}
+
+func Test_SetResult_InvalidJson_Returns_BadRequestError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	router.PUT("/jobs/:job_id/result", jh.SetResult)
+	request, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/jobs/%v/result", ksuid.New()), strings.NewReader(`{"streams": [`))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+}
+
+func Test_SetResult_TooLarge_Returns_BadRequestError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	apiError := api_error.NewBadRequestError("could not read result body")
+	errorJson, _ := json.Marshal(apiError)
+	router.PUT("/jobs/:job_id/result", jh.SetResult)
+	body := fmt.Sprintf(`{"data": "%s"}`, strings.Repeat("x", maxResultSize))
+	request, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/jobs/%v/result", ksuid.New()), strings.NewReader(body))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	assert.EqualValues(t, errorJson, recorder.Body.String())
+}
+
+func Test_SetResult_Returns_NoError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	id := ksuid.New()
+	result := `{"streams": [], "format": {"format_name": "mov,mp4,m4a,3gp,3g2,mj2"}}`
+	mockService.EXPECT().SetResult(id.String(), domain.EngineFfprobe, result).Return(nil)
+	router.PUT("/jobs/:job_id/result", jh.SetResult)
+	request, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/jobs/%v/result", id), strings.NewReader(result))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+}
+
+func Test_SetResult_NotRunning_Returns_ConflictError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	id := ksuid.New()
+	result := `{"streams": [], "format": {"format_name": "mov,mp4,m4a,3gp,3g2,mj2"}}`
+	apiError := api_error.NewProcessingConflictError(fmt.Sprintf("Job with id %v is not running, cannot set its result", id))
+	mockService.EXPECT().SetResult(id.String(), domain.EngineFfprobe, result).Return(apiError)
+	router.PUT("/jobs/:job_id/result", jh.SetResult)
+	request, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/jobs/%v/result", id), strings.NewReader(result))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusConflict, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "is not running")
+}
+
+func Test_SetStatus_IllegalTransition_Returns_ConflictError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	id := ksuid.New()
+	statusReq := dto.JobStatusUpdateRequest{Status: "running", ChangedBy: "worker-1"}
+	statusReqJson, _ := json.Marshal(statusReq)
+	apiError := api_error.NewProcessingConflictError(fmt.Sprintf("Cannot change status of job %v from finished to running", id))
+	errorJson, _ := json.Marshal(apiError)
+	mockService.EXPECT().SetStatus(id.String(), statusReq).Return(apiError)
+	router.PATCH("/jobs/:job_id/status", jh.SetStatus)
+	request, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/jobs/%v/status", id), strings.NewReader(string(statusReqJson)))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusConflict, recorder.Code)
+	assert.EqualValues(t, errorJson, recorder.Body.String())
+}
+
+func Test_PauseJob_WrongId_Returns_BadRequestError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	router.POST("/jobs/:job_id/pause", jh.PauseJob)
+	request, _ := http.NewRequest(http.MethodPost, "/jobs/wrong_id/pause", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+}
+
+func Test_PauseJob_Returns_NoError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	id := ksuid.New()
+	jobResp := dto.JobResponse{Id: id.String(), Name: "job 1", Status: "paused"}
+	bodyJson, _ := json.Marshal(jobResp)
+	mockService.EXPECT().PauseJob(id.String(), gomock.Any()).Return(&jobResp, nil)
+	router.POST("/jobs/:job_id/pause", jh.PauseJob)
+	request, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/jobs/%v/pause", id), nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, bodyJson, recorder.Body.String())
+}
+
+func Test_ResumeJob_NotPaused_Returns_ConflictError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	id := ksuid.New()
+	apiError := api_error.NewProcessingConflictError(fmt.Sprintf("Job with id %v is not paused", id))
+	errorJson, _ := json.Marshal(apiError)
+	mockService.EXPECT().ResumeJob(id.String(), gomock.Any()).Return(nil, apiError)
+	router.POST("/jobs/:job_id/resume", jh.ResumeJob)
+	request, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/jobs/%v/resume", id), nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusConflict, recorder.Code)
+	assert.EqualValues(t, errorJson, recorder.Body.String())
+}
+
+func Test_ResumeJob_Returns_NoError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	id := ksuid.New()
+	jobResp := dto.JobResponse{Id: id.String(), Name: "job 1", Status: "created"}
+	bodyJson, _ := json.Marshal(jobResp)
+	mockService.EXPECT().ResumeJob(id.String(), gomock.Any()).Return(&jobResp, nil)
+	router.POST("/jobs/:job_id/resume", jh.ResumeJob)
+	request, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/jobs/%v/resume", id), nil)
 
 	router.ServeHTTP(recorder, request)
 
 	assert.EqualValues(t, http.StatusOK, recorder.Code)
 	assert.EqualValues(t, bodyJson, recorder.Body.String())
 }
+
+func Test_GetProcessingStatus_Returns_Status(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	mockService.EXPECT().IsProcessingPaused().Return(false, nil)
+	router.GET("/processing", jh.GetProcessingStatus)
+	request, _ := http.NewRequest(http.MethodGet, "/processing", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, `{"paused":false}`, recorder.Body.String())
+}
+
+func Test_PauseProcessing_Returns_PausedStatus(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	mockService.EXPECT().PauseProcessing().Return(nil)
+	router.POST("/processing/pause", jh.PauseProcessing)
+	request, _ := http.NewRequest(http.MethodPost, "/processing/pause", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, `{"paused":true}`, recorder.Body.String())
+}
+
+func Test_ResumeProcessing_Returns_ResumedStatus(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	mockService.EXPECT().ResumeProcessing().Return(nil)
+	router.POST("/processing/resume", jh.ResumeProcessing)
+	request, _ := http.NewRequest(http.MethodPost, "/processing/resume", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, `{"paused":false}`, recorder.Body.String())
+}
+
+func Test_PauseProcessing_Returns_ServiceError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	apiError := api_error.NewInternalServerError("Database error while setting processing status", nil)
+	errorJson, _ := json.Marshal(apiError)
+	mockService.EXPECT().PauseProcessing().Return(apiError)
+	router.POST("/processing/pause", jh.PauseProcessing)
+	request, _ := http.NewRequest(http.MethodPost, "/processing/pause", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusInternalServerError, recorder.Code)
+	assert.EqualValues(t, errorJson, recorder.Body.String())
+}
+
+func Test_GetResult_NoResult_Returns_NotFoundError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	id := ksuid.New()
+	apiError := api_error.NewNotFoundError(fmt.Sprintf("Job with id %v has no ffprobe result yet", id))
+	errorJson, _ := json.Marshal(apiError)
+	mockService.EXPECT().GetResult(id.String(), domain.EngineFfprobe).Return("", apiError)
+	router.GET("/jobs/:job_id/result", jh.GetResult)
+	request, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/jobs/%v/result", id), nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusNotFound, recorder.Code)
+	assert.EqualValues(t, errorJson, recorder.Body.String())
+}
+
+func Test_GetResult_Returns_RawResult(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	id := ksuid.New()
+	result := `{"streams": [], "format": {"format_name": "wav"}}`
+	mockService.EXPECT().GetResult(id.String(), domain.EngineFfprobe).Return(result, nil)
+	router.GET("/jobs/:job_id/result", jh.GetResult)
+	request, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/jobs/%v/result", id), nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, result, recorder.Body.String())
+	assert.EqualValues(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+}
+
+func Test_SetResult_MediaInfo_Returns_NoError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	id := ksuid.New()
+	result := `{"media": {"track": [{"@type": "General"}]}}`
+	mockService.EXPECT().SetResult(id.String(), domain.EngineMediaInfo, result).Return(nil)
+	router.PUT("/jobs/:job_id/result", jh.SetResult)
+	request, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/jobs/%v/result?engine=mediainfo", id), strings.NewReader(result))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+}
+
+func Test_GetResult_UnknownEngine_Returns_BadRequestError(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	id := ksuid.New()
+	apiError := api_error.NewBadRequestError("Unknown probe engine exiftool")
+	errorJson, _ := json.Marshal(apiError)
+	router.GET("/jobs/:job_id/result", jh.GetResult)
+	request, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/jobs/%v/result?engine=exiftool", id), nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	assert.EqualValues(t, errorJson, recorder.Body.String())
+}
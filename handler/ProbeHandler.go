@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/probesvc/dto"
+	"github.com/johannes-kuhfuss/probesvc/service"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type ProbeHandlers struct {
+	Service service.ProbeService
+}
+
+func (ph ProbeHandlers) Probe(c *gin.Context) {
+	var probeReq dto.ProbeRequest
+	if err := c.ShouldBindJSON(&probeReq); err != nil {
+		logger.Error("invalid JSON body in probe request", err)
+		apiErr := api_error.NewBadRequestError("invalid json body")
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	if err := validateSrcUrl(probeReq.SrcUrl); err != nil {
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	result, err := ph.Service.Probe(c.Request.Context(), probeReq.SrcUrl)
+	if err != nil {
+		logger.Error("Service error while probing file", err)
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (ph ProbeHandlers) ProbeUpload(c *gin.Context) {
+	upload, size, err := uploadReader(c.Request)
+	if err != nil {
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	result, err := ph.Service.ProbeUpload(c.Request.Context(), upload, size)
+	if err != nil {
+		logger.Error("Service error while probing uploaded file", err)
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// uploadReader returns the first file part of a multipart upload, or the
+// request body itself for any other content type, without buffering either.
+// The size is only known for raw uploads and is -1 otherwise.
+func uploadReader(r *http.Request) (io.Reader, int64, api_error.ApiErr) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, 0, api_error.NewBadRequestError("upload must not be empty")
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, r.ContentLength, nil
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		logger.Error("invalid multipart upload", err)
+		return nil, 0, api_error.NewBadRequestError("invalid multipart upload")
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, 0, api_error.NewBadRequestError("multipart upload contains no file")
+		}
+		if err != nil {
+			logger.Error("invalid multipart upload", err)
+			return nil, 0, api_error.NewBadRequestError("invalid multipart upload")
+		}
+		if part.FileName() != "" {
+			return part, -1, nil
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/johannes-kuhfuss/probesvc/dto"
+	"github.com/johannes-kuhfuss/probesvc/mocks/service"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ph               ProbeHandlers
+	mockProbeService *service.MockProbeService
+)
+
+func setupProbeTest(t *testing.T) func() {
+	ctrl := gomock.NewController(t)
+	mockProbeService = service.NewMockProbeService(ctrl)
+	ph = ProbeHandlers{mockProbeService}
+	router = gin.Default()
+	recorder = httptest.NewRecorder()
+	return func() {
+		router = nil
+		ctrl.Finish()
+	}
+}
+
+func Test_Probe_Returns_InvalidJsonError(t *testing.T) {
+	teardown := setupProbeTest(t)
+	defer teardown()
+	router.POST("/probe", ph.Probe)
+	request, _ := http.NewRequest(http.MethodPost, "/probe", strings.NewReader("{"))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+}
+
+func Test_Probe_Returns_ServiceError(t *testing.T) {
+	teardown := setupProbeTest(t)
+	defer teardown()
+	apiError := api_error.NewError("source file is larger than the limit of 1024 bytes", http.StatusRequestEntityTooLarge, nil)
+	errorJson, _ := json.Marshal(apiError)
+	mockProbeService.EXPECT().Probe(gomock.Any(), "https://server/file.mp4").Return(nil, apiError)
+	router.POST("/probe", ph.Probe)
+	request, _ := http.NewRequest(http.MethodPost, "/probe", strings.NewReader(`{"src_url": "https://server/file.mp4"}`))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.EqualValues(t, errorJson, recorder.Body.String())
+}
+
+func Test_Probe_SignedUrl_Keeps_Query(t *testing.T) {
+	teardown := setupProbeTest(t)
+	defer teardown()
+	srcUrl := "https://bucket.server/file.mp4?sv=2021-08-06&se=2026-10-18T00%3A00%3A00Z&sig=abc"
+	mockProbeService.EXPECT().Probe(gomock.Any(), srcUrl).Return(&dto.ProbeResponse{}, nil)
+	router.POST("/probe", ph.Probe)
+	body, _ := json.Marshal(dto.ProbeRequest{SrcUrl: srcUrl})
+	request, _ := http.NewRequest(http.MethodPost, "/probe", bytes.NewReader(body))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+}
+
+func Test_Probe_Returns_NoError(t *testing.T) {
+	teardown := setupProbeTest(t)
+	defer teardown()
+	probeResp := dto.ProbeResponse{
+		TechInfo: dto.ProbeResultResponse{Format: dto.ProbeFormatResponse{FormatName: "wav"}},
+		Summary:  dto.MediaSummaryResponse{Container: "wav", DurationSeconds: 1.5},
+	}
+	bodyJson, _ := json.Marshal(probeResp)
+	mockProbeService.EXPECT().Probe(gomock.Any(), "https://server/file.wav").Return(&probeResp, nil)
+	router.POST("/probe", ph.Probe)
+	request, _ := http.NewRequest(http.MethodPost, "/probe", strings.NewReader(`{"src_url": "https://server/file.wav"}`))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, bodyJson, recorder.Body.String())
+}
+
+func Test_ProbeUpload_NoBody_Returns_BadRequestError(t *testing.T) {
+	teardown := setupProbeTest(t)
+	defer teardown()
+	router.POST("/probe/upload", ph.ProbeUpload)
+	request, _ := http.NewRequest(http.MethodPost, "/probe/upload", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "upload must not be empty")
+}
+
+func Test_ProbeUpload_RawBody_Streams_Body(t *testing.T) {
+	teardown := setupProbeTest(t)
+	defer teardown()
+	var received string
+	mockProbeService.EXPECT().ProbeUpload(gomock.Any(), gomock.Any(), int64(10)).DoAndReturn(
+		func(_ context.Context, upload io.Reader, _ int64) (*dto.ProbeResponse, api_error.ApiErr) {
+			data, _ := io.ReadAll(upload)
+			received = string(data)
+			return &dto.ProbeResponse{}, nil
+		})
+	router.POST("/probe/upload", ph.ProbeUpload)
+	request, _ := http.NewRequest(http.MethodPost, "/probe/upload", strings.NewReader("media data"))
+	request.Header.Set("Content-Type", "application/octet-stream")
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, "media data", received)
+}
+
+func Test_ProbeUpload_Multipart_Streams_FilePart(t *testing.T) {
+	teardown := setupProbeTest(t)
+	defer teardown()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("comment", "not the file")
+	part, _ := writer.CreateFormFile("file", "clip.wav")
+	part.Write([]byte("media data"))
+	writer.Close()
+	var received string
+	mockProbeService.EXPECT().ProbeUpload(gomock.Any(), gomock.Any(), int64(-1)).DoAndReturn(
+		func(_ context.Context, upload io.Reader, _ int64) (*dto.ProbeResponse, api_error.ApiErr) {
+			data, _ := io.ReadAll(upload)
+			received = string(data)
+			return &dto.ProbeResponse{}, nil
+		})
+	router.POST("/probe/upload", ph.ProbeUpload)
+	request, _ := http.NewRequest(http.MethodPost, "/probe/upload", &body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, "media data", received)
+}
+
+func Test_ProbeUpload_MultipartWithoutFile_Returns_BadRequestError(t *testing.T) {
+	teardown := setupProbeTest(t)
+	defer teardown()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("comment", "no file here")
+	writer.Close()
+	router.POST("/probe/upload", ph.ProbeUpload)
+	request, _ := http.NewRequest(http.MethodPost, "/probe/upload", &body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "multipart upload contains no file")
+}
@@ -5,10 +5,12 @@
 package domain
 
 import (
+	context "context"
 	reflect "reflect"
 
-	azblob "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	gomock "github.com/golang/mock/gomock"
+	domain "github.com/johannes-kuhfuss/probesvc/domain"
+	api_error "github.com/johannes-kuhfuss/services_utils/api_error"
 )
 
 // MockFileRepository is a mock of FileRepository interface.
@@ -34,16 +36,17 @@ func (m *MockFileRepository) EXPECT() *MockFileRepositoryMockRecorder {
 	return m.recorder
 }
 
-// GetClient mocks base method.
-func (m *MockFileRepository) GetClient() *azblob.ServiceClient {
+// GetReader mocks base method.
+func (m *MockFileRepository) GetReader(arg0 context.Context, arg1 string) (*domain.SourceFile, api_error.ApiErr) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetClient")
-	ret0, _ := ret[0].(*azblob.ServiceClient)
-	return ret0
+	ret := m.ctrl.Call(m, "GetReader", arg0, arg1)
+	ret0, _ := ret[0].(*domain.SourceFile)
+	ret1, _ := ret[1].(api_error.ApiErr)
+	return ret0, ret1
 }
 
-// GetClient indicates an expected call of GetClient.
-func (mr *MockFileRepositoryMockRecorder) GetClient() *gomock.Call {
+// GetReader indicates an expected call of GetReader.
+func (mr *MockFileRepositoryMockRecorder) GetReader(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClient", reflect.TypeOf((*MockFileRepository)(nil).GetClient))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReader", reflect.TypeOf((*MockFileRepository)(nil).GetReader), arg0, arg1)
 }
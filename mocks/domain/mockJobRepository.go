@@ -35,6 +35,21 @@ func (m *MockJobRepository) EXPECT() *MockJobRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ClaimNext mocks base method.
+func (m *MockJobRepository) ClaimNext(arg0 string) (*domain.Job, api_error.ApiErr) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimNext", arg0)
+	ret0, _ := ret[0].(*domain.Job)
+	ret1, _ := ret[1].(api_error.ApiErr)
+	return ret0, ret1
+}
+
+// ClaimNext indicates an expected call of ClaimNext.
+func (mr *MockJobRepositoryMockRecorder) ClaimNext(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimNext", reflect.TypeOf((*MockJobRepository)(nil).ClaimNext), arg0)
+}
+
 // DeleteById mocks base method.
 func (m *MockJobRepository) DeleteById(arg0 string) api_error.ApiErr {
 	m.ctrl.T.Helper()
@@ -79,19 +94,19 @@ func (mr *MockJobRepositoryMockRecorder) FindById(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindById", reflect.TypeOf((*MockJobRepository)(nil).FindById), arg0)
 }
 
-// GetNext mocks base method.
-func (m *MockJobRepository) GetNext() (*domain.Job, api_error.ApiErr) {
+// IsProcessingPaused mocks base method.
+func (m *MockJobRepository) IsProcessingPaused() (bool, api_error.ApiErr) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetNext")
-	ret0, _ := ret[0].(*domain.Job)
+	ret := m.ctrl.Call(m, "IsProcessingPaused")
+	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(api_error.ApiErr)
 	return ret0, ret1
 }
 
-// GetNext indicates an expected call of GetNext.
-func (mr *MockJobRepositoryMockRecorder) GetNext() *gomock.Call {
+// IsProcessingPaused indicates an expected call of IsProcessingPaused.
+func (mr *MockJobRepositoryMockRecorder) IsProcessingPaused() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNext", reflect.TypeOf((*MockJobRepository)(nil).GetNext))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsProcessingPaused", reflect.TypeOf((*MockJobRepository)(nil).IsProcessingPaused))
 }
 
 // Save mocks base method.
@@ -108,18 +123,60 @@ func (mr *MockJobRepositoryMockRecorder) Save(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockJobRepository)(nil).Save), arg0)
 }
 
+// SetDeepAnalysis mocks base method.
+func (m *MockJobRepository) SetDeepAnalysis(arg0 string, arg1 domain.DeepAnalysis) api_error.ApiErr {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDeepAnalysis", arg0, arg1)
+	ret0, _ := ret[0].(api_error.ApiErr)
+	return ret0
+}
+
+// SetDeepAnalysis indicates an expected call of SetDeepAnalysis.
+func (mr *MockJobRepositoryMockRecorder) SetDeepAnalysis(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeepAnalysis", reflect.TypeOf((*MockJobRepository)(nil).SetDeepAnalysis), arg0, arg1)
+}
+
+// SetMediaInfoResult mocks base method.
+func (m *MockJobRepository) SetMediaInfoResult(arg0 string, arg1 domain.MediaInfoResult) api_error.ApiErr {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMediaInfoResult", arg0, arg1)
+	ret0, _ := ret[0].(api_error.ApiErr)
+	return ret0
+}
+
+// SetMediaInfoResult indicates an expected call of SetMediaInfoResult.
+func (mr *MockJobRepositoryMockRecorder) SetMediaInfoResult(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMediaInfoResult", reflect.TypeOf((*MockJobRepository)(nil).SetMediaInfoResult), arg0, arg1)
+}
+
+// SetProcessingPaused mocks base method.
+func (m *MockJobRepository) SetProcessingPaused(arg0 bool) api_error.ApiErr {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProcessingPaused", arg0)
+	ret0, _ := ret[0].(api_error.ApiErr)
+	return ret0
+}
+
+// SetProcessingPaused indicates an expected call of SetProcessingPaused.
+func (mr *MockJobRepositoryMockRecorder) SetProcessingPaused(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProcessingPaused", reflect.TypeOf((*MockJobRepository)(nil).SetProcessingPaused), arg0)
+}
+
 // SetResult mocks base method.
-func (m *MockJobRepository) SetResult(arg0, arg1 string) api_error.ApiErr {
+func (m *MockJobRepository) SetResult(arg0, arg1 string, arg2 domain.ProbeResult) api_error.ApiErr {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetResult", arg0, arg1)
+	ret := m.ctrl.Call(m, "SetResult", arg0, arg1, arg2)
 	ret0, _ := ret[0].(api_error.ApiErr)
 	return ret0
 }
 
 // SetResult indicates an expected call of SetResult.
-func (mr *MockJobRepositoryMockRecorder) SetResult(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockJobRepositoryMockRecorder) SetResult(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetResult", reflect.TypeOf((*MockJobRepository)(nil).SetResult), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetResult", reflect.TypeOf((*MockJobRepository)(nil).SetResult), arg0, arg1, arg2)
 }
 
 // SetStatus mocks base method.
@@ -135,3 +192,17 @@ func (mr *MockJobRepositoryMockRecorder) SetStatus(arg0, arg1 interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatus", reflect.TypeOf((*MockJobRepository)(nil).SetStatus), arg0, arg1)
 }
+
+// Update mocks base method.
+func (m *MockJobRepository) Update(arg0 domain.Job, arg1 domain.JobStatus) api_error.ApiErr {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(api_error.ApiErr)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockJobRepositoryMockRecorder) Update(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockJobRepository)(nil).Update), arg0, arg1)
+}
@@ -5,10 +5,11 @@
 package service
 
 import (
-	io "io"
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	domain "github.com/johannes-kuhfuss/probesvc/domain"
 	dto "github.com/johannes-kuhfuss/probesvc/dto"
 	api_error "github.com/johannes-kuhfuss/services_utils/api_error"
 )
@@ -37,29 +38,29 @@ func (m *MockFileService) EXPECT() *MockFileServiceMockRecorder {
 }
 
 // Run mocks base method.
-func (m *MockFileService) Run() {
+func (m *MockFileService) Run(arg0 context.Context, arg1 <-chan struct{}, arg2 string) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Run")
+	m.ctrl.Call(m, "Run", arg0, arg1, arg2)
 }
 
 // Run indicates an expected call of Run.
-func (mr *MockFileServiceMockRecorder) Run() *gomock.Call {
+func (mr *MockFileServiceMockRecorder) Run(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockFileService)(nil).Run))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockFileService)(nil).Run), arg0, arg1, arg2)
 }
 
 // addResultToJob mocks base method.
-func (m *MockFileService) addResultToJob(arg0 *dto.JobResponse, arg1 string) api_error.ApiErr {
+func (m *MockFileService) addResultToJob(arg0 *dto.JobResponse, arg1 domain.ProbeEngine, arg2 string) api_error.ApiErr {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "addResultToJob", arg0, arg1)
+	ret := m.ctrl.Call(m, "addResultToJob", arg0, arg1, arg2)
 	ret0, _ := ret[0].(api_error.ApiErr)
 	return ret0
 }
 
 // addResultToJob indicates an expected call of addResultToJob.
-func (mr *MockFileServiceMockRecorder) addResultToJob(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockFileServiceMockRecorder) addResultToJob(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "addResultToJob", reflect.TypeOf((*MockFileService)(nil).addResultToJob), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "addResultToJob", reflect.TypeOf((*MockFileService)(nil).addResultToJob), arg0, arg1, arg2)
 }
 
 // failJob mocks base method.
@@ -90,31 +91,28 @@ func (mr *MockFileServiceMockRecorder) finishJob(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "finishJob", reflect.TypeOf((*MockFileService)(nil).finishJob), arg0)
 }
 
-// getAzureReader mocks base method.
-func (m *MockFileService) getAzureReader(arg0 string) (*io.ReadCloser, api_error.ApiErr) {
+// processJob mocks base method.
+func (m *MockFileService) processJob(arg0 context.Context, arg1 *dto.JobResponse) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "getAzureReader", arg0)
-	ret0, _ := ret[0].(*io.ReadCloser)
-	ret1, _ := ret[1].(api_error.ApiErr)
-	return ret0, ret1
+	m.ctrl.Call(m, "processJob", arg0, arg1)
 }
 
-// getAzureReader indicates an expected call of getAzureReader.
-func (mr *MockFileServiceMockRecorder) getAzureReader(arg0 interface{}) *gomock.Call {
+// processJob indicates an expected call of processJob.
+func (mr *MockFileServiceMockRecorder) processJob(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "getAzureReader", reflect.TypeOf((*MockFileService)(nil).getAzureReader), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "processJob", reflect.TypeOf((*MockFileService)(nil).processJob), arg0, arg1)
 }
 
-// startJob mocks base method.
-func (m *MockFileService) startJob(arg0 *dto.JobResponse) api_error.ApiErr {
+// requeueJob mocks base method.
+func (m *MockFileService) requeueJob(arg0 *dto.JobResponse) api_error.ApiErr {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "startJob", arg0)
+	ret := m.ctrl.Call(m, "requeueJob", arg0)
 	ret0, _ := ret[0].(api_error.ApiErr)
 	return ret0
 }
 
-// startJob indicates an expected call of startJob.
-func (mr *MockFileServiceMockRecorder) startJob(arg0 interface{}) *gomock.Call {
+// requeueJob indicates an expected call of requeueJob.
+func (mr *MockFileServiceMockRecorder) requeueJob(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "startJob", reflect.TypeOf((*MockFileService)(nil).startJob), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "requeueJob", reflect.TypeOf((*MockFileService)(nil).requeueJob), arg0)
 }
@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	domain "github.com/johannes-kuhfuss/probesvc/domain"
 	dto "github.com/johannes-kuhfuss/probesvc/dto"
 	api_error "github.com/johannes-kuhfuss/services_utils/api_error"
 )
@@ -35,6 +36,36 @@ func (m *MockJobService) EXPECT() *MockJobServiceMockRecorder {
 	return m.recorder
 }
 
+// CancelJob mocks base method.
+func (m *MockJobService) CancelJob(arg0, arg1 string) (*dto.JobResponse, api_error.ApiErr) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelJob", arg0, arg1)
+	ret0, _ := ret[0].(*dto.JobResponse)
+	ret1, _ := ret[1].(api_error.ApiErr)
+	return ret0, ret1
+}
+
+// CancelJob indicates an expected call of CancelJob.
+func (mr *MockJobServiceMockRecorder) CancelJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelJob", reflect.TypeOf((*MockJobService)(nil).CancelJob), arg0, arg1)
+}
+
+// ClaimNextJob mocks base method.
+func (m *MockJobService) ClaimNextJob(arg0 string) (*dto.JobResponse, api_error.ApiErr) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimNextJob", arg0)
+	ret0, _ := ret[0].(*dto.JobResponse)
+	ret1, _ := ret[1].(api_error.ApiErr)
+	return ret0, ret1
+}
+
+// ClaimNextJob indicates an expected call of ClaimNextJob.
+func (mr *MockJobServiceMockRecorder) ClaimNextJob(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimNextJob", reflect.TypeOf((*MockJobService)(nil).ClaimNextJob), arg0)
+}
+
 // CreateJob mocks base method.
 func (m *MockJobService) CreateJob(arg0 dto.NewJobRequest) (*dto.JobResponse, api_error.ApiErr) {
 	m.ctrl.T.Helper()
@@ -64,6 +95,20 @@ func (mr *MockJobServiceMockRecorder) DeleteJobById(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteJobById", reflect.TypeOf((*MockJobService)(nil).DeleteJobById), arg0)
 }
 
+// FailJob mocks base method.
+func (m *MockJobService) FailJob(arg0 string, arg1 domain.JobErrorCode, arg2 string) api_error.ApiErr {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailJob", arg0, arg1, arg2)
+	ret0, _ := ret[0].(api_error.ApiErr)
+	return ret0
+}
+
+// FailJob indicates an expected call of FailJob.
+func (mr *MockJobServiceMockRecorder) FailJob(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailJob", reflect.TypeOf((*MockJobService)(nil).FailJob), arg0, arg1, arg2)
+}
+
 // GetAllJobs mocks base method.
 func (m *MockJobService) GetAllJobs(arg0 string) (*[]dto.JobResponse, api_error.ApiErr) {
 	m.ctrl.T.Helper()
@@ -94,33 +139,135 @@ func (mr *MockJobServiceMockRecorder) GetJobById(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJobById", reflect.TypeOf((*MockJobService)(nil).GetJobById), arg0)
 }
 
-// GetNextJob mocks base method.
-func (m *MockJobService) GetNextJob() (*dto.JobResponse, api_error.ApiErr) {
+// GetResult mocks base method.
+func (m *MockJobService) GetResult(arg0 string, arg1 domain.ProbeEngine) (string, api_error.ApiErr) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetResult", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(api_error.ApiErr)
+	return ret0, ret1
+}
+
+// GetResult indicates an expected call of GetResult.
+func (mr *MockJobServiceMockRecorder) GetResult(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResult", reflect.TypeOf((*MockJobService)(nil).GetResult), arg0, arg1)
+}
+
+// IsProcessingPaused mocks base method.
+func (m *MockJobService) IsProcessingPaused() (bool, api_error.ApiErr) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsProcessingPaused")
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(api_error.ApiErr)
+	return ret0, ret1
+}
+
+// IsProcessingPaused indicates an expected call of IsProcessingPaused.
+func (mr *MockJobServiceMockRecorder) IsProcessingPaused() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsProcessingPaused", reflect.TypeOf((*MockJobService)(nil).IsProcessingPaused))
+}
+
+// PauseJob mocks base method.
+func (m *MockJobService) PauseJob(arg0, arg1 string) (*dto.JobResponse, api_error.ApiErr) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetNextJob")
+	ret := m.ctrl.Call(m, "PauseJob", arg0, arg1)
 	ret0, _ := ret[0].(*dto.JobResponse)
 	ret1, _ := ret[1].(api_error.ApiErr)
 	return ret0, ret1
 }
 
-// GetNextJob indicates an expected call of GetNextJob.
-func (mr *MockJobServiceMockRecorder) GetNextJob() *gomock.Call {
+// PauseJob indicates an expected call of PauseJob.
+func (mr *MockJobServiceMockRecorder) PauseJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseJob", reflect.TypeOf((*MockJobService)(nil).PauseJob), arg0, arg1)
+}
+
+// PauseProcessing mocks base method.
+func (m *MockJobService) PauseProcessing() api_error.ApiErr {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PauseProcessing")
+	ret0, _ := ret[0].(api_error.ApiErr)
+	return ret0
+}
+
+// PauseProcessing indicates an expected call of PauseProcessing.
+func (mr *MockJobServiceMockRecorder) PauseProcessing() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseProcessing", reflect.TypeOf((*MockJobService)(nil).PauseProcessing))
+}
+
+// ResumeJob mocks base method.
+func (m *MockJobService) ResumeJob(arg0, arg1 string) (*dto.JobResponse, api_error.ApiErr) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResumeJob", arg0, arg1)
+	ret0, _ := ret[0].(*dto.JobResponse)
+	ret1, _ := ret[1].(api_error.ApiErr)
+	return ret0, ret1
+}
+
+// ResumeJob indicates an expected call of ResumeJob.
+func (mr *MockJobServiceMockRecorder) ResumeJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeJob", reflect.TypeOf((*MockJobService)(nil).ResumeJob), arg0, arg1)
+}
+
+// ResumeProcessing mocks base method.
+func (m *MockJobService) ResumeProcessing() api_error.ApiErr {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResumeProcessing")
+	ret0, _ := ret[0].(api_error.ApiErr)
+	return ret0
+}
+
+// ResumeProcessing indicates an expected call of ResumeProcessing.
+func (mr *MockJobServiceMockRecorder) ResumeProcessing() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeProcessing", reflect.TypeOf((*MockJobService)(nil).ResumeProcessing))
+}
+
+// RetryDeadLetterJobs mocks base method.
+func (m *MockJobService) RetryDeadLetterJobs(arg0 string) (*[]dto.JobResponse, api_error.ApiErr) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryDeadLetterJobs", arg0)
+	ret0, _ := ret[0].(*[]dto.JobResponse)
+	ret1, _ := ret[1].(api_error.ApiErr)
+	return ret0, ret1
+}
+
+// RetryDeadLetterJobs indicates an expected call of RetryDeadLetterJobs.
+func (mr *MockJobServiceMockRecorder) RetryDeadLetterJobs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryDeadLetterJobs", reflect.TypeOf((*MockJobService)(nil).RetryDeadLetterJobs), arg0)
+}
+
+// SetDeepAnalysis mocks base method.
+func (m *MockJobService) SetDeepAnalysis(arg0, arg1 string) api_error.ApiErr {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDeepAnalysis", arg0, arg1)
+	ret0, _ := ret[0].(api_error.ApiErr)
+	return ret0
+}
+
+// SetDeepAnalysis indicates an expected call of SetDeepAnalysis.
+func (mr *MockJobServiceMockRecorder) SetDeepAnalysis(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNextJob", reflect.TypeOf((*MockJobService)(nil).GetNextJob))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeepAnalysis", reflect.TypeOf((*MockJobService)(nil).SetDeepAnalysis), arg0, arg1)
 }
 
 // SetResult mocks base method.
-func (m *MockJobService) SetResult(arg0, arg1 string) api_error.ApiErr {
+func (m *MockJobService) SetResult(arg0 string, arg1 domain.ProbeEngine, arg2 string) api_error.ApiErr {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetResult", arg0, arg1)
+	ret := m.ctrl.Call(m, "SetResult", arg0, arg1, arg2)
 	ret0, _ := ret[0].(api_error.ApiErr)
 	return ret0
 }
 
 // SetResult indicates an expected call of SetResult.
-func (mr *MockJobServiceMockRecorder) SetResult(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockJobServiceMockRecorder) SetResult(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetResult", reflect.TypeOf((*MockJobService)(nil).SetResult), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetResult", reflect.TypeOf((*MockJobService)(nil).SetResult), arg0, arg1, arg2)
 }
 
 // SetStatus mocks base method.
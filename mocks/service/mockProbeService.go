@@ -0,0 +1,68 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/johannes-kuhfuss/probesvc/service (interfaces: ProbeService)
+
+// Package service is a generated GoMock package.
+package service
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dto "github.com/johannes-kuhfuss/probesvc/dto"
+	api_error "github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+// MockProbeService is a mock of ProbeService interface.
+type MockProbeService struct {
+	ctrl     *gomock.Controller
+	recorder *MockProbeServiceMockRecorder
+}
+
+// MockProbeServiceMockRecorder is the mock recorder for MockProbeService.
+type MockProbeServiceMockRecorder struct {
+	mock *MockProbeService
+}
+
+// NewMockProbeService creates a new mock instance.
+func NewMockProbeService(ctrl *gomock.Controller) *MockProbeService {
+	mock := &MockProbeService{ctrl: ctrl}
+	mock.recorder = &MockProbeServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProbeService) EXPECT() *MockProbeServiceMockRecorder {
+	return m.recorder
+}
+
+// Probe mocks base method.
+func (m *MockProbeService) Probe(arg0 context.Context, arg1 string) (*dto.ProbeResponse, api_error.ApiErr) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Probe", arg0, arg1)
+	ret0, _ := ret[0].(*dto.ProbeResponse)
+	ret1, _ := ret[1].(api_error.ApiErr)
+	return ret0, ret1
+}
+
+// Probe indicates an expected call of Probe.
+func (mr *MockProbeServiceMockRecorder) Probe(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Probe", reflect.TypeOf((*MockProbeService)(nil).Probe), arg0, arg1)
+}
+
+// ProbeUpload mocks base method.
+func (m *MockProbeService) ProbeUpload(arg0 context.Context, arg1 io.Reader, arg2 int64) (*dto.ProbeResponse, api_error.ApiErr) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProbeUpload", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*dto.ProbeResponse)
+	ret1, _ := ret[1].(api_error.ApiErr)
+	return ret0, ret1
+}
+
+// ProbeUpload indicates an expected call of ProbeUpload.
+func (mr *MockProbeServiceMockRecorder) ProbeUpload(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProbeUpload", reflect.TypeOf((*MockProbeService)(nil).ProbeUpload), arg0, arg1, arg2)
+}
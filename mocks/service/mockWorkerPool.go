@@ -0,0 +1,75 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/johannes-kuhfuss/probesvc/service (interfaces: WorkerPool)
+
+// Package service is a generated GoMock package.
+package service
+
+import (
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockWorkerPool is a mock of WorkerPool interface.
+type MockWorkerPool struct {
+	ctrl     *gomock.Controller
+	recorder *MockWorkerPoolMockRecorder
+}
+
+// MockWorkerPoolMockRecorder is the mock recorder for MockWorkerPool.
+type MockWorkerPoolMockRecorder struct {
+	mock *MockWorkerPool
+}
+
+// NewMockWorkerPool creates a new mock instance.
+func NewMockWorkerPool(ctrl *gomock.Controller) *MockWorkerPool {
+	mock := &MockWorkerPool{ctrl: ctrl}
+	mock.recorder = &MockWorkerPoolMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWorkerPool) EXPECT() *MockWorkerPoolMockRecorder {
+	return m.recorder
+}
+
+// Size mocks base method.
+func (m *MockWorkerPool) Size() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Size")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Size indicates an expected call of Size.
+func (mr *MockWorkerPoolMockRecorder) Size() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Size", reflect.TypeOf((*MockWorkerPool)(nil).Size))
+}
+
+// Start mocks base method.
+func (m *MockWorkerPool) Start() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Start")
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockWorkerPoolMockRecorder) Start() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockWorkerPool)(nil).Start))
+}
+
+// Stop mocks base method.
+func (m *MockWorkerPool) Stop(arg0 time.Duration) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stop", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Stop indicates an expected call of Stop.
+func (mr *MockWorkerPoolMockRecorder) Stop(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockWorkerPool)(nil).Stop), arg0)
+}
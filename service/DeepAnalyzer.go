@@ -0,0 +1,363 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johannes-kuhfuss/probesvc/domain"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+// DeepAnalyzer reads every packet and frame of a source and aggregates them
+// into per stream statistics.
+type DeepAnalyzer interface {
+	Analyze(context.Context, io.Reader) (*domain.DeepAnalysis, api_error.ApiErr)
+	AnalyzeSeekable(context.Context, string) (*domain.DeepAnalysis, api_error.ApiErr)
+}
+
+// deepAnalysisArgs makes ffprobe print one compact line per packet and frame
+// with only the entries the aggregation needs.
+func deepAnalysisArgs(input string) []string {
+	return []string{
+		"-loglevel", "fatal",
+		"-print_format", "compact",
+		"-show_entries", "packet=codec_type,stream_index,pts_time,dts_time,size,flags:frame=media_type,stream_index,key_frame,pict_type,best_effort_timestamp_time",
+		input,
+	}
+}
+
+// deepLineSize bounds a single line of ffprobe output. Packet and frame lines
+// are far shorter than this.
+const deepLineSize = 1024 * 1024
+
+// deepMaxSeconds caps the bitrate series, so a broken timestamp cannot make
+// it grow without bound.
+const deepMaxSeconds = 7 * 24 * 60 * 60
+
+// Analyze decodes the whole source, so it takes about as long as playing the
+// file through a decoder. The output is aggregated while ffprobe runs and
+// never held in memory as a whole.
+func (p FfprobeProber) Analyze(ctx context.Context, src io.Reader) (*domain.DeepAnalysis, api_error.ApiErr) {
+	cmd := exec.CommandContext(ctx, p.path, deepAnalysisArgs("-")...)
+	cmd.Stdin = src
+	return analyzeStream(cmd)
+}
+
+func (p FfprobeProber) AnalyzeSeekable(ctx context.Context, input string) (*domain.DeepAnalysis, api_error.ApiErr) {
+	return analyzeStream(exec.CommandContext(ctx, p.path, deepAnalysisArgs(input)...))
+}
+
+func analyzeStream(cmd *exec.Cmd) (*domain.DeepAnalysis, api_error.ApiErr) {
+	aggregator := newDeepAggregator()
+	err := runStream(cmd, aggregator.read)
+	if err != nil {
+		return nil, err
+	}
+	analysis := aggregator.result()
+	return &analysis, nil
+}
+
+func (p NativeProber) Analyze(ctx context.Context, src io.Reader) (*domain.DeepAnalysis, api_error.ApiErr) {
+	analyzer, ok := p.fallback.(DeepAnalyzer)
+	if !ok {
+		return nil, deepNotSupportedError(p.fallback.Engine())
+	}
+	return analyzer.Analyze(ctx, src)
+}
+
+func (p NativeProber) AnalyzeSeekable(ctx context.Context, input string) (*domain.DeepAnalysis, api_error.ApiErr) {
+	analyzer, ok := p.fallback.(DeepAnalyzer)
+	if !ok {
+		return nil, deepNotSupportedError(p.fallback.Engine())
+	}
+	return analyzer.AnalyzeSeekable(ctx, input)
+}
+
+func deepNotSupportedError(engine domain.ProbeEngine) api_error.ApiErr {
+	msg := fmt.Sprintf("probe engine %v does not support deep analysis", engine)
+	return newProbeError(domain.ErrorCodeInternal, api_error.NewInternalServerError(msg, nil))
+}
+
+// deepProber runs a deep analysis where the file service expects a Prober,
+// so it gets the same reader, size and timeout handling as a probe.
+type deepProber struct {
+	analyzer DeepAnalyzer
+}
+
+func (p deepProber) Engine() domain.ProbeEngine {
+	return domain.EngineFfprobe
+}
+
+func (p deepProber) Probe(ctx context.Context, src io.Reader, opts ProbeOptions) (string, api_error.ApiErr) {
+	return deepResult(p.analyzer.Analyze(ctx, src))
+}
+
+func (p deepProber) ProbeSeekable(ctx context.Context, input string, opts ProbeOptions) (string, api_error.ApiErr) {
+	return deepResult(p.analyzer.AnalyzeSeekable(ctx, input))
+}
+
+func deepResult(analysis *domain.DeepAnalysis, err api_error.ApiErr) (string, api_error.ApiErr) {
+	if err != nil {
+		return "", err
+	}
+	data, marshalErr := json.Marshal(analysis)
+	if marshalErr != nil {
+		return "", newProbeError(domain.ErrorCodeInternal, api_error.NewInternalServerError("could not encode deep analysis", marshalErr))
+	}
+	return string(data), nil
+}
+
+// runStream runs cmd and hands its output to consume while it is produced.
+// If consume gives up early, the rest of the output is discarded so the
+// process can still finish.
+func runStream(cmd *exec.Cmd, consume func(io.Reader) error) api_error.ApiErr {
+	var stdErr bytes.Buffer
+	cmd.Stderr = &stdErr
+	stdout, pipeErr := cmd.StdoutPipe()
+	if pipeErr != nil {
+		return commandError(cmd, pipeErr, "")
+	}
+	if startErr := cmd.Start(); startErr != nil {
+		return commandError(cmd, startErr, "")
+	}
+	consumeErr := consume(stdout)
+	if consumeErr != nil {
+		io.Copy(io.Discard, stdout)
+	}
+	if err := commandError(cmd, cmd.Wait(), stdErr.String()); err != nil {
+		return err
+	}
+	if consumeErr != nil {
+		apiErr := api_error.NewInternalServerError(fmt.Sprintf("could not read output of %s", cmd.Args[0]), consumeErr)
+		return newProbeError(domain.ErrorCodeUnsupportedMedia, apiErr)
+	}
+	return nil
+}
+
+// streamStats collects the packets and frames of one stream. Packets give
+// the sizes for the bitrate, frames the picture types and key frames.
+type streamStats struct {
+	analysis     domain.StreamAnalysis
+	firstTime    float64
+	hasFirstTime bool
+	lastTime     float64
+	bits         []int64
+	// gopFrames counts the frames since the last key frame, gop and
+	// keyInterval the GOPs completed so far.
+	inGop       bool
+	gopFrames   int64
+	gop         runningStats
+	lastKey     float64
+	hasLastKey  bool
+	keyInterval runningStats
+}
+
+// runningStats keeps the minimum, maximum and sum of a series without
+// storing it.
+type runningStats struct {
+	count int64
+	min   float64
+	max   float64
+	total float64
+}
+
+func (r *runningStats) add(value float64) {
+	if r.count == 0 || value < r.min {
+		r.min = value
+	}
+	if r.count == 0 || value > r.max {
+		r.max = value
+	}
+	r.count++
+	r.total += value
+}
+
+func (r runningStats) avg() float64 {
+	if r.count == 0 {
+		return 0
+	}
+	return r.total / float64(r.count)
+}
+
+type deepAggregator struct {
+	streams map[int]*streamStats
+}
+
+func newDeepAggregator() *deepAggregator {
+	return &deepAggregator{streams: make(map[int]*streamStats)}
+}
+
+func (a *deepAggregator) read(output io.Reader) error {
+	scanner := bufio.NewScanner(output)
+	scanner.Buffer(make([]byte, 64*1024), deepLineSize)
+	for scanner.Scan() {
+		a.add(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// add aggregates one line of ffprobe's compact output, such as
+// "packet|codec_type=video|stream_index=0|pts_time=0.040000|...". Lines of
+// other sections are ignored.
+func (a *deepAggregator) add(line string) {
+	fields := strings.Split(line, "|")
+	values := make(map[string]string, len(fields))
+	for _, field := range fields[1:] {
+		if key, value, ok := cutField(field); ok {
+			values[key] = value
+		}
+	}
+	index, err := strconv.Atoi(values["stream_index"])
+	if err != nil {
+		return
+	}
+	switch fields[0] {
+	case "packet":
+		a.stream(index, values["codec_type"]).addPacket(values)
+	case "frame":
+		a.stream(index, values["media_type"]).addFrame(values)
+	}
+}
+
+func cutField(field string) (string, string, bool) {
+	i := strings.IndexByte(field, '=')
+	if i < 0 {
+		return "", "", false
+	}
+	return field[:i], field[i+1:], true
+}
+
+func (a *deepAggregator) stream(index int, codecType string) *streamStats {
+	stats, ok := a.streams[index]
+	if !ok {
+		stats = &streamStats{}
+		stats.analysis.Index = index
+		a.streams[index] = stats
+	}
+	if stats.analysis.CodecType == "" {
+		stats.analysis.CodecType = codecType
+	}
+	return stats
+}
+
+func parseTime(values map[string]string, keys ...string) (float64, bool) {
+	for _, key := range keys {
+		if t, err := strconv.ParseFloat(values[key], 64); err == nil && !math.IsNaN(t) && !math.IsInf(t, 0) {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+func (s *streamStats) addPacket(values map[string]string) {
+	size, _ := strconv.ParseInt(values["size"], 10, 64)
+	s.analysis.Packets++
+	s.analysis.Bytes += size
+	// The decoding timestamp increases steadily; packets without one are
+	// counted in the second of the packet before them.
+	t, ok := parseTime(values, "dts_time", "pts_time")
+	if !ok {
+		t = s.lastTime
+	}
+	if !s.hasFirstTime {
+		s.firstTime, s.hasFirstTime = t, true
+	}
+	s.lastTime = t
+	second := int(t - s.firstTime)
+	if second < 0 {
+		second = 0
+	}
+	if second >= deepMaxSeconds {
+		second = deepMaxSeconds - 1
+	}
+	for len(s.bits) <= second {
+		s.bits = append(s.bits, 0)
+	}
+	s.bits[second] += size * 8
+}
+
+func (s *streamStats) addFrame(values map[string]string) {
+	s.analysis.Frames++
+	if pictType := values["pict_type"]; pictType != "" && pictType != "?" {
+		if s.analysis.FrameTypes == nil {
+			s.analysis.FrameTypes = make(map[string]int64)
+		}
+		s.analysis.FrameTypes[pictType]++
+	}
+	if values["key_frame"] != "1" {
+		s.gopFrames++
+		return
+	}
+	s.analysis.KeyFrames++
+	if s.analysis.CodecType != "video" {
+		return
+	}
+	// Frames before the first key frame do not form a GOP.
+	if s.inGop {
+		s.gop.add(float64(s.gopFrames))
+	}
+	s.inGop, s.gopFrames = true, 1
+	if t, ok := parseTime(values, "best_effort_timestamp_time"); ok {
+		if s.hasLastKey {
+			s.keyInterval.add(t - s.lastKey)
+		}
+		s.lastKey, s.hasLastKey = t, true
+	}
+}
+
+func (a *deepAggregator) result() domain.DeepAnalysis {
+	indexes := make([]int, 0, len(a.streams))
+	for index := range a.streams {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	analysis := domain.DeepAnalysis{Streams: make([]domain.StreamAnalysis, 0, len(indexes))}
+	for _, index := range indexes {
+		analysis.Streams = append(analysis.Streams, a.streams[index].result())
+	}
+	return analysis
+}
+
+func (s *streamStats) result() domain.StreamAnalysis {
+	stream := s.analysis
+	stream.BitrateSeries = s.bits
+	if stream.BitrateSeries == nil {
+		stream.BitrateSeries = []int64{}
+	}
+	for _, bits := range s.bits {
+		if bits > stream.MaxBitrate {
+			stream.MaxBitrate = bits
+		}
+	}
+	if len(s.bits) > 0 {
+		stream.AvgBitrate = stream.Bytes * 8 / int64(len(s.bits))
+	}
+	// The frames after the last key frame form a final, possibly open, GOP.
+	if s.inGop {
+		gop := s.gop
+		gop.add(float64(s.gopFrames))
+		stream.Gop = &domain.GopAnalysis{
+			Count:               gop.count,
+			MinLength:           int64(gop.min),
+			MaxLength:           int64(gop.max),
+			AvgLength:           roundAnalysis(gop.avg()),
+			MinKeyFrameInterval: roundAnalysis(s.keyInterval.min),
+			MaxKeyFrameInterval: roundAnalysis(s.keyInterval.max),
+			AvgKeyFrameInterval: roundAnalysis(s.keyInterval.avg()),
+		}
+	}
+	return stream
+}
+
+func roundAnalysis(value float64) float64 {
+	return math.Round(value*1000000) / 1000000
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	realdomain "github.com/johannes-kuhfuss/probesvc/domain"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDeepOutput is a two second video stream with a GOP of three frames and
+// one audio packet, as ffprobe prints it in compact format.
+const testDeepOutput = `packet|codec_type=video|stream_index=0|pts_time=0.000000|dts_time=0.000000|size=1000|flags=K_
+frame|media_type=video|stream_index=0|key_frame=1|best_effort_timestamp_time=0.000000|pict_type=I
+packet|codec_type=audio|stream_index=1|pts_time=0.000000|dts_time=0.000000|size=200|flags=K_
+frame|media_type=audio|stream_index=1|key_frame=1|best_effort_timestamp_time=0.000000|pict_type=?
+packet|codec_type=video|stream_index=0|pts_time=0.500000|dts_time=0.500000|size=250|flags=__
+frame|media_type=video|stream_index=0|key_frame=0|best_effort_timestamp_time=0.500000|pict_type=P
+packet|codec_type=video|stream_index=0|pts_time=1.000000|dts_time=1.000000|size=250|flags=__
+frame|media_type=video|stream_index=0|key_frame=0|best_effort_timestamp_time=1.000000|pict_type=B
+packet|codec_type=video|stream_index=0|pts_time=1.500000|dts_time=1.500000|size=500|flags=K_
+frame|media_type=video|stream_index=0|key_frame=1|best_effort_timestamp_time=1.500000|pict_type=I
+side_data|side_data_type=unknown
+`
+
+type stubAnalyzer struct {
+	stubProber
+}
+
+func (p stubAnalyzer) Analyze(ctx context.Context, src io.Reader) (*realdomain.DeepAnalysis, api_error.ApiErr) {
+	io.Copy(io.Discard, src)
+	return p.AnalyzeSeekable(ctx, "-")
+}
+
+func (p stubAnalyzer) AnalyzeSeekable(ctx context.Context, input string) (*realdomain.DeepAnalysis, api_error.ApiErr) {
+	aggregator := newDeepAggregator()
+	aggregator.read(strings.NewReader(testDeepOutput))
+	analysis := aggregator.result()
+	return &analysis, nil
+}
+
+func Test_deepAggregator_Returns_StreamStatistics(t *testing.T) {
+	aggregator := newDeepAggregator()
+
+	err := aggregator.read(strings.NewReader(testDeepOutput))
+	analysis := aggregator.result()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, len(analysis.Streams))
+	video := analysis.Streams[0]
+	assert.EqualValues(t, "video", video.CodecType)
+	assert.EqualValues(t, 4, video.Packets)
+	assert.EqualValues(t, 2000, video.Bytes)
+	assert.EqualValues(t, 4, video.Frames)
+	assert.EqualValues(t, 2, video.KeyFrames)
+	assert.EqualValues(t, map[string]int64{"I": 2, "P": 1, "B": 1}, video.FrameTypes)
+	assert.EqualValues(t, []int64{10000, 6000}, video.BitrateSeries)
+	assert.EqualValues(t, 8000, video.AvgBitrate)
+	assert.EqualValues(t, 10000, video.MaxBitrate)
+	assert.EqualValues(t, realdomain.GopAnalysis{
+		Count:               2,
+		MinLength:           1,
+		MaxLength:           3,
+		AvgLength:           2,
+		MinKeyFrameInterval: 1.5,
+		MaxKeyFrameInterval: 1.5,
+		AvgKeyFrameInterval: 1.5,
+	}, *video.Gop)
+	audio := analysis.Streams[1]
+	assert.EqualValues(t, "audio", audio.CodecType)
+	assert.EqualValues(t, []int64{1600}, audio.BitrateSeries)
+	assert.Nil(t, audio.FrameTypes)
+	assert.Nil(t, audio.Gop)
+}
+
+func Test_deepAggregator_NoTimestamps_Uses_PreviousSecond(t *testing.T) {
+	aggregator := newDeepAggregator()
+
+	aggregator.add("packet|codec_type=video|stream_index=0|pts_time=N/A|dts_time=N/A|size=100|flags=__")
+	aggregator.add("packet|codec_type=video|stream_index=0|pts_time=N/A|dts_time=N/A|size=100|flags=__")
+	analysis := aggregator.result()
+
+	assert.EqualValues(t, []int64{1600}, analysis.Streams[0].BitrateSeries)
+}
+
+func Test_runStream_Helper_Streams_Output(t *testing.T) {
+	aggregator := newDeepAggregator()
+
+	err := runStream(helperCommand("frames"), aggregator.read)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, len(aggregator.result().Streams))
+}
+
+func Test_runStream_InvalidInput_Returns_UnsupportedMedia(t *testing.T) {
+	err := runStream(helperCommand("invalid"), newDeepAggregator().read)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, realdomain.ErrorCodeUnsupportedMedia, errorCode(err))
+}
+
+func Test_probeJob_DeepMode_Stores_DeepAnalysis(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	probingService := NewFileService(mockFileRepo, jobFileService,
+		stubAnalyzer{stubProber{engine: realdomain.EngineFfprobe, output: testResult}})
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	newJob.Mode = realdomain.JobModeDeep
+	id := newJob.Id.String()
+	jobResp := newJob.ToDto()
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), "url1").DoAndReturn(stubReader).Times(2)
+	mockJobFileRepo.EXPECT().FindById(id).Return(newJob, nil).Times(2)
+	mockJobFileRepo.EXPECT().SetResult(id, testResult, gomock.Any()).Return(nil)
+	mockJobFileRepo.EXPECT().SetDeepAnalysis(id, gomock.Any()).DoAndReturn(func(id string, analysis realdomain.DeepAnalysis) api_error.ApiErr {
+		assert.EqualValues(t, 2, len(analysis.Streams))
+		assert.EqualValues(t, 2, analysis.Streams[0].KeyFrames)
+		return nil
+	})
+
+	err := probingService.probeJob(context.Background(), &jobResp)
+
+	assert.Nil(t, err)
+}
+
+func Test_probeJob_DeepModeNotSupported_Returns_InternalServerError(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	probingService := NewFileService(mockFileRepo, jobFileService,
+		stubProber{engine: realdomain.EngineFfprobe, output: testResult})
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	newJob.Mode = realdomain.JobModeDeep
+	id := newJob.Id.String()
+	jobResp := newJob.ToDto()
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), "url1").DoAndReturn(stubReader)
+	mockJobFileRepo.EXPECT().FindById(id).Return(newJob, nil)
+	mockJobFileRepo.EXPECT().SetResult(id, testResult, gomock.Any()).Return(nil)
+
+	err := probingService.probeJob(context.Background(), &jobResp)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "probe engine ffprobe does not support deep analysis", err.Message())
+	assert.EqualValues(t, http.StatusInternalServerError, err.StatusCode())
+}
+
+func Test_NativeProber_Analyze_Uses_Fallback(t *testing.T) {
+	prober := NewNativeProber(stubAnalyzer{stubProber{engine: realdomain.EngineFfprobe}})
+
+	analysis, err := prober.Analyze(context.Background(), strings.NewReader("media"))
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, len(analysis.Streams))
+}
@@ -3,16 +3,14 @@ package service
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"net/url"
-	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/johannes-kuhfuss/probesvc/config"
 	"github.com/johannes-kuhfuss/probesvc/domain"
 	"github.com/johannes-kuhfuss/probesvc/dto"
@@ -22,116 +20,427 @@ import (
 
 //go:generate mockgen -destination=../mocks/service/mockFileService.go -package=service github.com/johannes-kuhfuss/probesvc/service FileService
 type FileService interface {
-	Run()
-	startJob(*dto.JobResponse) api_error.ApiErr
+	Run(context.Context, <-chan struct{}, string)
+	processJob(context.Context, *dto.JobResponse)
+	requeueJob(*dto.JobResponse) api_error.ApiErr
 	failJob(*dto.JobResponse, api_error.ApiErr) api_error.ApiErr
 	finishJob(*dto.JobResponse) api_error.ApiErr
-	addResultToJob(*dto.JobResponse, string) api_error.ApiErr
-	getAzureReader(string) (*io.ReadCloser, api_error.ApiErr)
+	addResultToJob(*dto.JobResponse, domain.ProbeEngine, string) api_error.ApiErr
+}
+
+//go:generate mockgen -destination=../mocks/service/mockProbeService.go -package=service github.com/johannes-kuhfuss/probesvc/service ProbeService
+type ProbeService interface {
+	Probe(context.Context, string) (*dto.ProbeResponse, api_error.ApiErr)
+	ProbeUpload(context.Context, io.Reader, int64) (*dto.ProbeResponse, api_error.ApiErr)
 }
 
 type DefaultFileService struct {
-	repo   domain.FileRepository
-	jobSrv JobService
+	repo    domain.FileRepository
+	jobSrv  JobService
+	probers map[domain.ProbeEngine]Prober
+}
+
+func NewFileService(repository domain.FileRepository, jobSrv JobService, probers ...Prober) DefaultFileService {
+	proberMap := make(map[domain.ProbeEngine]Prober)
+	for _, prober := range probers {
+		proberMap[prober.Engine()] = prober
+	}
+	return DefaultFileService{repository, jobSrv, proberMap}
+}
+
+func workerField(workerId string) logger.Field {
+	return logger.Field{Key: "worker", Value: workerId}
 }
 
 var (
-	jobStatus dto.JobStatusUpdateRequest = dto.JobStatusUpdateRequest{}
+	errSourceTooLarge = errors.New("source file exceeds size limit")
 )
 
-func NewFileService(repository domain.FileRepository, jobSrv JobService) DefaultFileService {
-	return DefaultFileService{repository, jobSrv}
+// probeLimits bounds a single data extraction. A maxSize of 0 means no limit.
+type probeLimits struct {
+	timeout int
+	maxSize int64
+}
+
+// sizeLimitReader fails once the source turns out to be larger than the
+// limit, instead of handing the prober a silently truncated file.
+type sizeLimitReader struct {
+	reader    io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		n, err := l.reader.Read(make([]byte, 1))
+		if n > 0 {
+			l.exceeded = true
+			return 0, errSourceTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.reader.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// readErrors remembers the first error reading a source returned, other
+// than the end of the source.
+type readErrors struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (e *readErrors) record(err error) {
+	if err == nil || err == io.EOF {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+func (e *readErrors) first() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+// readErrorReader records the errors reading the source returned.
+type readErrorReader struct {
+	reader io.Reader
+	errs   readErrors
 }
 
-func (s DefaultFileService) Run() {
+func (r *readErrorReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.errs.record(err)
+	return n, err
+}
+
+func isStopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
 
-	for !config.Shutdown {
-		job, err := s.jobSrv.GetNextJob()
+// Run claims and probes jobs until stop is closed. Cancelling ctx aborts the
+// probe in flight; the job is then put back into the queue.
+func (s DefaultFileService) Run(ctx context.Context, stop <-chan struct{}, workerId string) {
+	logger.Info("Worker started", workerField(workerId))
+	defer logger.Info("Worker stopped", workerField(workerId))
+	for !isStopped(stop) && ctx.Err() == nil {
+		job, err := s.jobSrv.ClaimNextJob(workerId)
 		if err != nil {
-			logger.Debug(err.Message())
-			time.Sleep(time.Second * time.Duration(config.NoJobWaitTime))
+			logger.Debug(err.Message(), workerField(workerId))
+			select {
+			case <-stop:
+			case <-ctx.Done():
+			case <-time.After(time.Second * time.Duration(config.NoJobWaitTime)):
+			}
 		} else {
-			s.startJob(job)
-			result, err := s.analyzeFile(job.SrcUrl)
-			if err != nil {
-				s.failJob(job, err)
-			} else {
-				err := s.addResultToJob(job, result)
-				if err != nil {
-					s.failJob(job, err)
-				} else {
-					s.finishJob(job)
+			s.processJob(ctx, job)
+		}
+	}
+}
+
+func (s DefaultFileService) processJob(ctx context.Context, job *dto.JobResponse) {
+	logger.Info(fmt.Sprintf("Started data extraction for Job ID %v with Source %v", job.Id, job.SrcUrl), workerField(job.ClaimedBy))
+	var jobCtx context.Context
+	var cancel context.CancelFunc
+	if config.JobTimeout > 0 {
+		jobCtx, cancel = context.WithTimeout(ctx, time.Second*time.Duration(config.JobTimeout))
+	} else {
+		jobCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+	cancelled := s.watchForCancel(jobCtx, cancel, job.Id)
+
+	err := s.probeJob(jobCtx, job)
+	switch {
+	case err == nil:
+		s.finishJob(job)
+	case ctx.Err() != nil:
+		s.requeueJob(job)
+	case cancelled():
+		logger.Info(fmt.Sprintf("Cancelled data extraction for Job ID %v with Source %v", job.Id, job.SrcUrl), workerField(job.ClaimedBy))
+	default:
+		s.failJob(job, err)
+	}
+}
+
+// probeJob runs every engine the job asks for, one after the other, each on
+// a fresh reader for the source, and stores each engine's result. Deep jobs
+// then read the source once more for the deep analysis.
+func (s DefaultFileService) probeJob(ctx context.Context, job *dto.JobResponse) api_error.ApiErr {
+	engines, err := domain.ParseProbeEngines(job.Engines)
+	if err != nil {
+		return err
+	}
+	for _, engine := range engines {
+		prober, err := s.prober(engine)
+		if err != nil {
+			return err
+		}
+		result, err := s.analyzeFile(ctx, prober, job.SrcUrl, probeLimits{timeout: config.JobTimeout}, ProbeOptions{Profile: job.Profile})
+		if err != nil {
+			return err
+		}
+		err = s.addResultToJob(job, engine, result)
+		if err != nil {
+			return err
+		}
+	}
+	if job.Mode == string(domain.JobModeDeep) {
+		return s.analyzeJob(ctx, job)
+	}
+	return nil
+}
+
+func (s DefaultFileService) analyzeJob(ctx context.Context, job *dto.JobResponse) api_error.ApiErr {
+	prober, err := s.prober(domain.EngineFfprobe)
+	if err != nil {
+		return err
+	}
+	analyzer, ok := prober.(DeepAnalyzer)
+	if !ok {
+		return deepNotSupportedError(domain.EngineFfprobe)
+	}
+	analysis, err := s.analyzeFile(ctx, deepProber{analyzer}, job.SrcUrl, probeLimits{timeout: config.JobTimeout}, ProbeOptions{})
+	if err != nil {
+		return err
+	}
+	return s.jobSrv.SetDeepAnalysis(job.Id, analysis)
+}
+
+func (s DefaultFileService) prober(engine domain.ProbeEngine) (Prober, api_error.ApiErr) {
+	prober, ok := s.probers[engine]
+	if !ok {
+		msg := fmt.Sprintf("probe engine %v is not available", engine)
+		return nil, newProbeError(domain.ErrorCodeInternal, api_error.NewInternalServerError(msg, nil))
+	}
+	return prober, nil
+}
+
+// watchForCancel polls the job status while the job is running and cancels
+// the job context once the job has been cancelled through the API. The
+// returned function reports whether that happened.
+func (s DefaultFileService) watchForCancel(ctx context.Context, cancel context.CancelFunc, jobId string) func() bool {
+	var mu sync.Mutex
+	var cancelled bool
+	interval := config.CancelPollInterval
+	if interval <= 0 {
+		logger.Error(fmt.Sprintf("Invalid cancel poll interval %v, not watching job %v for cancellation", interval, jobId), nil)
+		return func() bool { return false }
+	}
+	go func() {
+		ticker := time.NewTicker(time.Second * time.Duration(interval))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				job, err := s.jobSrv.GetJobById(jobId)
+				if err == nil && job.Status == string(domain.JobStatusCancelled) {
+					mu.Lock()
+					cancelled = true
+					mu.Unlock()
+					cancel()
+					return
 				}
 			}
 		}
+	}()
+	return func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return cancelled
 	}
 }
 
-func (s DefaultFileService) startJob(job *dto.JobResponse) api_error.ApiErr {
-	logger.Info(fmt.Sprintf("Started data extraction for Job ID %v with Source %v", job.Id, job.SrcUrl))
-	jobStatus.Status = "running"
+func (s DefaultFileService) requeueJob(job *dto.JobResponse) api_error.ApiErr {
+	logger.Info(fmt.Sprintf("Requeueing unfinished Job ID %v with Source %v", job.Id, job.SrcUrl), workerField(job.ClaimedBy))
+	jobStatus := dto.JobStatusUpdateRequest{
+		Status:    "created",
+		ErrMsg:    "",
+		ChangedBy: job.ClaimedBy,
+	}
 	err := s.jobSrv.SetStatus(job.Id, jobStatus)
 	return err
 }
 
 func (s DefaultFileService) failJob(job *dto.JobResponse, failErr api_error.ApiErr) api_error.ApiErr {
-	logger.Error("Error while analyzing file", failErr)
-	jobStatus.Status = "failed"
-	jobStatus.ErrMsg = "Error while analyzing file"
-	err := s.jobSrv.SetStatus(job.Id, jobStatus)
+	logger.Error("Error while analyzing file", failErr, workerField(job.ClaimedBy))
+	err := s.jobSrv.FailJob(job.Id, errorCode(failErr), failErr.Message())
 	return err
 }
 
 func (s DefaultFileService) finishJob(job *dto.JobResponse) api_error.ApiErr {
-	logger.Info(fmt.Sprintf("Finished data extraction for Job ID %v with Source %v", job.Id, job.SrcUrl))
-	jobStatus.Status = "finished"
-	jobStatus.ErrMsg = ""
+	logger.Info(fmt.Sprintf("Finished data extraction for Job ID %v with Source %v", job.Id, job.SrcUrl), workerField(job.ClaimedBy))
+	jobStatus := dto.JobStatusUpdateRequest{
+		Status:    "finished",
+		ErrMsg:    "",
+		ChangedBy: job.ClaimedBy,
+	}
 	err := s.jobSrv.SetStatus(job.Id, jobStatus)
 	return err
 }
 
-func (s DefaultFileService) addResultToJob(job *dto.JobResponse, result string) api_error.ApiErr {
-	err := s.jobSrv.SetResult(job.Id, result)
+func (s DefaultFileService) addResultToJob(job *dto.JobResponse, engine domain.ProbeEngine, result string) api_error.ApiErr {
+	err := s.jobSrv.SetResult(job.Id, engine, result)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s DefaultFileService) analyzeFile(srcUrl string) (string, api_error.ApiErr) {
-	ctx := context.Background()
-	reader, err := s.getAzureReader(srcUrl)
+// Probe runs the data extraction inline for a single source URL, bounded by
+// the probe timeout and size limit, and returns the parsed result.
+func (s DefaultFileService) Probe(ctx context.Context, srcUrl string) (*dto.ProbeResponse, api_error.ApiErr) {
+	if strings.TrimSpace(srcUrl) == "" {
+		return nil, api_error.NewBadRequestError("Probe request must have a source URL")
+	}
+	prober, err := s.prober(domain.EngineFfprobe)
+	if err != nil {
+		return nil, err
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, time.Second*time.Duration(config.ProbeTimeout))
+	defer cancel()
+
+	data, err := s.analyzeFile(probeCtx, prober, srcUrl, probeLimits{timeout: config.ProbeTimeout, maxSize: config.ProbeMaxSize}, ProbeOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return probeResponse(data)
+}
+
+// ProbeUpload streams an uploaded file straight into ffprobe. size is the
+// announced upload size, or -1 if unknown.
+func (s DefaultFileService) ProbeUpload(ctx context.Context, upload io.Reader, size int64) (*dto.ProbeResponse, api_error.ApiErr) {
+	limits := probeLimits{timeout: config.ProbeTimeout, maxSize: config.UploadMaxSize}
+	if size > limits.maxSize {
+		return nil, tooLargeError(limits.maxSize)
+	}
+	prober, err := s.prober(domain.EngineFfprobe)
 	if err != nil {
-		return "", api_error.NewInternalServerError("could not connect to storage", err)
+		return nil, err
 	}
+	probeCtx, cancel := context.WithTimeout(ctx, time.Second*time.Duration(config.ProbeTimeout))
+	defer cancel()
 
-	ffArgs := []string{"-loglevel", "fatal", "-print_format", "json", "-show_format", "-show_streams", "-"}
-	cmd := exec.CommandContext(ctx, config.FfprobePath, ffArgs...)
-	cmd.Stdin = *reader
+	data, err := analyzeReader(probeCtx, prober, upload, limits, ProbeOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return probeResponse(data)
+}
 
-	result, runErr := runProbe(cmd)
-	if runErr != nil {
-		return "", api_error.NewInternalServerError("could not extract metadata from file", err)
+func probeResponse(data string) (*dto.ProbeResponse, api_error.ApiErr) {
+	result, err := domain.ParseProbeResult(data)
+	if err != nil {
+		return nil, newProbeError(domain.ErrorCodeUnsupportedMedia, err)
 	}
-	return result, nil
+	return &dto.ProbeResponse{
+		TechInfo: result.ToDto(),
+		Summary:  result.Summary().ToDto(),
+	}, nil
 }
 
-func (s DefaultFileService) getAzureReader(srcUrl string) (*io.ReadCloser, api_error.ApiErr) {
-	url, _ := url.Parse(srcUrl)
-	containerName := strings.TrimLeft(filepath.Dir(url.Path), string(os.PathSeparator))
-	fileName := filepath.Base(srcUrl)
-	ctx := context.Background()
-	container := s.repo.GetClient().NewContainerClient(containerName)
-	blockBlob := container.NewBlobClient(fileName)
+// analyzeFile probes a source from storage. Probers that cannot read from a
+// stream always get the source in a seekable form. Other probers that can
+// seek get it if the seek mode asks for it, if the source's container needs
+// it, if it helps and the source can be read from any position, or once
+// streaming the source to them has failed.
+func (s DefaultFileService) analyzeFile(ctx context.Context, prober Prober, srcUrl string, limits probeLimits, opts ProbeOptions) (string, api_error.ApiErr) {
+	srcFile, err := s.openSource(ctx, srcUrl, limits)
+	if err != nil {
+		return "", err
+	}
+	defer srcFile.Reader.Close()
+	open := func(ctx context.Context) (*domain.SourceFile, api_error.ApiErr) {
+		return s.repo.GetReader(ctx, srcUrl)
+	}
+	if pathOnly, ok := prober.(pathOnlyProber); ok {
+		return analyzeSeekable(ctx, pathOnly, srcFile, srcFile.Reader, open, limits, opts)
+	}
+	seekable, ok := prober.(SeekableProber)
+	if !ok || config.SeekMode == seekModeStream {
+		return analyzeReader(ctx, prober, srcFile.Reader, limits, opts)
+	}
+	if config.SeekMode == seekModeSeekable {
+		return analyzeSeekable(ctx, seekable, srcFile, srcFile.Reader, open, limits, opts)
+	}
+
+	head, src := sniffSource(srcFile.Reader)
+	if needsSeeking(head) && canSeek(srcFile, limits) {
+		logger.Debug(fmt.Sprintf("Probing %v with seeking, as its index is not at the start", srcUrl))
+		return analyzeSeekable(ctx, seekable, srcFile, src, open, limits, opts)
+	}
+	// Sources that only profit from seeking are not spooled to disk for it.
+	if _, ok := srcFile.Reader.(io.ReadSeeker); ok && srcFile.Size > 0 && prefersSeeking(head) {
+		logger.Debug(fmt.Sprintf("Probing %v with seeking, as its index is not in the header", srcUrl))
+		return analyzeSeekable(ctx, seekable, srcFile, src, open, limits, opts)
+	}
+	result, err := analyzeReader(ctx, prober, src, limits, opts)
+	if err == nil || errorCode(err) != domain.ErrorCodeUnsupportedMedia || ctx.Err() != nil || !canSeek(srcFile, limits) {
+		return result, err
+	}
+	retryFile, retryErr := s.openSource(ctx, srcUrl, limits)
+	if retryErr != nil {
+		return "", err
+	}
+	defer retryFile.Reader.Close()
+	logger.Info(fmt.Sprintf("Retrying %v with seeking after streaming failed: %v", srcUrl, err.Message()))
+	return analyzeSeekable(ctx, seekable, retryFile, retryFile.Reader, open, limits, opts)
+}
 
-	get, err := blockBlob.Download(ctx, nil)
+func (s DefaultFileService) openSource(ctx context.Context, srcUrl string, limits probeLimits) (*domain.SourceFile, api_error.ApiErr) {
+	srcFile, err := s.repo.GetReader(ctx, srcUrl)
 	if err != nil {
-		logger.Error("Cannot access file on storage account", err)
-		return nil, api_error.NewBadRequestError("Cannot access file on storage account")
+		if isTimeout(ctx) {
+			return nil, timeoutError(limits.timeout)
+		}
+		return nil, storageError(err)
+	}
+	if limits.maxSize > 0 && srcFile.Size > limits.maxSize {
+		srcFile.Reader.Close()
+		return nil, tooLargeError(limits.maxSize)
+	}
+	return srcFile, nil
+}
+
+func analyzeReader(ctx context.Context, prober Prober, src io.Reader, limits probeLimits, opts ProbeOptions) (string, api_error.ApiErr) {
+	source := &readErrorReader{reader: src}
+	src = source
+	var limited *sizeLimitReader
+	if limits.maxSize > 0 {
+		limited = &sizeLimitReader{reader: src, remaining: limits.maxSize}
+		src = limited
 	}
-	reader := get.Body(azblob.RetryReaderOptions{})
 
-	return &reader, nil
+	result, runErr := prober.Probe(ctx, src, opts)
+	if limited != nil && limited.exceeded {
+		return "", tooLargeError(limits.maxSize)
+	}
+	if runErr != nil {
+		if isTimeout(ctx) {
+			return "", timeoutError(limits.timeout)
+		}
+		if readErr := source.errs.first(); readErr != nil {
+			return "", readError(readErr)
+		}
+		return "", runErr
+	}
+	return result, nil
 }
 
 func runProbe(cmd *exec.Cmd) (data string, err api_error.ApiErr) {
@@ -141,14 +450,26 @@ func runProbe(cmd *exec.Cmd) (data string, err api_error.ApiErr) {
 	cmd.Stdout = &outputBuf
 	cmd.Stderr = &stdErr
 
-	runErr := cmd.Run()
-	if runErr != nil {
-		return "", api_error.NewInternalServerError(fmt.Sprintf("error running %s [%s]", config.FfprobePath, stdErr.String()), runErr)
-	}
-	if stdErr.Len() > 0 {
-		return "", api_error.NewInternalServerError(fmt.Sprintf("error running %s [%s]", config.FfprobePath, stdErr.String()), nil)
+	if err := commandError(cmd, cmd.Run(), stdErr.String()); err != nil {
+		return "", err
 	}
 	data = outputBuf.String()
 
 	return data, nil
 }
+
+// commandError classifies how a prober process ended. Only the exit status
+// counts; stderr goes into the message, as probers also print warnings there
+// for files they read fine.
+func commandError(cmd *exec.Cmd, runErr error, stdErr string) api_error.ApiErr {
+	if runErr == nil {
+		return nil
+	}
+	apiErr := api_error.NewInternalServerError(fmt.Sprintf("error running %s [%s]", cmd.Args[0], stdErr), runErr)
+	// Probers exit with an error code on input they cannot parse; anything
+	// else means the prober did not start or was killed.
+	if exitErr, ok := runErr.(*exec.ExitError); ok && exitErr.Exited() {
+		return newProbeError(domain.ErrorCodeUnsupportedMedia, apiErr)
+	}
+	return newProbeError(domain.ErrorCodeProbeCrash, apiErr)
+}
@@ -2,10 +2,16 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
 	"os/exec"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/golang/mock/gomock"
 	"github.com/johannes-kuhfuss/probesvc/config"
@@ -32,14 +38,14 @@ func setupFile(t *testing.T) func() {
 	jobFileService = NewJobService(mockJobFileRepo)
 	fileCtrl = gomock.NewController(t)
 	mockFileRepo = domain.NewMockFileRepository(fileCtrl)
-	fileService = NewFileService(mockFileRepo, jobFileService)
+	fileService = NewFileService(mockFileRepo, jobFileService, NewFfprobeProber(probePath, nil))
 	return func() {
 		fileService = nil
 		fileCtrl.Finish()
 	}
 }
 
-func Test_startJob_Returns_NotFoundError(t *testing.T) {
+func Test_failJob_Returns_NotFoundError(t *testing.T) {
 	teardown := setupFile(t)
 	defer teardown()
 	newJob, _ := realdomain.NewJob("job 1", "url1")
@@ -47,72 +53,63 @@ func Test_startJob_Returns_NotFoundError(t *testing.T) {
 	jobReq := newJob.ToDto()
 
 	apiError := api_error.NewNotFoundError(fmt.Sprintf("Job with id %v does not exist", id))
+	failErr := api_error.NewBadRequestError("bad request")
 	mockJobFileRepo.EXPECT().FindById(id).Return(nil, apiError)
 
-	err := fileService.startJob(&jobReq)
+	err := fileService.failJob(&jobReq, failErr)
 
 	assert.NotNil(t, err)
 	assert.EqualValues(t, fmt.Sprintf("Job with id %v does not exist", id), err.Message())
 	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
 }
 
-func Test_startJob_Returns_NoError(t *testing.T) {
+func Test_failJob_Returns_NoError(t *testing.T) {
 	teardown := setupFile(t)
 	defer teardown()
 	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
 	id := newJob.Id.String()
 	jobReq := newJob.ToDto()
-	jobStatus := dto.JobStatusUpdateRequest{
-		Status: "running",
-		ErrMsg: "",
-	}
-	jobStatusReq, _ := realdomain.ParseStatusRequest(jobStatus)
+	failErr := api_error.NewBadRequestError("bad request")
+	var saved realdomain.Job
 
 	mockJobFileRepo.EXPECT().FindById(id).Return(newJob, nil)
-	mockJobFileRepo.EXPECT().SetStatus(id, *jobStatusReq).Return(nil)
-
-	err := fileService.startJob(&jobReq)
-
-	assert.Nil(t, err)
-}
-
-func Test_failJob_Returns_NotFoundError(t *testing.T) {
-	teardown := setupFile(t)
-	defer teardown()
-	newJob, _ := realdomain.NewJob("job 1", "url1")
-	id := newJob.Id.String()
-	jobReq := newJob.ToDto()
-
-	apiError := api_error.NewNotFoundError(fmt.Sprintf("Job with id %v does not exist", id))
-	failErr := api_error.NewBadRequestError("bad request")
-	mockJobFileRepo.EXPECT().FindById(id).Return(nil, apiError)
+	mockJobFileRepo.EXPECT().Update(gomock.Any(), realdomain.JobStatusRunning).DoAndReturn(func(job realdomain.Job, oldStatus realdomain.JobStatus) api_error.ApiErr {
+		saved = job
+		return nil
+	})
 
 	err := fileService.failJob(&jobReq, failErr)
 
-	assert.NotNil(t, err)
-	assert.EqualValues(t, fmt.Sprintf("Job with id %v does not exist", id), err.Message())
-	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	assert.Nil(t, err)
+	assert.EqualValues(t, realdomain.JobStatusFailed, saved.Status)
+	assert.EqualValues(t, realdomain.ErrorCodeInternal, saved.ErrorCode)
+	assert.EqualValues(t, "bad request", saved.ErrorMsg)
 }
 
-func Test_failJob_Returns_NoError(t *testing.T) {
+func Test_failJob_NetworkError_RequeuesJob(t *testing.T) {
 	teardown := setupFile(t)
 	defer teardown()
 	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	newJob.Attempts = 1
 	id := newJob.Id.String()
 	jobReq := newJob.ToDto()
-	jobStatus := dto.JobStatusUpdateRequest{
-		Status: "failed",
-		ErrMsg: "Error while analyzing file",
-	}
-	jobStatusReq, _ := realdomain.ParseStatusRequest(jobStatus)
-	failErr := api_error.NewBadRequestError("bad request")
+	failErr := newProbeError(realdomain.ErrorCodeNetwork, api_error.NewInternalServerError("could not read source file", nil))
+	var saved realdomain.Job
 
 	mockJobFileRepo.EXPECT().FindById(id).Return(newJob, nil)
-	mockJobFileRepo.EXPECT().SetStatus(id, *jobStatusReq).Return(nil)
+	mockJobFileRepo.EXPECT().Update(gomock.Any(), realdomain.JobStatusRunning).DoAndReturn(func(job realdomain.Job, oldStatus realdomain.JobStatus) api_error.ApiErr {
+		saved = job
+		return nil
+	})
 
 	err := fileService.failJob(&jobReq, failErr)
 
 	assert.Nil(t, err)
+	assert.EqualValues(t, realdomain.JobStatusCreated, saved.Status)
+	assert.EqualValues(t, 1, len(saved.ErrorHistory))
+	assert.True(t, saved.NextAttemptAt.After(newJob.CreatedAt))
 }
 
 func Test_finishJob_Returns_NotFoundError(t *testing.T) {
@@ -136,6 +133,7 @@ func Test_finishJob_Returns_NoError(t *testing.T) {
 	teardown := setupFile(t)
 	defer teardown()
 	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.TechInfo = testResult
 	id := newJob.Id.String()
 	jobReq := newJob.ToDto()
 	jobStatus := dto.JobStatusUpdateRequest{
@@ -162,7 +160,7 @@ func Test_addResultToJob_Returns_NotFoundError(t *testing.T) {
 	apiError := api_error.NewNotFoundError(fmt.Sprintf("Job with id %v does not exist", id))
 	mockJobFileRepo.EXPECT().FindById(id).Return(nil, apiError)
 
-	err := fileService.addResultToJob(&jobReq, "")
+	err := fileService.addResultToJob(&jobReq, realdomain.EngineFfprobe, "")
 
 	assert.NotNil(t, err)
 	assert.EqualValues(t, fmt.Sprintf("Job with id %v does not exist", id), err.Message())
@@ -173,14 +171,15 @@ func Test_addResultToJob_Returns_NoError(t *testing.T) {
 	teardown := setupFile(t)
 	defer teardown()
 	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
 	id := newJob.Id.String()
 	jobReq := newJob.ToDto()
-	result := "result data"
+	result := `{"format": {"format_name": "wav"}}`
 
 	mockJobFileRepo.EXPECT().FindById(id).Return(newJob, nil)
-	mockJobFileRepo.EXPECT().SetResult(id, result).Return(nil)
+	mockJobFileRepo.EXPECT().SetResult(id, result, gomock.Any()).Return(nil)
 
-	err := fileService.addResultToJob(&jobReq, result)
+	err := fileService.addResultToJob(&jobReq, realdomain.EngineFfprobe, result)
 
 	assert.Nil(t, err)
 }
@@ -210,3 +209,373 @@ func Test_runProbe_Returns_NoError(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, data)
 }
+
+func Test_analyzeFile_StorageError_Returns_InternalServerError(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	srcUrl := "https://server/path/file.ext"
+	storageErr := api_error.NewBadRequestError("Cannot access file on storage account")
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), srcUrl).Return(nil, storageErr)
+
+	result, err := fileService.(DefaultFileService).analyzeFile(context.Background(), NewFfprobeProber(probePath, nil), srcUrl, probeLimits{}, ProbeOptions{})
+
+	assert.EqualValues(t, "", result)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "could not read source file: Cannot access file on storage account", err.Message())
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+	assert.EqualValues(t, realdomain.ErrorCodeInvalidSource, errorCode(err))
+}
+
+func Test_Run_Stopped_DoesNotClaim(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	stop := make(chan struct{})
+	close(stop)
+
+	fileService.Run(context.Background(), stop, "worker-1")
+}
+
+func Test_Run_Aborted_RequeuesJob(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	ctx, abort := context.WithCancel(context.Background())
+	defer abort()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	newJob.ClaimedBy = "worker-1"
+	id := newJob.Id.String()
+	mockJobFileRepo.EXPECT().ClaimNext("worker-1").Return(newJob, nil)
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), "url1").DoAndReturn(func(context.Context, string) (*realdomain.SourceFile, api_error.ApiErr) {
+		abort()
+		return nil, api_error.NewInternalServerError("aborted", nil)
+	})
+	mockJobFileRepo.EXPECT().FindById(id).Return(newJob, nil)
+	requeued, _ := realdomain.ParseStatusRequest(dto.JobStatusUpdateRequest{Status: "created", ChangedBy: "worker-1"})
+	mockJobFileRepo.EXPECT().SetStatus(id, *requeued).Return(nil)
+
+	fileService.Run(ctx, make(chan struct{}), "worker-1")
+}
+
+func blockingReader(ctx context.Context, srcUrl string) (*realdomain.SourceFile, api_error.ApiErr) {
+	<-ctx.Done()
+	return nil, api_error.NewInternalServerError("Cannot access file", ctx.Err())
+}
+
+func Test_processJob_Timeout_SetsTimedOut(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	config.JobTimeout = 1
+	defer func() { config.JobTimeout = 3600 }()
+	config.CancelPollInterval = 3600
+	defer func() { config.CancelPollInterval = 5 }()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	id := newJob.Id.String()
+	jobResp := newJob.ToDto()
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), "url1").DoAndReturn(blockingReader)
+	var saved realdomain.Job
+	mockJobFileRepo.EXPECT().FindById(id).Return(newJob, nil)
+	mockJobFileRepo.EXPECT().Update(gomock.Any(), realdomain.JobStatusRunning).DoAndReturn(func(job realdomain.Job, oldStatus realdomain.JobStatus) api_error.ApiErr {
+		saved = job
+		return nil
+	})
+
+	fileService.processJob(context.Background(), &jobResp)
+
+	assert.EqualValues(t, realdomain.JobStatusTimedOut, saved.Status)
+	assert.EqualValues(t, realdomain.ErrorCodeTimeout, saved.ErrorCode)
+	assert.EqualValues(t, "Data extraction did not finish within 1 seconds", saved.ErrorMsg)
+}
+
+func Test_processJob_Cancelled_StopsProbe(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	config.CancelPollInterval = 1
+	defer func() { config.CancelPollInterval = 5 }()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	id := newJob.Id.String()
+	jobResp := newJob.ToDto()
+	cancelledJob := *newJob
+	cancelledJob.Status = realdomain.JobStatusCancelled
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), "url1").DoAndReturn(blockingReader)
+	mockJobFileRepo.EXPECT().FindById(id).Return(&cancelledJob, nil)
+
+	fileService.processJob(context.Background(), &jobResp)
+}
+
+func Test_watchForCancel_InvalidInterval_DoesNotWatch(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	config.CancelPollInterval = 0
+	defer func() { config.CancelPollInterval = 5 }()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cancelled := fileService.(DefaultFileService).watchForCancel(ctx, cancel, "job 1")
+
+	assert.False(t, cancelled())
+	assert.Nil(t, ctx.Err())
+}
+
+func Test_analyzeFile_StorageUnavailable_Returns_NetworkError(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	srcUrl := "https://server/path/file.ext"
+	storageErr := api_error.NewInternalServerError("Cannot access file", nil)
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), srcUrl).Return(nil, storageErr)
+
+	_, err := fileService.(DefaultFileService).analyzeFile(context.Background(), NewFfprobeProber(probePath, nil), srcUrl, probeLimits{}, ProbeOptions{})
+
+	assert.EqualValues(t, realdomain.ErrorCodeNetwork, errorCode(err))
+}
+
+func Test_Probe_NoSrcUrl_Returns_BadRequestError(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+
+	result, err := fileService.(DefaultFileService).Probe(context.Background(), " ")
+
+	assert.Nil(t, result)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "Probe request must have a source URL", err.Message())
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func Test_Probe_SourceTooLarge_Returns_TooLargeError(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	config.ProbeMaxSize = 1024
+	defer func() { config.ProbeMaxSize = 1024 * 1024 * 1024 }()
+	srcFile := realdomain.SourceFile{Reader: io.NopCloser(strings.NewReader("")), Size: 2048}
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), "url1").Return(&srcFile, nil)
+
+	result, err := fileService.(DefaultFileService).Probe(context.Background(), "url1")
+	errJson, _ := json.Marshal(err)
+
+	assert.Nil(t, result)
+	assert.EqualValues(t, http.StatusRequestEntityTooLarge, err.StatusCode())
+	assert.EqualValues(t, realdomain.ErrorCodeTooLarge, errorCode(err))
+	assert.Contains(t, string(errJson), `"error_code":"too_large"`)
+}
+
+func Test_Probe_Timeout_Returns_TimeoutError(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	config.ProbeTimeout = 1
+	defer func() { config.ProbeTimeout = 30 }()
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), "url1").DoAndReturn(blockingReader)
+
+	result, err := fileService.(DefaultFileService).Probe(context.Background(), "url1")
+
+	assert.Nil(t, result)
+	assert.EqualValues(t, http.StatusGatewayTimeout, err.StatusCode())
+	assert.EqualValues(t, "Data extraction did not finish within 1 seconds", err.Message())
+	assert.EqualValues(t, realdomain.ErrorCodeTimeout, errorCode(err))
+}
+
+func Test_ProbeUpload_AnnouncedSizeTooLarge_Returns_TooLargeError(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	config.UploadMaxSize = 1024
+	defer func() { config.UploadMaxSize = 1024 * 1024 * 1024 }()
+
+	result, err := fileService.(DefaultFileService).ProbeUpload(context.Background(), strings.NewReader(""), 2048)
+
+	assert.Nil(t, result)
+	assert.EqualValues(t, http.StatusRequestEntityTooLarge, err.StatusCode())
+	assert.EqualValues(t, "source file is larger than the limit of 1024 bytes", err.Message())
+}
+
+func Test_sizeLimitReader_ExactSize_Returns_EOF(t *testing.T) {
+	reader := &sizeLimitReader{reader: strings.NewReader("12345"), remaining: 5}
+
+	data, err := io.ReadAll(reader)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "12345", string(data))
+	assert.False(t, reader.exceeded)
+}
+
+func Test_sizeLimitReader_LargerSource_Returns_Error(t *testing.T) {
+	reader := &sizeLimitReader{reader: strings.NewReader("123456"), remaining: 5}
+
+	data, err := io.ReadAll(reader)
+
+	assert.EqualValues(t, errSourceTooLarge, err)
+	assert.EqualValues(t, "12345", string(data))
+	assert.True(t, reader.exceeded)
+}
+
+func helperCommand(mode string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], "-test.run=Test_HelperProcess", "--", mode)
+	cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+	return cmd
+}
+
+// Test_HelperProcess stands in for ffprobe when started by helperCommand.
+func Test_HelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	switch os.Args[len(os.Args)-1] {
+	case "invalid":
+		fmt.Fprint(os.Stderr, "pipe:: Invalid data found when processing input")
+		os.Exit(1)
+	case "warning":
+		fmt.Fprint(os.Stderr, "Track 1: unknown codec id")
+		fmt.Fprint(os.Stdout, "{}")
+		os.Exit(0)
+	case "frames":
+		fmt.Fprint(os.Stdout, testDeepOutput)
+		os.Exit(0)
+	case "truncated":
+		io.Copy(io.Discard, os.Stdin)
+		fmt.Fprint(os.Stderr, "pipe:: Invalid data found when processing input")
+		os.Exit(1)
+	default:
+		fmt.Fprint(os.Stdout, "{}")
+		os.Exit(0)
+	}
+}
+
+func Test_runProbe_InvalidInput_Returns_UnsupportedMedia(t *testing.T) {
+	_, err := runProbe(helperCommand("invalid"))
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, realdomain.ErrorCodeUnsupportedMedia, errorCode(err))
+	assert.Contains(t, err.Message(), "Invalid data found when processing input")
+}
+
+// helperProber feeds the source to a helper process.
+type helperProber struct {
+	mode string
+}
+
+func (p helperProber) Engine() realdomain.ProbeEngine {
+	return realdomain.EngineFfprobe
+}
+
+func (p helperProber) Probe(ctx context.Context, src io.Reader, opts ProbeOptions) (string, api_error.ApiErr) {
+	cmd := helperCommand(p.mode)
+	cmd.Stdin = src
+	return runProbe(cmd)
+}
+
+func Test_analyzeReader_SourceFailsPartway_Returns_NetworkError(t *testing.T) {
+	src := io.MultiReader(strings.NewReader("media"), iotest.ErrReader(errors.New("connection reset by peer")))
+
+	_, err := analyzeReader(context.Background(), helperProber{mode: "truncated"}, src, probeLimits{}, ProbeOptions{})
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, realdomain.ErrorCodeNetwork, errorCode(err))
+	assert.EqualValues(t, "could not read source file: connection reset by peer", err.Message())
+}
+
+func Test_analyzeReader_SourceReadFully_Returns_UnsupportedMedia(t *testing.T) {
+	_, err := analyzeReader(context.Background(), helperProber{mode: "truncated"}, strings.NewReader("media"), probeLimits{}, ProbeOptions{})
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, realdomain.ErrorCodeUnsupportedMedia, errorCode(err))
+}
+
+func Test_runProbe_MissingBinary_Returns_ProbeCrash(t *testing.T) {
+	_, err := runProbe(exec.Command("ffprobe-does-not-exist"))
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, realdomain.ErrorCodeProbeCrash, errorCode(err))
+}
+
+func Test_runProbe_WarningOnCleanExit_Returns_Output(t *testing.T) {
+	data, err := runProbe(helperCommand("warning"))
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "{}", data)
+}
+
+func Test_runProbe_Helper_Returns_Output(t *testing.T) {
+	data, err := runProbe(helperCommand("ok"))
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "{}", data)
+}
+
+type stubProber struct {
+	engine realdomain.ProbeEngine
+	output string
+}
+
+func (p stubProber) Engine() realdomain.ProbeEngine {
+	return p.engine
+}
+
+func (p stubProber) Probe(ctx context.Context, src io.Reader, opts ProbeOptions) (string, api_error.ApiErr) {
+	io.Copy(io.Discard, src)
+	return p.output, nil
+}
+
+func stubReader(ctx context.Context, srcUrl string) (*realdomain.SourceFile, api_error.ApiErr) {
+	return &realdomain.SourceFile{Reader: io.NopCloser(strings.NewReader("media")), Size: 5}, nil
+}
+
+func Test_probeJob_BothEngines_Stores_BothResults(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	probingService := NewFileService(mockFileRepo, jobFileService,
+		stubProber{engine: realdomain.EngineFfprobe, output: testResult},
+		stubProber{engine: realdomain.EngineMediaInfo, output: `{"media": {"track": [{"@type": "General"}]}}`})
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	newJob.Engines = realdomain.ProbeEngines{realdomain.EngineFfprobe, realdomain.EngineMediaInfo}
+	id := newJob.Id.String()
+	jobResp := newJob.ToDto()
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), "url1").DoAndReturn(stubReader).Times(2)
+	mockJobFileRepo.EXPECT().FindById(id).Return(newJob, nil).Times(2)
+	mockJobFileRepo.EXPECT().SetResult(id, testResult, gomock.Any()).Return(nil)
+	mockJobFileRepo.EXPECT().SetMediaInfoResult(id, gomock.Any()).Return(nil)
+
+	err := probingService.probeJob(context.Background(), &jobResp)
+
+	assert.Nil(t, err)
+}
+
+func Test_probeJob_EngineNotAvailable_Returns_InternalServerError(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Engines = realdomain.ProbeEngines{realdomain.EngineMediaInfo}
+	jobResp := newJob.ToDto()
+
+	err := fileService.(DefaultFileService).probeJob(context.Background(), &jobResp)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "probe engine mediainfo is not available", err.Message())
+	assert.EqualValues(t, http.StatusInternalServerError, err.StatusCode())
+}
+
+func Test_ffprobeArgs_NoProfile_Returns_DefaultArgs(t *testing.T) {
+	args := ffprobeArgs(nil, "-")
+
+	assert.EqualValues(t, []string{"-loglevel", "fatal", "-print_format", "json", "-show_format", "-show_streams", "-"}, args)
+}
+
+func Test_ffprobeArgs_Profile_Adds_Options(t *testing.T) {
+	args := ffprobeArgs([]string{"-show_chapters", "-probesize", "50M"}, "-")
+
+	assert.EqualValues(t, []string{"-loglevel", "fatal", "-print_format", "json", "-show_format", "-show_streams", "-show_chapters", "-probesize", "50M", "-"}, args)
+}
+
+func Test_ffprobeArgs_ShowEntries_Replaces_DefaultSections(t *testing.T) {
+	args := ffprobeArgs([]string{"-show_entries", "stream=codec_name"}, "-")
+
+	assert.EqualValues(t, []string{"-loglevel", "fatal", "-print_format", "json", "-show_entries", "stream=codec_name", "-"}, args)
+}
+
+func Test_FfprobeProber_UnknownProfile_Returns_InternalServerError(t *testing.T) {
+	prober := NewFfprobeProber(probePath, map[string][]string{"chapters": {"-show_chapters"}})
+
+	_, err := prober.Probe(context.Background(), strings.NewReader(""), ProbeOptions{Profile: "ts"})
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "probe profile ts is not configured", err.Message())
+	assert.EqualValues(t, realdomain.ErrorCodeInternal, errorCode(err))
+}
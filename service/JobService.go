@@ -1,11 +1,16 @@
 package service
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/johannes-kuhfuss/probesvc/config"
 	"github.com/johannes-kuhfuss/probesvc/domain"
 	"github.com/johannes-kuhfuss/probesvc/dto"
 	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/date"
 )
 
 //go:generate mockgen -destination=../mocks/service/mockJobService.go -package=service github.com/johannes-kuhfuss/probesvc/service JobService
@@ -14,9 +19,19 @@ type JobService interface {
 	GetJobById(string) (*dto.JobResponse, api_error.ApiErr)
 	CreateJob(dto.NewJobRequest) (*dto.JobResponse, api_error.ApiErr)
 	DeleteJobById(string) api_error.ApiErr
-	GetNextJob() (*dto.JobResponse, api_error.ApiErr)
+	ClaimNextJob(string) (*dto.JobResponse, api_error.ApiErr)
 	SetStatus(string, dto.JobStatusUpdateRequest) api_error.ApiErr
-	SetResult(string, string) api_error.ApiErr
+	SetResult(string, domain.ProbeEngine, string) api_error.ApiErr
+	GetResult(string, domain.ProbeEngine) (string, api_error.ApiErr)
+	SetDeepAnalysis(string, string) api_error.ApiErr
+	CancelJob(string, string) (*dto.JobResponse, api_error.ApiErr)
+	FailJob(string, domain.JobErrorCode, string) api_error.ApiErr
+	RetryDeadLetterJobs(string) (*[]dto.JobResponse, api_error.ApiErr)
+	PauseJob(string, string) (*dto.JobResponse, api_error.ApiErr)
+	ResumeJob(string, string) (*dto.JobResponse, api_error.ApiErr)
+	PauseProcessing() api_error.ApiErr
+	ResumeProcessing() api_error.ApiErr
+	IsProcessingPaused() (bool, api_error.ApiErr)
 }
 
 type DefaultJobService struct {
@@ -53,6 +68,22 @@ func (s DefaultJobService) CreateJob(jobreq dto.NewJobRequest) (*dto.JobResponse
 	if err != nil {
 		return nil, err
 	}
+	newJob.ScheduleAt(jobreq.StartAt)
+	newJob.Engines, err = domain.ParseProbeEngines(jobreq.Engines)
+	if err != nil {
+		return nil, err
+	}
+	if newJob.Engines.Contains(domain.EngineMediaInfo) && config.MediaInfoPath == "" {
+		return nil, api_error.NewBadRequestError("Probe engine mediainfo is not configured")
+	}
+	if _, ok := config.ProbeProfiles[jobreq.Profile]; !ok && jobreq.Profile != "" {
+		return nil, api_error.NewBadRequestError(fmt.Sprintf("Unknown probe profile %v", jobreq.Profile))
+	}
+	newJob.Profile = jobreq.Profile
+	newJob.Mode, err = domain.ParseJobMode(jobreq.Mode)
+	if err != nil {
+		return nil, err
+	}
 	err = s.repo.Save(*newJob)
 	if err != nil {
 		return nil, err
@@ -73,8 +104,8 @@ func (s DefaultJobService) DeleteJobById(id string) api_error.ApiErr {
 	return nil
 }
 
-func (s DefaultJobService) GetNextJob() (*dto.JobResponse, api_error.ApiErr) {
-	job, err := s.repo.GetNext()
+func (s DefaultJobService) ClaimNextJob(workerId string) (*dto.JobResponse, api_error.ApiErr) {
+	job, err := s.repo.ClaimNext(workerId)
 	if err != nil {
 		return nil, err
 	}
@@ -83,10 +114,18 @@ func (s DefaultJobService) GetNextJob() (*dto.JobResponse, api_error.ApiErr) {
 }
 
 func (s DefaultJobService) SetStatus(id string, newStatus dto.JobStatusUpdateRequest) api_error.ApiErr {
-	_, err := s.GetJobById(id)
+	job, err := s.repo.FindById(id)
 	if err != nil {
 		return api_error.NewNotFoundError(fmt.Sprintf("Job with id %v does not exist", id))
 	}
+	switch domain.JobStatus(strings.ToLower(newStatus.Status)) {
+	case domain.JobStatusFailed, domain.JobStatusTimedOut:
+		return s.reportFailure(id, newStatus)
+	case domain.JobStatusFinished:
+		if !job.HasResults() {
+			return api_error.NewProcessingConflictError(fmt.Sprintf("Job with id %v has no stored result, cannot finish it", id))
+		}
+	}
 	statusRequest, err := domain.ParseStatusRequest(newStatus)
 	if err != nil {
 		return err
@@ -98,14 +137,202 @@ func (s DefaultJobService) SetStatus(id string, newStatus dto.JobStatusUpdateReq
 	return nil
 }
 
-func (s DefaultJobService) SetResult(id string, data string) api_error.ApiErr {
-	_, err := s.GetJobById(id)
+// reportFailure records a failure reported through a status update like one
+// a local worker ran into, so it gets the same retry handling.
+func (s DefaultJobService) reportFailure(id string, newStatus dto.JobStatusUpdateRequest) api_error.ApiErr {
+	code, err := domain.ParseJobErrorCode(newStatus.ErrorCode)
+	if err != nil {
+		return err
+	}
+	switch {
+	case domain.JobStatus(strings.ToLower(newStatus.Status)) == domain.JobStatusTimedOut:
+		code = domain.ErrorCodeTimeout
+	case code == domain.ErrorCodeNone:
+		code = domain.ErrorCodeInternal
+	}
+	return s.FailJob(id, code, newStatus.ErrMsg)
+}
+
+func (s DefaultJobService) SetResult(id string, engine domain.ProbeEngine, data string) api_error.ApiErr {
+	job, err := s.repo.FindById(id)
+	if err != nil {
+		return api_error.NewNotFoundError(fmt.Sprintf("Job with id %v does not exist", id))
+	}
+	if job.Status != domain.JobStatusRunning {
+		return domain.NotRunningError(id)
+	}
+	switch engine {
+	case domain.EngineMediaInfo:
+		result, err := domain.ParseMediaInfoResult(data)
+		if err != nil {
+			return err
+		}
+		return s.repo.SetMediaInfoResult(id, *result)
+	default:
+		result, err := domain.ParseProbeResult(data)
+		if err != nil {
+			return err
+		}
+		return s.repo.SetResult(id, data, *result)
+	}
+}
+
+func (s DefaultJobService) SetDeepAnalysis(id string, data string) api_error.ApiErr {
+	job, err := s.repo.FindById(id)
 	if err != nil {
 		return api_error.NewNotFoundError(fmt.Sprintf("Job with id %v does not exist", id))
 	}
-	err = s.repo.SetResult(id, data)
+	if job.Status != domain.JobStatusRunning {
+		return domain.NotRunningError(id)
+	}
+	analysis, err := domain.ParseDeepAnalysis(data)
 	if err != nil {
 		return err
 	}
-	return nil
+	return s.repo.SetDeepAnalysis(id, *analysis)
+}
+
+// GetResult returns the output an engine produced for a job. For ffprobe
+// this is the unmodified output.
+func (s DefaultJobService) GetResult(id string, engine domain.ProbeEngine) (string, api_error.ApiErr) {
+	job, err := s.repo.FindById(id)
+	if err != nil {
+		return "", api_error.NewNotFoundError(fmt.Sprintf("Job with id %v does not exist", id))
+	}
+	noResult := api_error.NewNotFoundError(fmt.Sprintf("Job with id %v has no %v result yet", id, engine))
+	switch engine {
+	case domain.EngineMediaInfo:
+		if job.MediaInfo == nil {
+			return "", noResult
+		}
+		data, jsonErr := json.Marshal(job.MediaInfo)
+		if jsonErr != nil {
+			return "", api_error.NewInternalServerError("could not encode MediaInfo result", jsonErr)
+		}
+		return string(data), nil
+	default:
+		if job.TechInfo == "" {
+			return "", noResult
+		}
+		return job.TechInfo, nil
+	}
+}
+
+func (s DefaultJobService) CancelJob(id string, changedBy string) (*dto.JobResponse, api_error.ApiErr) {
+	job, err := s.repo.FindById(id)
+	if err != nil {
+		return nil, api_error.NewNotFoundError(fmt.Sprintf("Job with id %v does not exist", id))
+	}
+	if job.Status.IsFinal() {
+		return nil, api_error.NewProcessingConflictError(fmt.Sprintf("Job with id %v has already ended with status %v", id, job.Status))
+	}
+	statusRequest, err := domain.ParseStatusRequest(dto.JobStatusUpdateRequest{
+		Status:    string(domain.JobStatusCancelled),
+		ErrMsg:    "Job cancelled by user",
+		ChangedBy: changedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.SetStatus(id, *statusRequest)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetJobById(id)
+}
+
+func retryPolicy() domain.RetryPolicy {
+	return domain.RetryPolicy{
+		MaxAttempts: config.RetryMaxAttempts,
+		BaseDelay:   time.Second * time.Duration(config.RetryBaseDelay),
+		MaxDelay:    time.Second * time.Duration(config.RetryMaxDelay),
+	}
+}
+
+func (s DefaultJobService) FailJob(id string, code domain.JobErrorCode, errMsg string) api_error.ApiErr {
+	job, err := s.repo.FindById(id)
+	if err != nil {
+		return api_error.NewNotFoundError(fmt.Sprintf("Job with id %v does not exist", id))
+	}
+	oldStatus := job.Status
+	err = job.RecordFailure(code, errMsg, retryPolicy())
+	if err != nil {
+		return err
+	}
+	return s.repo.Update(*job, oldStatus)
+}
+
+func (s DefaultJobService) RetryDeadLetterJobs(changedBy string) (*[]dto.JobResponse, api_error.ApiErr) {
+	jobs, err := s.repo.FindAll(string(domain.JobStatusDeadLetter))
+	if err != nil {
+		return nil, err
+	}
+	response := make([]dto.JobResponse, 0)
+	for _, job := range *jobs {
+		err = job.ResetAttempts(changedBy)
+		if err != nil {
+			return nil, err
+		}
+		err = s.repo.Update(job, domain.JobStatusDeadLetter)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, job.ToDto())
+	}
+	return &response, nil
+}
+
+func (s DefaultJobService) changeStatus(id string, newStatus domain.JobStatus, changedBy string) (*dto.JobResponse, api_error.ApiErr) {
+	statusRequest, err := domain.ParseStatusRequest(dto.JobStatusUpdateRequest{
+		Status:    string(newStatus),
+		ChangedBy: changedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.SetStatus(id, *statusRequest)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetJobById(id)
+}
+
+func (s DefaultJobService) PauseJob(id string, changedBy string) (*dto.JobResponse, api_error.ApiErr) {
+	_, err := s.repo.FindById(id)
+	if err != nil {
+		return nil, api_error.NewNotFoundError(fmt.Sprintf("Job with id %v does not exist", id))
+	}
+	return s.changeStatus(id, domain.JobStatusPaused, changedBy)
+}
+
+// ResumeJob puts a paused job back in line. A job whose scheduled start
+// or retry time still lies ahead goes back to queued instead of created.
+func (s DefaultJobService) ResumeJob(id string, changedBy string) (*dto.JobResponse, api_error.ApiErr) {
+	job, err := s.repo.FindById(id)
+	if err != nil {
+		return nil, api_error.NewNotFoundError(fmt.Sprintf("Job with id %v does not exist", id))
+	}
+	if job.Status != domain.JobStatusPaused {
+		return nil, api_error.NewProcessingConflictError(fmt.Sprintf("Job with id %v is not paused", id))
+	}
+	newStatus := domain.JobStatusCreated
+	if job.NextAttemptAt.After(date.GetNowUtc()) {
+		newStatus = domain.JobStatusQueued
+	}
+	return s.changeStatus(id, newStatus, changedBy)
+}
+
+// PauseProcessing stops handing out jobs, both to local and to remote
+// workers, until ResumeProcessing is called. The flag is kept with the jobs,
+// so it applies to every instance sharing the job database.
+func (s DefaultJobService) PauseProcessing() api_error.ApiErr {
+	return s.repo.SetProcessingPaused(true)
+}
+
+func (s DefaultJobService) ResumeProcessing() api_error.ApiErr {
+	return s.repo.SetProcessingPaused(false)
+}
+
+func (s DefaultJobService) IsProcessingPaused() (bool, api_error.ApiErr) {
+	return s.repo.IsProcessingPaused()
 }
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/johannes-kuhfuss/probesvc/config"
 	realdomain "github.com/johannes-kuhfuss/probesvc/domain"
 	"github.com/johannes-kuhfuss/probesvc/dto"
 	"github.com/johannes-kuhfuss/probesvc/mocks/domain"
@@ -188,13 +190,13 @@ func Test_DeleteJobById_Returns_NoError(t *testing.T) {
 	assert.Nil(t, err)
 }
 
-func Test_GetNextJob_Returns_NotFoundError(t *testing.T) {
+func Test_ClaimNextJob_Returns_NotFoundError(t *testing.T) {
 	teardown := setupJob(t)
 	defer teardown()
 	apiError := api_error.NewNotFoundError("No next job found")
-	mockJobRepo.EXPECT().GetNext().Return(nil, apiError)
+	mockJobRepo.EXPECT().ClaimNext("worker-1").Return(nil, apiError)
 
-	job, err := jobService.GetNextJob()
+	job, err := jobService.ClaimNextJob("worker-1")
 
 	assert.Nil(t, job)
 	assert.NotNil(t, err)
@@ -202,13 +204,13 @@ func Test_GetNextJob_Returns_NotFoundError(t *testing.T) {
 	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
 }
 
-func Test_GetNextJob_Returns_NoError(t *testing.T) {
+func Test_ClaimNextJob_Returns_NoError(t *testing.T) {
 	teardown := setupJob(t)
 	defer teardown()
 	nextJob, _ := realdomain.NewJob("job 1", "url 1")
-	mockJobRepo.EXPECT().GetNext().Return(nextJob, nil)
+	mockJobRepo.EXPECT().ClaimNext("worker-1").Return(nextJob, nil)
 
-	job, err := jobService.GetNextJob()
+	job, err := jobService.ClaimNextJob("worker-1")
 
 	assert.NotNil(t, job)
 	assert.Nil(t, err)
@@ -252,15 +254,15 @@ func Test_SetStatus_WrongStatusValue_Returns_BadRequestError(t *testing.T) {
 	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
 }
 
-func Test_SetStatus_StatusFailed_Returns_Error(t *testing.T) {
+func Test_SetStatus_StatusFinished_Returns_Error(t *testing.T) {
 	teardown := setupJob(t)
 	defer teardown()
 	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.TechInfo = testResult
 	id := newJob.Id.String()
 	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
 	updReq := dto.JobStatusUpdateRequest{
-		Status: "failed",
-		ErrMsg: "failure_reason",
+		Status: "finished",
 	}
 	updReqParsed, _ := realdomain.ParseStatusRequest(updReq)
 	apiError := api_error.NewInternalServerError("something bad happened", nil)
@@ -273,24 +275,131 @@ func Test_SetStatus_StatusFailed_Returns_Error(t *testing.T) {
 	assert.EqualValues(t, http.StatusInternalServerError, err.StatusCode())
 }
 
-func Test_SetStatus_StatusFailed_Returns_NoError(t *testing.T) {
+func Test_SetStatus_FinishedWithoutResult_Returns_ConflictError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	id := newJob.Id.String()
+	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
+
+	err := jobService.SetStatus(id, dto.JobStatusUpdateRequest{Status: "finished"})
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, fmt.Sprintf("Job with id %v has no stored result, cannot finish it", id), err.Message())
+	assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+}
+
+func Test_SetStatus_FinishedDeepWithoutAnalysis_Returns_ConflictError(t *testing.T) {
 	teardown := setupJob(t)
 	defer teardown()
 	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	newJob.Mode = realdomain.JobModeDeep
+	newJob.TechInfo = testResult
 	id := newJob.Id.String()
 	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
+
+	err := jobService.SetStatus(id, dto.JobStatusUpdateRequest{Status: "finished"})
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+}
+
+func Test_SetStatus_StatusFailed_Records_Failure(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	newJob.Attempts = 1
+	id := newJob.Id.String()
+	var saved realdomain.Job
+	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil).Times(2)
+	mockJobRepo.EXPECT().Update(gomock.Any(), realdomain.JobStatusRunning).DoAndReturn(func(job realdomain.Job, oldStatus realdomain.JobStatus) api_error.ApiErr {
+		saved = job
+		return nil
+	})
+	updReq := dto.JobStatusUpdateRequest{
+		Status:    "failed",
+		ErrMsg:    "storage unreachable",
+		ErrorCode: "network",
+	}
+
+	err := jobService.SetStatus(id, updReq)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, realdomain.JobStatusCreated, saved.Status)
+	assert.EqualValues(t, realdomain.ErrorCodeNetwork, saved.ErrorCode)
+	assert.EqualValues(t, 1, len(saved.ErrorHistory))
+}
+
+func Test_SetStatus_StatusFailedWithoutCode_Records_InternalError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	id := newJob.Id.String()
+	var saved realdomain.Job
+	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil).Times(2)
+	mockJobRepo.EXPECT().Update(gomock.Any(), realdomain.JobStatusRunning).DoAndReturn(func(job realdomain.Job, oldStatus realdomain.JobStatus) api_error.ApiErr {
+		saved = job
+		return nil
+	})
 	updReq := dto.JobStatusUpdateRequest{
 		Status: "failed",
 		ErrMsg: "failure_reason",
 	}
-	updReqParsed, _ := realdomain.ParseStatusRequest(updReq)
-	mockJobRepo.EXPECT().SetStatus(id, *updReqParsed).Return(nil)
 
 	err := jobService.SetStatus(id, updReq)
 
 	assert.Nil(t, err)
+	assert.EqualValues(t, realdomain.JobStatusFailed, saved.Status)
+	assert.EqualValues(t, realdomain.ErrorCodeInternal, saved.ErrorCode)
+}
+
+func Test_SetStatus_StatusTimedOut_Records_Timeout(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	id := newJob.Id.String()
+	var saved realdomain.Job
+	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil).Times(2)
+	mockJobRepo.EXPECT().Update(gomock.Any(), realdomain.JobStatusRunning).DoAndReturn(func(job realdomain.Job, oldStatus realdomain.JobStatus) api_error.ApiErr {
+		saved = job
+		return nil
+	})
+	updReq := dto.JobStatusUpdateRequest{
+		Status: "timed_out",
+		ErrMsg: "took too long",
+	}
+
+	err := jobService.SetStatus(id, updReq)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, realdomain.JobStatusTimedOut, saved.Status)
+	assert.EqualValues(t, realdomain.ErrorCodeTimeout, saved.ErrorCode)
+}
+
+func Test_SetStatus_UnknownErrorCode_Returns_BadRequestError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	id := newJob.Id.String()
+	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
+	updReq := dto.JobStatusUpdateRequest{
+		Status:    "failed",
+		ErrorCode: "disk_full",
+	}
+
+	err := jobService.SetStatus(id, updReq)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
 }
 
+const testResult = `{"streams": [{"index": 0, "codec_type": "audio", "codec_name": "pcm_s16le"}], "format": {"format_name": "wav"}}`
+
 func Test_SetResult_NoJobWithId_Returns_NotFoundError(t *testing.T) {
 	teardown := setupJob(t)
 	defer teardown()
@@ -298,7 +407,7 @@ func Test_SetResult_NoJobWithId_Returns_NotFoundError(t *testing.T) {
 	apiError := api_error.NewNotFoundError(fmt.Sprintf("Job with id %v does not exist", id))
 	mockJobRepo.EXPECT().FindById(id).Return(nil, apiError)
 
-	err := jobService.SetResult(id, "new result data")
+	err := jobService.SetResult(id, realdomain.EngineFfprobe, testResult)
 
 	assert.NotNil(t, err)
 	assert.EqualValues(t, apiError.Message(), err.Message())
@@ -309,12 +418,13 @@ func Test_SetResult_Returns_Error(t *testing.T) {
 	teardown := setupJob(t)
 	defer teardown()
 	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
 	id := newJob.Id.String()
 	apiError := api_error.NewInternalServerError("something went wrong", nil)
 	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
-	mockJobRepo.EXPECT().SetResult(id, "new result data").Return(apiError)
+	mockJobRepo.EXPECT().SetResult(id, testResult, gomock.Any()).Return(apiError)
 
-	err := jobService.SetResult(id, "new result data")
+	err := jobService.SetResult(id, realdomain.EngineFfprobe, testResult)
 
 	assert.NotNil(t, err)
 	assert.EqualValues(t, apiError.Message(), err.Message())
@@ -322,14 +432,532 @@ func Test_SetResult_Returns_Error(t *testing.T) {
 }
 
 func Test_SetResult_Returns_NoError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	id := newJob.Id.String()
+	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
+	mockJobRepo.EXPECT().SetResult(id, testResult, gomock.Any()).Return(nil)
+
+	err := jobService.SetResult(id, realdomain.EngineFfprobe, testResult)
+
+	assert.Nil(t, err)
+}
+
+func Test_SetResult_NotRunning_Returns_ConflictError(t *testing.T) {
+	for _, status := range []realdomain.JobStatus{realdomain.JobStatusCreated, realdomain.JobStatusFinished, realdomain.JobStatusCancelled} {
+		t.Run(string(status), func(t *testing.T) {
+			teardown := setupJob(t)
+			defer teardown()
+			newJob, _ := realdomain.NewJob("job 1", "url1")
+			newJob.Status = status
+			id := newJob.Id.String()
+			mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
+
+			err := jobService.SetResult(id, realdomain.EngineFfprobe, testResult)
+
+			assert.NotNil(t, err)
+			assert.EqualValues(t, fmt.Sprintf("Job with id %v is not running, cannot set its result", id), err.Message())
+			assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+		})
+	}
+}
+
+func Test_SetResult_NotProbeOutput_Returns_ValidationError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	id := newJob.Id.String()
+	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
+
+	err := jobService.SetResult(id, realdomain.EngineFfprobe, `{"streams": "none"}`)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusUnprocessableEntity, err.StatusCode())
+}
+
+func Test_GetResult_NoResult_Returns_NotFoundError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	id := newJob.Id.String()
+	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
+
+	result, err := jobService.GetResult(id, realdomain.EngineFfprobe)
+
+	assert.Empty(t, result)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, fmt.Sprintf("Job with id %v has no ffprobe result yet", id), err.Message())
+	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+}
+
+func Test_GetResult_Returns_RawResult(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.TechInfo = testResult
+	id := newJob.Id.String()
+	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
+
+	result, err := jobService.GetResult(id, realdomain.EngineFfprobe)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, testResult, result)
+}
+
+func Test_CancelJob_NoJobWithId_Returns_NotFoundError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	id := ksuid.New().String()
+	mockJobRepo.EXPECT().FindById(id).Return(nil, api_error.NewNotFoundError("no jobs in joblist"))
+
+	job, err := jobService.CancelJob(id, "10.0.0.1")
+
+	assert.Nil(t, job)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, fmt.Sprintf("Job with id %v does not exist", id), err.Message())
+	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+}
+
+func Test_CancelJob_FinishedJob_Returns_ConflictError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusFinished
+	id := newJob.Id.String()
+	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
+
+	job, err := jobService.CancelJob(id, "10.0.0.1")
+
+	assert.Nil(t, job)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, fmt.Sprintf("Job with id %v has already ended with status finished", id), err.Message())
+	assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+}
+
+func Test_CancelJob_RunningJob_Returns_NoError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	id := newJob.Id.String()
+	cancelledJob := *newJob
+	cancelledJob.Status = realdomain.JobStatusCancelled
+	cancelled, _ := realdomain.ParseStatusRequest(dto.JobStatusUpdateRequest{Status: "cancelled", ErrMsg: "Job cancelled by user", ChangedBy: "10.0.0.1"})
+	gomock.InOrder(
+		mockJobRepo.EXPECT().FindById(id).Return(newJob, nil),
+		mockJobRepo.EXPECT().SetStatus(id, *cancelled).Return(nil),
+		mockJobRepo.EXPECT().FindById(id).Return(&cancelledJob, nil),
+	)
+
+	job, err := jobService.CancelJob(id, "10.0.0.1")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "cancelled", job.Status)
+}
+
+func Test_FailJob_NoJobWithId_Returns_NotFoundError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	id := ksuid.New().String()
+	mockJobRepo.EXPECT().FindById(id).Return(nil, api_error.NewNotFoundError("no jobs in joblist"))
+
+	err := jobService.FailJob(id, realdomain.ErrorCodeNetwork, "boom")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+}
+
+func Test_FailJob_AttemptsExhausted_DeadLettersJob(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	newJob.Attempts = config.RetryMaxAttempts
+	id := newJob.Id.String()
+	var saved realdomain.Job
+	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
+	mockJobRepo.EXPECT().Update(gomock.Any(), realdomain.JobStatusRunning).DoAndReturn(func(job realdomain.Job, oldStatus realdomain.JobStatus) api_error.ApiErr {
+		saved = job
+		return nil
+	})
+
+	err := jobService.FailJob(id, realdomain.ErrorCodeNetwork, "boom")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, realdomain.JobStatusDeadLetter, saved.Status)
+}
+
+func Test_FailJob_StatusChanged_Returns_ConflictError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	id := newJob.Id.String()
+	apiError := api_error.NewProcessingConflictError(fmt.Sprintf("Status of job %v was changed concurrently", id))
+	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
+	mockJobRepo.EXPECT().Update(gomock.Any(), realdomain.JobStatusRunning).Return(apiError)
+
+	err := jobService.FailJob(id, realdomain.ErrorCodeNetwork, "boom")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+}
+
+func Test_RetryDeadLetterJobs_NoJobs_Returns_NotFoundError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	apiError := api_error.NewNotFoundError("no jobs with status dead_letter in joblist")
+	mockJobRepo.EXPECT().FindAll("dead_letter").Return(nil, apiError)
+
+	jobs, err := jobService.RetryDeadLetterJobs("10.0.0.1")
+
+	assert.Nil(t, jobs)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+}
+
+func Test_RetryDeadLetterJobs_Requeues_Jobs(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	job1, _ := realdomain.NewJob("job 1", "url1")
+	job2, _ := realdomain.NewJob("job 2", "url2")
+	job1.Status, job1.Attempts = realdomain.JobStatusDeadLetter, 3
+	job2.Status, job2.Attempts = realdomain.JobStatusDeadLetter, 3
+	mockJobRepo.EXPECT().FindAll("dead_letter").Return(&[]realdomain.Job{*job1, *job2}, nil)
+	mockJobRepo.EXPECT().Update(gomock.Any(), realdomain.JobStatusDeadLetter).Return(nil).Times(2)
+
+	jobs, err := jobService.RetryDeadLetterJobs("10.0.0.1")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, len(*jobs))
+	assert.EqualValues(t, "created", (*jobs)[0].Status)
+	assert.EqualValues(t, 0, (*jobs)[0].Attempts)
+}
+
+func Test_RetryDeadLetterJobs_StatusChanged_Returns_ConflictError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	job1, _ := realdomain.NewJob("job 1", "url1")
+	job1.Status, job1.Attempts = realdomain.JobStatusDeadLetter, 3
+	apiError := api_error.NewProcessingConflictError(fmt.Sprintf("Status of job %v was changed concurrently", job1.Id))
+	mockJobRepo.EXPECT().FindAll("dead_letter").Return(&[]realdomain.Job{*job1}, nil)
+	mockJobRepo.EXPECT().Update(gomock.Any(), realdomain.JobStatusDeadLetter).Return(apiError)
+
+	jobs, err := jobService.RetryDeadLetterJobs("10.0.0.1")
+
+	assert.Nil(t, jobs)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+}
+
+func Test_CreateJob_FutureStart_Returns_QueuedJob(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	jobReq := dto.NewJobRequest{
+		Name:    "job 1",
+		SrcUrl:  "url 1",
+		StartAt: time.Now().Add(1 * time.Hour),
+	}
+	mockJobRepo.EXPECT().Save(gomock.Any()).Return(nil)
+
+	result, err := jobService.CreateJob(jobReq)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "queued", result.Status)
+	assert.True(t, result.NextAttemptAt.Equal(jobReq.StartAt))
+}
+
+func Test_ClaimNextJob_ProcessingPaused_Returns_UnavailableError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	apiError := api_error.NewError("processing is paused", http.StatusServiceUnavailable, nil)
+	mockJobRepo.EXPECT().ClaimNext("worker-1").Return(nil, apiError)
+
+	job, err := jobService.ClaimNextJob("worker-1")
+
+	assert.Nil(t, job)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "processing is paused", err.Message())
+	assert.EqualValues(t, http.StatusServiceUnavailable, err.StatusCode())
+}
+
+func Test_PauseProcessing_Stores_Flag(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	gomock.InOrder(
+		mockJobRepo.EXPECT().SetProcessingPaused(true).Return(nil),
+		mockJobRepo.EXPECT().IsProcessingPaused().Return(true, nil),
+	)
+
+	err := jobService.PauseProcessing()
+	paused, pausedErr := jobService.IsProcessingPaused()
+
+	assert.Nil(t, err)
+	assert.Nil(t, pausedErr)
+	assert.True(t, paused)
+}
+
+func Test_ResumeProcessing_Stores_Flag(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	apiError := api_error.NewInternalServerError("Database error while setting processing status", nil)
+	mockJobRepo.EXPECT().SetProcessingPaused(false).Return(apiError)
+
+	err := jobService.ResumeProcessing()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusInternalServerError, err.StatusCode())
+}
+
+func Test_PauseJob_NoJobWithId_Returns_NotFoundError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	id := ksuid.New().String()
+	mockJobRepo.EXPECT().FindById(id).Return(nil, api_error.NewNotFoundError("no jobs in joblist"))
+
+	job, err := jobService.PauseJob(id, "10.0.0.1")
+
+	assert.Nil(t, job)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, fmt.Sprintf("Job with id %v does not exist", id), err.Message())
+}
+
+func Test_PauseJob_Returns_NoError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	id := newJob.Id.String()
+	pausedJob := *newJob
+	pausedJob.Status = realdomain.JobStatusPaused
+	paused, _ := realdomain.ParseStatusRequest(dto.JobStatusUpdateRequest{Status: "paused", ChangedBy: "10.0.0.1"})
+	gomock.InOrder(
+		mockJobRepo.EXPECT().FindById(id).Return(newJob, nil),
+		mockJobRepo.EXPECT().SetStatus(id, *paused).Return(nil),
+		mockJobRepo.EXPECT().FindById(id).Return(&pausedJob, nil),
+	)
+
+	job, err := jobService.PauseJob(id, "10.0.0.1")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "paused", job.Status)
+}
+
+func Test_ResumeJob_NotPaused_Returns_ConflictError(t *testing.T) {
 	teardown := setupJob(t)
 	defer teardown()
 	newJob, _ := realdomain.NewJob("job 1", "url1")
 	id := newJob.Id.String()
 	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
-	mockJobRepo.EXPECT().SetResult(id, "new result data").Return(nil)
 
-	err := jobService.SetResult(id, "new result data")
+	job, err := jobService.ResumeJob(id, "10.0.0.1")
+
+	assert.Nil(t, job)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, fmt.Sprintf("Job with id %v is not paused", id), err.Message())
+	assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+}
+
+func Test_ResumeJob_Returns_CreatedJob(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusPaused
+	id := newJob.Id.String()
+	created, _ := realdomain.ParseStatusRequest(dto.JobStatusUpdateRequest{Status: "created", ChangedBy: "10.0.0.1"})
+	gomock.InOrder(
+		mockJobRepo.EXPECT().FindById(id).Return(newJob, nil),
+		mockJobRepo.EXPECT().SetStatus(id, *created).Return(nil),
+		mockJobRepo.EXPECT().FindById(id).Return(newJob, nil),
+	)
+
+	_, err := jobService.ResumeJob(id, "10.0.0.1")
 
 	assert.Nil(t, err)
 }
+
+func Test_ResumeJob_ScheduledJob_Returns_QueuedJob(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusPaused
+	newJob.NextAttemptAt = newJob.CreatedAt.Add(1 * time.Hour)
+	id := newJob.Id.String()
+	queued, _ := realdomain.ParseStatusRequest(dto.JobStatusUpdateRequest{Status: "queued", ChangedBy: "10.0.0.1"})
+	gomock.InOrder(
+		mockJobRepo.EXPECT().FindById(id).Return(newJob, nil),
+		mockJobRepo.EXPECT().SetStatus(id, *queued).Return(nil),
+		mockJobRepo.EXPECT().FindById(id).Return(newJob, nil),
+	)
+
+	_, err := jobService.ResumeJob(id, "10.0.0.1")
+
+	assert.Nil(t, err)
+}
+
+func Test_CreateJob_MediaInfoNotConfigured_Returns_BadRequestError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	jobReq := dto.NewJobRequest{
+		Name:    "job 1",
+		SrcUrl:  "url 1",
+		Engines: []string{"ffprobe", "mediainfo"},
+	}
+
+	result, err := jobService.CreateJob(jobReq)
+
+	assert.Nil(t, result)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "Probe engine mediainfo is not configured", err.Message())
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func Test_CreateJob_BothEngines_Returns_NoError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	config.MediaInfoPath = "mediainfo"
+	defer func() { config.MediaInfoPath = "" }()
+	jobReq := dto.NewJobRequest{
+		Name:    "job 1",
+		SrcUrl:  "url 1",
+		Engines: []string{"mediainfo", "ffprobe"},
+	}
+	mockJobRepo.EXPECT().Save(gomock.Any()).Return(nil)
+
+	result, err := jobService.CreateJob(jobReq)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"mediainfo", "ffprobe"}, result.Engines)
+}
+
+const testMediaInfoResult = `{"media": {"@ref": "", "track": [{"@type": "General", "Format": "MXF"}]}}`
+
+func Test_SetResult_MediaInfo_Returns_NoError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	id := newJob.Id.String()
+	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
+	mockJobRepo.EXPECT().SetMediaInfoResult(id, gomock.Any()).DoAndReturn(func(id string, result realdomain.MediaInfoResult) api_error.ApiErr {
+		assert.EqualValues(t, "MXF", result.Media.Tracks[0]["Format"])
+		return nil
+	})
+
+	err := jobService.SetResult(id, realdomain.EngineMediaInfo, testMediaInfoResult)
+
+	assert.Nil(t, err)
+}
+
+func Test_GetResult_MediaInfo_Returns_Result(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.TechInfo = testResult
+	newJob.MediaInfo, _ = realdomain.ParseMediaInfoResult(testMediaInfoResult)
+	id := newJob.Id.String()
+	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
+
+	result, err := jobService.GetResult(id, realdomain.EngineMediaInfo)
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, testMediaInfoResult, result)
+}
+
+func Test_CreateJob_UnknownProfile_Returns_BadRequestError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	jobReq := dto.NewJobRequest{
+		Name:    "job 1",
+		SrcUrl:  "url 1",
+		Profile: "chapters",
+	}
+
+	result, err := jobService.CreateJob(jobReq)
+
+	assert.Nil(t, result)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "Unknown probe profile chapters", err.Message())
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func Test_CreateJob_WithProfile_Returns_NoError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	config.ProbeProfiles = map[string][]string{"chapters": {"-show_chapters"}}
+	defer func() { config.ProbeProfiles = nil }()
+	jobReq := dto.NewJobRequest{
+		Name:    "job 1",
+		SrcUrl:  "url 1",
+		Profile: "chapters",
+	}
+	mockJobRepo.EXPECT().Save(gomock.Any()).Return(nil)
+
+	result, err := jobService.CreateJob(jobReq)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "chapters", result.Profile)
+}
+
+func Test_CreateJob_UnknownMode_Returns_BadRequestError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	jobReq := dto.NewJobRequest{
+		Name:   "job 1",
+		SrcUrl: "url 1",
+		Mode:   "full",
+	}
+
+	result, err := jobService.CreateJob(jobReq)
+
+	assert.Nil(t, result)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "Unknown job mode full", err.Message())
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func Test_CreateJob_DeepMode_Returns_NoError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	jobReq := dto.NewJobRequest{
+		Name:   "job 1",
+		SrcUrl: "url 1",
+		Mode:   "deep",
+	}
+	mockJobRepo.EXPECT().Save(gomock.Any()).Return(nil)
+
+	result, err := jobService.CreateJob(jobReq)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "deep", result.Mode)
+}
+
+func Test_SetDeepAnalysis_NotRunning_Returns_ConflictError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusFinished
+	id := newJob.Id.String()
+	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
+
+	err := jobService.SetDeepAnalysis(id, "{}")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+}
+
+func Test_SetDeepAnalysis_NotDeepAnalysis_Returns_ValidationError(t *testing.T) {
+	teardown := setupJob(t)
+	defer teardown()
+	newJob, _ := realdomain.NewJob("job 1", "url1")
+	newJob.Status = realdomain.JobStatusRunning
+	id := newJob.Id.String()
+	mockJobRepo.EXPECT().FindById(id).Return(newJob, nil)
+
+	err := jobService.SetDeepAnalysis(id, "packets")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusUnprocessableEntity, err.StatusCode())
+}
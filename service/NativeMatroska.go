@@ -0,0 +1,419 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/johannes-kuhfuss/probesvc/domain"
+)
+
+const (
+	ebmlIdHeader                 = 0x1a45dfa3
+	ebmlIdDocType                = 0x4282
+	mkvIdSegment                 = 0x18538067
+	mkvIdInfo                    = 0x1549a966
+	mkvIdTimestampScale          = 0x2ad7b1
+	mkvIdDuration                = 0x4489
+	mkvIdTracks                  = 0x1654ae6b
+	mkvIdCluster                 = 0x1f43b675
+	mkvIdTrackEntry              = 0xae
+	mkvIdTrackType               = 0x83
+	mkvIdCodecId                 = 0x86
+	mkvIdName                    = 0x536e
+	mkvIdLanguage                = 0x22b59c
+	mkvIdFlagDefault             = 0x88
+	mkvIdFlagForced              = 0x55aa
+	mkvIdDefaultDuration         = 0x23e383
+	mkvIdVideo                   = 0xe0
+	mkvIdPixelWidth              = 0xb0
+	mkvIdPixelHeight             = 0xba
+	mkvIdDisplayWidth            = 0x54b0
+	mkvIdDisplayHeight           = 0x54ba
+	mkvIdDisplayUnit             = 0x54b2
+	mkvIdFlagInterlaced          = 0x9a
+	mkvIdFieldOrder              = 0x9d
+	mkvIdColour                  = 0x55b0
+	mkvIdMatrixCoefficients      = 0x55b1
+	mkvIdBitsPerChannel          = 0x55b2
+	mkvIdTransferCharacteristics = 0x55ba
+	mkvIdPrimaries               = 0x55bb
+	mkvIdAudio                   = 0xe1
+	mkvIdSamplingFrequency       = 0xb5
+	mkvIdChannels                = 0x9f
+	mkvIdBitDepth                = 0x6264
+)
+
+var mkvCodecs = map[string]string{
+	"V_MPEG4/ISO/AVC":  "h264",
+	"V_MPEGH/ISO/HEVC": "hevc",
+	"V_AV1":            "av1",
+	"V_VP8":            "vp8",
+	"V_VP9":            "vp9",
+	"V_PRORES":         "prores",
+	"V_MPEG2":          "mpeg2video",
+	"V_MPEG4/ISO/ASP":  "mpeg4",
+	"V_MJPEG":          "mjpeg",
+	"V_FFV1":           "ffv1",
+	"A_AAC":            "aac",
+	"A_AC3":            "ac3",
+	"A_EAC3":           "eac3",
+	"A_DTS":            "dts",
+	"A_TRUEHD":         "truehd",
+	"A_OPUS":           "opus",
+	"A_VORBIS":         "vorbis",
+	"A_FLAC":           "flac",
+	"A_MPEG/L3":        "mp3",
+	"A_MPEG/L2":        "mp2",
+	"S_TEXT/UTF8":      "subrip",
+	"S_TEXT/ASS":       "ass",
+	"S_TEXT/SSA":       "ass",
+	"S_TEXT/WEBVTT":    "webvtt",
+	"S_HDMV/PGS":       "hdmv_pgs_subtitle",
+	"S_VOBSUB":         "dvd_subtitle",
+}
+
+// ebmlElement is one element of an EBML document. An element that runs past
+// the end of the data, or has an unknown size, is truncated to the data.
+type ebmlElement struct {
+	id        uint64
+	body      []byte
+	truncated bool
+}
+
+func ebmlElements(data []byte) []ebmlElement {
+	elements := make([]ebmlElement, 0)
+	for len(data) > 0 {
+		id, idLen, ok := ebmlVint(data)
+		if !ok || idLen > 4 {
+			break
+		}
+		size, sizeLen, ok := ebmlVint(data[idLen:])
+		if !ok {
+			break
+		}
+		marker := uint64(1) << (7 * uint(sizeLen))
+		size &^= marker
+		start := idLen + sizeLen
+		end, truncated := len(data), true
+		if size != marker-1 && size <= uint64(len(data)-start) {
+			end, truncated = start+int(size), false
+		}
+		elements = append(elements, ebmlElement{id, data[start:end], truncated})
+		data = data[end:]
+	}
+	return elements
+}
+
+// ebmlVint reads a variable length integer including its length marker.
+func ebmlVint(data []byte) (uint64, int, bool) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0, false
+	}
+	length := bits.LeadingZeros8(data[0]) + 1
+	if len(data) < length {
+		return 0, 0, false
+	}
+	value := uint64(data[0])
+	for _, b := range data[1:length] {
+		value = value<<8 | uint64(b)
+	}
+	return value, length, true
+}
+
+func ebmlUint(body []byte) uint64 {
+	var value uint64
+	for _, b := range body {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+func ebmlFloat(body []byte) float64 {
+	switch len(body) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(body)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(body))
+	default:
+		return 0
+	}
+}
+
+func ebmlString(body []byte) string {
+	if end := bytes.IndexByte(body, 0); end >= 0 {
+		body = body[:end]
+	}
+	return string(body)
+}
+
+func parseMatroska(head []byte) (*domain.ProbeResult, error) {
+	elements := ebmlElements(head)
+	if len(elements) < 2 || elements[0].id != ebmlIdHeader || elements[1].id != mkvIdSegment {
+		return nil, errNativeUnsupported
+	}
+	docType := "matroska"
+	for _, element := range ebmlElements(elements[0].body) {
+		if element.id == ebmlIdDocType {
+			docType = ebmlString(element.body)
+		}
+	}
+	if docType != "matroska" && docType != "webm" {
+		return nil, errNativeUnsupported
+	}
+	// Segment info and tracks precede the first cluster in files written by
+	// common muxers. Files that place them elsewhere need seeking.
+	var info, tracks *ebmlElement
+	for _, element := range ebmlElements(elements[1].body) {
+		if element.id == mkvIdCluster {
+			break
+		}
+		element := element
+		switch element.id {
+		case mkvIdInfo:
+			info = &element
+		case mkvIdTracks:
+			tracks = &element
+		}
+	}
+	if info == nil || tracks == nil || info.truncated || tracks.truncated {
+		return nil, errNativeUnsupported
+	}
+	timestampScale := uint64(1000000)
+	var duration float64
+	for _, element := range ebmlElements(info.body) {
+		switch element.id {
+		case mkvIdTimestampScale:
+			timestampScale = ebmlUint(element.body)
+		case mkvIdDuration:
+			duration = ebmlFloat(element.body)
+		}
+	}
+	streams := make([]domain.ProbeStream, 0)
+	for _, element := range ebmlElements(tracks.body) {
+		if element.id != mkvIdTrackEntry {
+			continue
+		}
+		stream, err := mkvStream(element.body, len(streams), timestampScale)
+		if err != nil {
+			return nil, err
+		}
+		streams = append(streams, stream)
+	}
+	seconds := duration * float64(timestampScale) / 1e9
+	result := domain.ProbeResult{
+		Streams: streams,
+		Format:  nativeFormat("matroska,webm", "Matroska / WebM", streams, seconds),
+	}
+	return &result, nil
+}
+
+func mkvStream(entry []byte, index int, timestampScale uint64) (domain.ProbeStream, error) {
+	stream := domain.ProbeStream{
+		Index:       index,
+		TimeBase:    nativeRational(int64(timestampScale), 1000000000),
+		StartPts:    0,
+		StartTime:   "0.000000",
+		Disposition: domain.ProbeDisposition{Default: 1},
+	}
+	var trackType uint64
+	var codecId, language, name string
+	var video, audio []byte
+	var defaultDuration uint64
+	language = "eng"
+	for _, element := range ebmlElements(entry) {
+		switch element.id {
+		case mkvIdTrackType:
+			trackType = ebmlUint(element.body)
+		case mkvIdCodecId:
+			codecId = ebmlString(element.body)
+		case mkvIdName:
+			name = ebmlString(element.body)
+		case mkvIdLanguage:
+			language = ebmlString(element.body)
+		case mkvIdFlagDefault:
+			stream.Disposition.Default = int(ebmlUint(element.body))
+		case mkvIdFlagForced:
+			stream.Disposition.Forced = int(ebmlUint(element.body))
+		case mkvIdDefaultDuration:
+			defaultDuration = ebmlUint(element.body)
+		case mkvIdVideo:
+			video = element.body
+		case mkvIdAudio:
+			audio = element.body
+		}
+	}
+	stream.Tags = nativeLanguage(language)
+	if name != "" {
+		if stream.Tags == nil {
+			stream.Tags = make(map[string]string)
+		}
+		stream.Tags["title"] = name
+	}
+	switch trackType {
+	case 1:
+		stream.CodecType = "video"
+		stream.CodecName = mkvCodecs[codecId]
+		if stream.CodecName == "" {
+			return stream, errNativeUnsupported
+		}
+		mkvVideoStream(&stream, video)
+		if defaultDuration > 0 {
+			stream.RFrameRate = mkvFrameRate(defaultDuration)
+			stream.AvgFrameRate = stream.RFrameRate
+		}
+	case 2:
+		stream.CodecType = "audio"
+		if err := mkvAudioStream(&stream, codecId, audio); err != nil {
+			return stream, err
+		}
+	case 17:
+		stream.CodecType = "subtitle"
+		stream.CodecName = mkvCodecs[codecId]
+	default:
+		stream.CodecType = "data"
+	}
+	return stream, nil
+}
+
+func mkvVideoStream(stream *domain.ProbeStream, video []byte) {
+	var displayWidth, displayHeight, displayUnit, interlaced uint64
+	fieldOrder := uint64(2)
+	for _, element := range ebmlElements(video) {
+		switch element.id {
+		case mkvIdPixelWidth:
+			stream.Width = int(ebmlUint(element.body))
+		case mkvIdPixelHeight:
+			stream.Height = int(ebmlUint(element.body))
+		case mkvIdDisplayWidth:
+			displayWidth = ebmlUint(element.body)
+		case mkvIdDisplayHeight:
+			displayHeight = ebmlUint(element.body)
+		case mkvIdDisplayUnit:
+			displayUnit = ebmlUint(element.body)
+		case mkvIdFlagInterlaced:
+			interlaced = ebmlUint(element.body)
+		case mkvIdFieldOrder:
+			fieldOrder = ebmlUint(element.body)
+		case mkvIdColour:
+			mkvColour(stream, element.body)
+		}
+	}
+	stream.CodedWidth = stream.Width
+	stream.CodedHeight = stream.Height
+	stream.FieldOrder = mkvFieldOrder(interlaced, fieldOrder)
+	width, height := int64(stream.Width), int64(stream.Height)
+	if displayUnit == 0 && displayWidth > 0 && displayHeight > 0 {
+		stream.SampleAspectRatio = nativeRatio(int64(displayWidth)*height, int64(displayHeight)*width)
+		stream.DisplayAspectRatio = nativeRatio(int64(displayWidth), int64(displayHeight))
+		return
+	}
+	stream.SampleAspectRatio = nativeRatio(1, 1)
+	stream.DisplayAspectRatio = nativeRatio(width, height)
+}
+
+// mkvFieldOrder maps the interlacing elements the way ffmpeg's Matroska
+// demuxer does.
+func mkvFieldOrder(interlaced uint64, fieldOrder uint64) string {
+	switch {
+	case interlaced == 2:
+		return "progressive"
+	case interlaced != 1:
+		return ""
+	}
+	switch fieldOrder {
+	case 0:
+		return "progressive"
+	case 1:
+		return "tt"
+	case 6:
+		return "bb"
+	case 9:
+		return "bt"
+	case 14:
+		return "tb"
+	default:
+		return ""
+	}
+}
+
+func mkvColour(stream *domain.ProbeStream, colour []byte) {
+	for _, element := range ebmlElements(colour) {
+		switch element.id {
+		case mkvIdBitsPerChannel:
+			if depth := ebmlUint(element.body); depth > 0 {
+				stream.BitsPerRawSample = strconv.FormatUint(depth, 10)
+			}
+		case mkvIdPrimaries:
+			stream.ColorPrimaries = nativeColourPrimaries[ebmlUint(element.body)]
+		case mkvIdTransferCharacteristics:
+			stream.ColorTransfer = nativeColourTransfer[ebmlUint(element.body)]
+		case mkvIdMatrixCoefficients:
+			stream.ColorSpace = nativeColourSpace[ebmlUint(element.body)]
+		}
+	}
+}
+
+// mkvFrameRate turns the default frame duration in nanoseconds into a frame
+// rate, snapping to the NTSC rates the nanosecond rounding obscures.
+func mkvFrameRate(defaultDuration uint64) string {
+	fps := 1e9 / float64(defaultDuration)
+	for _, den := range []int64{1, 1001} {
+		num := math.Round(fps * float64(den))
+		if math.Abs(num/float64(den)-fps) < fps*1e-5 {
+			return nativeRational(int64(num), den)
+		}
+	}
+	return nativeRational(1000000000, int64(defaultDuration))
+}
+
+func mkvAudioStream(stream *domain.ProbeStream, codecId string, audio []byte) error {
+	sampleRate := 8000.0
+	channels := 1
+	var depth int
+	for _, element := range ebmlElements(audio) {
+		switch element.id {
+		case mkvIdSamplingFrequency:
+			sampleRate = ebmlFloat(element.body)
+		case mkvIdChannels:
+			channels = int(ebmlUint(element.body))
+		case mkvIdBitDepth:
+			depth = int(ebmlUint(element.body))
+		}
+	}
+	codec := mkvAudioCodec(codecId, depth)
+	if codec == "" || channels == 0 || sampleRate <= 0 {
+		return errNativeUnsupported
+	}
+	stream.CodecName = codec
+	stream.SampleRate = strconv.Itoa(int(sampleRate))
+	stream.Channels = channels
+	stream.ChannelLayout = nativeChannelLayout(channels)
+	if strings.HasPrefix(codec, "pcm_") {
+		stream.BitsPerSample = depth
+	}
+	return nil
+}
+
+func mkvAudioCodec(codecId string, depth int) string {
+	switch {
+	case strings.HasPrefix(codecId, "A_AAC"):
+		return "aac"
+	case codecId == "A_PCM/INT/LIT" && depth == 8:
+		return "pcm_u8"
+	case codecId == "A_PCM/INT/LIT" && depth > 8:
+		return fmt.Sprintf("pcm_s%dle", depth)
+	case codecId == "A_PCM/INT/BIG" && depth > 8:
+		return fmt.Sprintf("pcm_s%dbe", depth)
+	case codecId == "A_PCM/FLOAT/IEEE" && depth > 0:
+		return fmt.Sprintf("pcm_f%dle", depth)
+	case strings.HasPrefix(codecId, "A_"):
+		return mkvCodecs[codecId]
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,508 @@
+package service
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/johannes-kuhfuss/probesvc/domain"
+)
+
+var mp4Codecs = map[string]string{
+	"avc1": "h264",
+	"avc3": "h264",
+	"hvc1": "hevc",
+	"hev1": "hevc",
+	"dvh1": "hevc",
+	"dvhe": "hevc",
+	"apco": "prores",
+	"apcs": "prores",
+	"apcn": "prores",
+	"apch": "prores",
+	"ap4h": "prores",
+	"ap4x": "prores",
+	"mp4v": "mpeg4",
+	"av01": "av1",
+	"vp09": "vp9",
+	"jpeg": "mjpeg",
+	"mp4a": "aac",
+	"ac-3": "ac3",
+	"ec-3": "eac3",
+	"Opus": "opus",
+	"fLaC": "flac",
+	"alac": "alac",
+	".mp3": "mp3",
+	"raw ": "pcm_u8",
+	"sowt": "pcm_s16le",
+	"twos": "pcm_s16be",
+	"in24": "pcm_s24be",
+	"in32": "pcm_s32be",
+	"fl32": "pcm_f32be",
+	"fl64": "pcm_f64be",
+}
+
+var mp4ProresProfiles = map[string]string{
+	"apco": "Proxy",
+	"apcs": "LT",
+	"apcn": "Standard",
+	"apch": "HQ",
+	"ap4h": "4444",
+	"ap4x": "4444XQ",
+}
+
+type mp4Box struct {
+	kind string
+	body []byte
+}
+
+func isMp4Box(kind string) bool {
+	switch kind {
+	case "ftyp", "moov", "mdat", "free", "skip", "wide", "pnot":
+		return true
+	default:
+		return false
+	}
+}
+
+// mp4Boxes splits data into boxes. The boxes read so far are returned along
+// with an error when the last box runs past the end of data.
+func mp4Boxes(data []byte) ([]mp4Box, error) {
+	boxes := make([]mp4Box, 0)
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data))
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return boxes, errNativeUnsupported
+			}
+			size = binary.BigEndian.Uint64(data[8:])
+			header = 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return boxes, errNativeUnsupported
+		}
+		boxes = append(boxes, mp4Box{string(data[4:8]), data[header:size]})
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+// mp4Find descends into the nested boxes named by path.
+func mp4Find(data []byte, path ...string) ([]byte, error) {
+	for _, kind := range path {
+		boxes, err := mp4Boxes(data)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, box := range boxes {
+			if box.kind == kind {
+				data, found = box.body, true
+				break
+			}
+		}
+		if !found {
+			return nil, errNativeUnsupported
+		}
+	}
+	return data, nil
+}
+
+func parseMp4(head []byte) (*domain.ProbeResult, error) {
+	boxes, _ := mp4Boxes(head)
+	var tags map[string]string
+	var moov []byte
+	for _, box := range boxes {
+		switch box.kind {
+		case "ftyp":
+			tags = mp4BrandTags(box.body)
+		case "moov":
+			moov = box.body
+		}
+	}
+	if moov == nil {
+		return nil, errNativeUnsupported
+	}
+	mvhd, err := mp4Find(moov, "mvhd")
+	if err != nil {
+		return nil, err
+	}
+	timescale, duration, _, err := mp4Times(mvhd)
+	if err != nil {
+		return nil, err
+	}
+	// Fragmented files leave the duration to the movie fragments that
+	// follow the movie box.
+	if timescale == 0 || duration == 0 {
+		return nil, errNativeUnsupported
+	}
+	children, err := mp4Boxes(moov)
+	if err != nil {
+		return nil, err
+	}
+	streams := make([]domain.ProbeStream, 0)
+	for _, box := range children {
+		if box.kind != "trak" {
+			continue
+		}
+		stream, err := mp4Stream(box.body, len(streams))
+		if err != nil {
+			return nil, err
+		}
+		streams = append(streams, stream)
+	}
+	seconds := float64(duration) / float64(timescale)
+	result := domain.ProbeResult{
+		Streams: streams,
+		Format:  nativeFormat("mov,mp4,m4a,3gp,3g2,mj2", "QuickTime / MOV", streams, seconds),
+	}
+	result.Format.Tags = tags
+	return &result, nil
+}
+
+func mp4BrandTags(ftyp []byte) map[string]string {
+	if len(ftyp) < 8 {
+		return nil
+	}
+	return map[string]string{
+		"major_brand":       string(ftyp[0:4]),
+		"minor_version":     strconv.FormatUint(uint64(binary.BigEndian.Uint32(ftyp[4:])), 10),
+		"compatible_brands": string(ftyp[8:]),
+	}
+}
+
+// mp4Times reads timescale and duration from a full box laid out like mvhd
+// and mdhd, and returns the offset of the fields that follow them.
+func mp4Times(body []byte) (uint32, uint64, int, error) {
+	if len(body) >= 32 && body[0] == 1 {
+		duration := binary.BigEndian.Uint64(body[24:])
+		if duration == ^uint64(0) {
+			duration = 0
+		}
+		return binary.BigEndian.Uint32(body[20:]), duration, 32, nil
+	}
+	if len(body) < 20 || body[0] != 0 {
+		return 0, 0, 0, errNativeUnsupported
+	}
+	duration := binary.BigEndian.Uint32(body[16:])
+	if duration == ^uint32(0) {
+		duration = 0
+	}
+	return binary.BigEndian.Uint32(body[12:]), uint64(duration), 20, nil
+}
+
+func mp4Stream(trak []byte, index int) (domain.ProbeStream, error) {
+	stream := domain.ProbeStream{Index: index}
+	mdia, err := mp4Find(trak, "mdia")
+	if err != nil {
+		return stream, err
+	}
+	mdhd, err := mp4Find(mdia, "mdhd")
+	if err != nil {
+		return stream, err
+	}
+	timescale, duration, next, err := mp4Times(mdhd)
+	if err != nil || timescale == 0 || len(mdhd) < next+2 {
+		return stream, errNativeUnsupported
+	}
+	hdlr, err := mp4Find(mdia, "hdlr")
+	if err != nil || len(hdlr) < 12 {
+		return stream, errNativeUnsupported
+	}
+	stbl, err := mp4Find(mdia, "minf", "stbl")
+	if err != nil {
+		return stream, err
+	}
+	entry, err := mp4SampleEntry(stbl)
+	if err != nil {
+		return stream, err
+	}
+	samples, sampleDelta := mp4SampleCount(stbl)
+
+	stream.CodecTagString = entry.kind
+	stream.CodecTag = nativeCodecTag(entry.kind)
+	stream.TimeBase = fmt.Sprintf("1/%d", timescale)
+	stream.StartPts = 0
+	stream.StartTime = "0.000000"
+	stream.DurationTs = int64(duration)
+	stream.Duration = nativeSeconds(float64(duration) / float64(timescale))
+	stream.Tags = nativeLanguage(mp4Language(binary.BigEndian.Uint16(mdhd[next:])))
+	if samples > 0 {
+		stream.NbFrames = strconv.FormatUint(samples, 10)
+	}
+	switch string(hdlr[8:12]) {
+	case "vide":
+		stream.CodecType = "video"
+		err = mp4VideoStream(&stream, entry)
+		stream.AvgFrameRate = nativeRational(int64(samples)*int64(timescale), int64(duration))
+		stream.RFrameRate = stream.AvgFrameRate
+		if sampleDelta > 0 {
+			stream.RFrameRate = nativeRational(int64(timescale), int64(sampleDelta))
+		}
+	case "soun":
+		stream.CodecType = "audio"
+		err = mp4AudioStream(&stream, entry, timescale)
+	case "subt", "text", "sbtl":
+		stream.CodecType = "subtitle"
+	default:
+		stream.CodecType = "data"
+	}
+	return stream, err
+}
+
+func mp4SampleEntry(stbl []byte) (mp4Box, error) {
+	stsd, err := mp4Find(stbl, "stsd")
+	if err != nil || len(stsd) < 8 {
+		return mp4Box{}, errNativeUnsupported
+	}
+	entries, err := mp4Boxes(stsd[8:])
+	if err != nil || len(entries) == 0 {
+		return mp4Box{}, errNativeUnsupported
+	}
+	return entries[0], nil
+}
+
+// mp4SampleCount sums the decoding time table. The sample delta is returned
+// only when all samples share it, as for constant frame rate video.
+func mp4SampleCount(stbl []byte) (uint64, uint64) {
+	stts, err := mp4Find(stbl, "stts")
+	if err != nil || len(stts) < 8 {
+		return 0, 0
+	}
+	count := int(binary.BigEndian.Uint32(stts[4:]))
+	var samples, delta uint64
+	for i := 0; i < count && len(stts) >= 16+i*8; i++ {
+		entry := stts[8+i*8:]
+		samples += uint64(binary.BigEndian.Uint32(entry))
+		if i == 0 {
+			delta = uint64(binary.BigEndian.Uint32(entry[4:]))
+		} else if uint64(binary.BigEndian.Uint32(entry[4:])) != delta {
+			delta = 0
+		}
+	}
+	return samples, delta
+}
+
+// mp4Language unpacks the ISO 639-2/T code mdhd stores as three five bit
+// letters. Values below 0x400 are QuickTime's Macintosh language codes,
+// where 0 is English.
+func mp4Language(packed uint16) string {
+	switch {
+	case packed == 0:
+		return "eng"
+	case packed < 0x400 || packed == 0x7fff:
+		return "und"
+	}
+	return string([]byte{
+		byte(packed>>10&0x1f) + 0x60,
+		byte(packed>>5&0x1f) + 0x60,
+		byte(packed&0x1f) + 0x60,
+	})
+}
+
+func mp4VideoStream(stream *domain.ProbeStream, entry mp4Box) error {
+	codec, ok := mp4Codecs[entry.kind]
+	if !ok || len(entry.body) < 78 {
+		return errNativeUnsupported
+	}
+	stream.CodecName = codec
+	stream.Width = int(binary.BigEndian.Uint16(entry.body[24:]))
+	stream.Height = int(binary.BigEndian.Uint16(entry.body[26:]))
+	stream.CodedWidth = stream.Width
+	stream.CodedHeight = stream.Height
+	hSpacing, vSpacing := int64(1), int64(1)
+	children, _ := mp4Boxes(entry.body[78:])
+	for _, child := range children {
+		switch child.kind {
+		case "pasp":
+			if len(child.body) >= 8 && binary.BigEndian.Uint32(child.body) > 0 && binary.BigEndian.Uint32(child.body[4:]) > 0 {
+				hSpacing = int64(binary.BigEndian.Uint32(child.body))
+				vSpacing = int64(binary.BigEndian.Uint32(child.body[4:]))
+			}
+		case "fiel":
+			stream.FieldOrder = mp4FieldOrder(child.body)
+		case "colr":
+			mp4Colour(stream, child.body)
+		case "avcC":
+			mp4AvcConfig(stream, child.body)
+		case "hvcC":
+			mp4HevcConfig(stream, child.body)
+		}
+	}
+	if profile, ok := mp4ProresProfiles[entry.kind]; ok {
+		stream.Profile = profile
+		stream.BitsPerRawSample = "10"
+		if entry.kind == "ap4h" || entry.kind == "ap4x" {
+			stream.BitsPerRawSample = "12"
+		}
+	}
+	stream.SampleAspectRatio = nativeRatio(hSpacing, vSpacing)
+	stream.DisplayAspectRatio = nativeRatio(int64(stream.Width)*hSpacing, int64(stream.Height)*vSpacing)
+	return nil
+}
+
+// mp4FieldOrder maps the fiel box the way ffmpeg's mov demuxer does.
+func mp4FieldOrder(fiel []byte) string {
+	if len(fiel) < 2 {
+		return ""
+	}
+	switch uint16(fiel[0])<<8 | uint16(fiel[1]) {
+	case 0x0100:
+		return "progressive"
+	case 0x0201:
+		return "tt"
+	case 0x0209:
+		return "tb"
+	case 0x020e:
+		return "bt"
+	case 0x0206:
+		return "bb"
+	default:
+		return ""
+	}
+}
+
+func mp4Colour(stream *domain.ProbeStream, colr []byte) {
+	if len(colr) < 10 || (string(colr[0:4]) != "nclx" && string(colr[0:4]) != "nclc") {
+		return
+	}
+	stream.ColorPrimaries = nativeColourPrimaries[uint64(binary.BigEndian.Uint16(colr[4:]))]
+	stream.ColorTransfer = nativeColourTransfer[uint64(binary.BigEndian.Uint16(colr[6:]))]
+	stream.ColorSpace = nativeColourSpace[uint64(binary.BigEndian.Uint16(colr[8:]))]
+}
+
+func mp4AvcConfig(stream *domain.ProbeStream, avcC []byte) {
+	if len(avcC) < 6 {
+		return
+	}
+	switch avcC[1] {
+	case 66:
+		stream.Profile = "Baseline"
+		if avcC[2]&0x40 != 0 {
+			stream.Profile = "Constrained Baseline"
+		}
+	case 77:
+		stream.Profile = "Main"
+	case 88:
+		stream.Profile = "Extended"
+	case 100:
+		stream.Profile = "High"
+	case 110:
+		stream.Profile = "High 10"
+	case 122:
+		stream.Profile = "High 4:2:2"
+	case 244:
+		stream.Profile = "High 4:4:4 Predictive"
+	}
+	stream.Level = int(avcC[3])
+	if avcC[1] <= 100 {
+		stream.BitsPerRawSample = "8"
+	}
+	// High profiles append chroma format and bit depth after the parameter
+	// sets.
+	pos := 6
+	for i := 0; i < int(avcC[5]&0x1f); i++ {
+		if len(avcC) < pos+2 {
+			return
+		}
+		pos += 2 + int(binary.BigEndian.Uint16(avcC[pos:]))
+	}
+	if len(avcC) < pos+1 {
+		return
+	}
+	count := int(avcC[pos])
+	pos++
+	for i := 0; i < count; i++ {
+		if len(avcC) < pos+2 {
+			return
+		}
+		pos += 2 + int(binary.BigEndian.Uint16(avcC[pos:]))
+	}
+	if avcC[1] > 100 && len(avcC) >= pos+2 {
+		stream.BitsPerRawSample = strconv.Itoa(int(avcC[pos+1]&0x07) + 8)
+	}
+}
+
+func mp4HevcConfig(stream *domain.ProbeStream, hvcC []byte) {
+	if len(hvcC) < 19 {
+		return
+	}
+	switch hvcC[1] & 0x1f {
+	case 1:
+		stream.Profile = "Main"
+	case 2:
+		stream.Profile = "Main 10"
+	case 3:
+		stream.Profile = "Main Still Picture"
+	case 4:
+		stream.Profile = "Rext"
+	}
+	stream.Level = int(hvcC[12])
+	stream.BitsPerRawSample = strconv.Itoa(int(hvcC[17]&0x07) + 8)
+}
+
+func mp4AudioStream(stream *domain.ProbeStream, entry mp4Box, timescale uint32) error {
+	if len(entry.body) < 28 {
+		return errNativeUnsupported
+	}
+	version := binary.BigEndian.Uint16(entry.body[8:])
+	channels := int(binary.BigEndian.Uint16(entry.body[16:]))
+	bits := int(binary.BigEndian.Uint16(entry.body[18:]))
+	sampleRate := int(binary.BigEndian.Uint32(entry.body[24:]) >> 16)
+	codec, ok := mp4Codecs[entry.kind]
+	if version == 2 {
+		// QuickTime sound description version 2 moves the rate and channel
+		// count into wider fields.
+		if len(entry.body) < 64 {
+			return errNativeUnsupported
+		}
+		sampleRate = int(math.Float64frombits(binary.BigEndian.Uint64(entry.body[32:])))
+		channels = int(binary.BigEndian.Uint32(entry.body[40:]))
+		bits = int(binary.BigEndian.Uint32(entry.body[48:]))
+		if entry.kind == "lpcm" {
+			codec, ok = mp4LpcmCodec(binary.BigEndian.Uint32(entry.body[52:]), bits)
+		}
+	}
+	if !ok || channels == 0 {
+		return errNativeUnsupported
+	}
+	if sampleRate == 0 {
+		sampleRate = int(timescale)
+	}
+	stream.CodecName = codec
+	stream.SampleRate = strconv.Itoa(sampleRate)
+	stream.Channels = channels
+	stream.ChannelLayout = nativeChannelLayout(channels)
+	if strings.HasPrefix(codec, "pcm_") {
+		stream.BitsPerSample = bits
+	}
+	return nil
+}
+
+// mp4LpcmCodec derives the PCM codec from the format flags of an lpcm sample
+// description.
+func mp4LpcmCodec(flags uint32, bits int) (string, bool) {
+	if bits == 0 {
+		return "", false
+	}
+	kind := "u"
+	switch {
+	case flags&0x1 != 0:
+		kind = "f"
+	case flags&0x4 != 0:
+		kind = "s"
+	}
+	endian := "le"
+	if flags&0x2 != 0 {
+		endian = "be"
+	}
+	if bits == 8 {
+		endian = ""
+	}
+	return fmt.Sprintf("pcm_%v%d%v", kind, bits, endian), true
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/johannes-kuhfuss/probesvc/domain"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+// nativeHeaderSize bounds how much of a source the native prober buffers
+// while looking for the container headers.
+const nativeHeaderSize = 4 * 1024 * 1024
+
+var errNativeUnsupported = errors.New("source is not supported by the native prober")
+
+// NativeProber reads the headers of MP4/MOV, WAV/BWF and Matroska sources
+// without starting a process and reports them in ffprobe's output format.
+// Sources it cannot handle are passed on to the fallback prober, including
+// the bytes it has already read.
+type NativeProber struct {
+	fallback Prober
+}
+
+func NewNativeProber(fallback Prober) NativeProber {
+	return NativeProber{fallback}
+}
+
+func (p NativeProber) Engine() domain.ProbeEngine {
+	return p.fallback.Engine()
+}
+
+func (p NativeProber) Probe(ctx context.Context, src io.Reader, opts ProbeOptions) (string, api_error.ApiErr) {
+	// Probe profiles ask for details only ffprobe itself reports.
+	if opts.Profile != "" {
+		return p.fallback.Probe(ctx, src, opts)
+	}
+	head := make([]byte, nativeHeaderSize)
+	n, readErr := io.ReadFull(src, head)
+	if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+		apiErr := api_error.NewInternalServerError(fmt.Sprintf("could not read source file: %v", readErr), readErr)
+		return "", newProbeError(domain.ErrorCodeNetwork, apiErr)
+	}
+	head = head[:n]
+
+	result, err := parseNativeHeader(head)
+	if err != nil {
+		logger.Debug(fmt.Sprintf("Native prober passes source on to %v: %v", p.fallback.Engine(), err))
+		return p.fallback.Probe(ctx, io.MultiReader(bytes.NewReader(head), src), opts)
+	}
+	data, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		return "", newProbeError(domain.ErrorCodeInternal, api_error.NewInternalServerError("could not encode probe result", marshalErr))
+	}
+	return string(data), nil
+}
+
+// ProbeSeekable leaves sources that need seeking to the fallback, as the
+// native prober only looks at the start of a source.
+func (p NativeProber) ProbeSeekable(ctx context.Context, input string, opts ProbeOptions) (string, api_error.ApiErr) {
+	seekable, ok := p.fallback.(SeekableProber)
+	if !ok {
+		msg := fmt.Sprintf("probe engine %v cannot seek in sources", p.fallback.Engine())
+		return "", newProbeError(domain.ErrorCodeInternal, api_error.NewInternalServerError(msg, nil))
+	}
+	return seekable.ProbeSeekable(ctx, input, opts)
+}
+
+func parseNativeHeader(head []byte) (*domain.ProbeResult, error) {
+	switch {
+	case len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		return parseWav(head)
+	case len(head) >= 4 && string(head[0:4]) == "\x1a\x45\xdf\xa3":
+		return parseMatroska(head)
+	case len(head) >= 8 && isMp4Box(string(head[4:8])):
+		return parseMp4(head)
+	default:
+		return nil, errNativeUnsupported
+	}
+}
+
+func nativeFormat(name string, longName string, streams []domain.ProbeStream, seconds float64) domain.ProbeFormat {
+	format := domain.ProbeFormat{
+		Filename:       "pipe:",
+		NbStreams:      len(streams),
+		FormatName:     name,
+		FormatLongName: longName,
+		ProbeScore:     100,
+	}
+	if seconds > 0 {
+		format.StartTime = "0.000000"
+		format.Duration = nativeSeconds(seconds)
+	}
+	return format
+}
+
+func nativeSeconds(seconds float64) string {
+	return fmt.Sprintf("%.6f", seconds)
+}
+
+func nativeRational(num int64, den int64) string {
+	if num <= 0 || den <= 0 {
+		return "0/0"
+	}
+	rat := big.NewRat(num, den)
+	return rat.Num().String() + "/" + rat.Denom().String()
+}
+
+func nativeRatio(num int64, den int64) string {
+	if num <= 0 || den <= 0 {
+		return ""
+	}
+	rat := big.NewRat(num, den)
+	return rat.Num().String() + ":" + rat.Denom().String()
+}
+
+// nativeCodecTag renders a four character code the way ffprobe prints
+// codec_tag, as the little endian value of its bytes.
+func nativeCodecTag(fourcc string) string {
+	if len(fourcc) != 4 {
+		return ""
+	}
+	return fmt.Sprintf("0x%02x%02x%02x%02x", fourcc[3], fourcc[2], fourcc[1], fourcc[0])
+}
+
+func nativeChannelLayout(channels int) string {
+	switch channels {
+	case 1:
+		return "mono"
+	case 2:
+		return "stereo"
+	default:
+		return ""
+	}
+}
+
+func nativeLanguage(language string) map[string]string {
+	if language == "" {
+		return nil
+	}
+	return map[string]string{"language": language}
+}
+
+// Colour code points as defined in ISO/IEC 23091-4, which both MP4 colr boxes
+// and Matroska colour elements use, with ffprobe's names.
+var (
+	nativeColourPrimaries = map[uint64]string{1: "bt709", 5: "bt470bg", 6: "smpte170m", 9: "bt2020"}
+	nativeColourTransfer  = map[uint64]string{1: "bt709", 6: "smpte170m", 14: "bt2020-10", 16: "smpte2084", 18: "arib-std-b67"}
+	nativeColourSpace     = map[uint64]string{1: "bt709", 5: "bt470bg", 6: "smpte170m", 9: "bt2020nc"}
+)
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	realdomain "github.com/johannes-kuhfuss/probesvc/domain"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingProber struct {
+	input *[]byte
+}
+
+func (p recordingProber) Engine() realdomain.ProbeEngine {
+	return realdomain.EngineFfprobe
+}
+
+func (p recordingProber) Probe(ctx context.Context, src io.Reader, opts ProbeOptions) (string, api_error.ApiErr) {
+	*p.input, _ = io.ReadAll(src)
+	return testResult, nil
+}
+
+func be16(value uint16) []byte {
+	data := make([]byte, 2)
+	binary.BigEndian.PutUint16(data, value)
+	return data
+}
+
+func be32(value uint32) []byte {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, value)
+	return data
+}
+
+func be64(value uint64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, value)
+	return data
+}
+
+func le16(value uint16) []byte {
+	data := make([]byte, 2)
+	binary.LittleEndian.PutUint16(data, value)
+	return data
+}
+
+func le32(value uint32) []byte {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, value)
+	return data
+}
+
+func mp4TestBox(kind string, parts ...[]byte) []byte {
+	body := bytes.Join(parts, nil)
+	return bytes.Join([][]byte{be32(uint32(len(body) + 8)), []byte(kind), body}, nil)
+}
+
+func mp4TestTrack(handler string, timescale uint32, duration uint32, language uint16, entry []byte, samples uint32, delta uint32) []byte {
+	return mp4TestBox("trak",
+		mp4TestBox("mdia",
+			mp4TestBox("mdhd", make([]byte, 12), be32(timescale), be32(duration), be16(language), be16(0)),
+			mp4TestBox("hdlr", make([]byte, 8), []byte(handler), make([]byte, 12)),
+			mp4TestBox("minf",
+				mp4TestBox("stbl",
+					mp4TestBox("stsd", be32(0), be32(1), entry),
+					mp4TestBox("stts", be32(0), be32(1), be32(samples), be32(delta))))))
+}
+
+func testMp4() []byte {
+	videoEntry := mp4TestBox("avc1", make([]byte, 24), be16(1920), be16(1080), make([]byte, 50),
+		mp4TestBox("avcC", []byte{1, 100, 0, 40, 0xff, 0xe0, 0}),
+		mp4TestBox("fiel", []byte{1, 0}),
+		mp4TestBox("colr", []byte("nclx"), be16(1), be16(1), be16(1), []byte{0}))
+	audioEntry := mp4TestBox("mp4a", make([]byte, 16), be16(2), be16(16), make([]byte, 4), be32(48000<<16))
+	return bytes.Join([][]byte{
+		mp4TestBox("ftyp", []byte("isom"), be32(512), []byte("isomiso2avc1mp41")),
+		mp4TestBox("moov",
+			mp4TestBox("mvhd", make([]byte, 12), be32(1000), be32(10000), make([]byte, 80)),
+			mp4TestTrack("vide", 12800, 128000, 0x55c4, videoEntry, 250, 512),
+			mp4TestTrack("soun", 48000, 480000, 0x10b5, audioEntry, 469, 1024)),
+		mp4TestBox("mdat", []byte("media")),
+	}, nil)
+}
+
+func testWav() []byte {
+	bext := make([]byte, 602)
+	copy(bext, "Interview")
+	copy(bext[256:], "Recorder")
+	copy(bext[320:], "2024-01-01")
+	copy(bext[330:], "10:00:00")
+	binary.LittleEndian.PutUint64(bext[338:], 172800000)
+	bext = append(bext, []byte("A=PCM,F=48000,W=16,M=stereo\r\n")...)
+	chunks := bytes.Join([][]byte{
+		[]byte("WAVE"),
+		[]byte("fmt "), le32(16), le16(1), le16(2), le32(48000), le32(192000), le16(4), le16(16),
+		[]byte("bext"), le32(uint32(len(bext))), bext, []byte{0},
+		[]byte("data"), le32(1920000), make([]byte, 64),
+	}, nil)
+	return bytes.Join([][]byte{[]byte("RIFF"), le32(uint32(len(chunks))), chunks}, nil)
+}
+
+func ebmlTestElement(id uint64, parts ...[]byte) []byte {
+	idBytes := be64(id)
+	for len(idBytes) > 1 && idBytes[0] == 0 {
+		idBytes = idBytes[1:]
+	}
+	body := bytes.Join(parts, nil)
+	size := be64(uint64(len(body)))
+	size[0] = 0x01
+	return bytes.Join([][]byte{idBytes, size, body}, nil)
+}
+
+func ebmlTestUint(id uint64, value uint64) []byte {
+	return ebmlTestElement(id, be64(value))
+}
+
+func ebmlTestFloat(id uint64, value float64) []byte {
+	return ebmlTestElement(id, be64(math.Float64bits(value)))
+}
+
+func testMatroska() []byte {
+	video := ebmlTestElement(mkvIdTrackEntry,
+		ebmlTestUint(mkvIdTrackType, 1),
+		ebmlTestElement(mkvIdCodecId, []byte("V_MPEGH/ISO/HEVC")),
+		ebmlTestUint(mkvIdDefaultDuration, 41708333),
+		ebmlTestElement(mkvIdVideo,
+			ebmlTestUint(mkvIdPixelWidth, 3840),
+			ebmlTestUint(mkvIdPixelHeight, 2160),
+			ebmlTestUint(mkvIdFlagInterlaced, 1),
+			ebmlTestUint(mkvIdFieldOrder, 1),
+			ebmlTestElement(mkvIdColour,
+				ebmlTestUint(mkvIdBitsPerChannel, 10),
+				ebmlTestUint(mkvIdTransferCharacteristics, 16),
+				ebmlTestUint(mkvIdPrimaries, 9))))
+	audio := ebmlTestElement(mkvIdTrackEntry,
+		ebmlTestUint(mkvIdTrackType, 2),
+		ebmlTestElement(mkvIdCodecId, []byte("A_OPUS")),
+		ebmlTestElement(mkvIdLanguage, []byte("ger")),
+		ebmlTestElement(mkvIdAudio,
+			ebmlTestFloat(mkvIdSamplingFrequency, 48000),
+			ebmlTestUint(mkvIdChannels, 6)))
+	segment := bytes.Join([][]byte{
+		ebmlTestElement(mkvIdInfo,
+			ebmlTestUint(mkvIdTimestampScale, 1000000),
+			ebmlTestFloat(mkvIdDuration, 10000)),
+		ebmlTestElement(mkvIdTracks, video, audio),
+		ebmlTestElement(mkvIdCluster, []byte("media")),
+	}, nil)
+	return bytes.Join([][]byte{
+		ebmlTestElement(ebmlIdHeader, ebmlTestElement(ebmlIdDocType, []byte("matroska"))),
+		{0x18, 0x53, 0x80, 0x67, 0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		segment,
+	}, nil)
+}
+
+func nativeSummary(t *testing.T, source []byte) (realdomain.ProbeResult, realdomain.MediaSummary) {
+	var input []byte
+	prober := NewNativeProber(recordingProber{&input})
+
+	data, err := prober.Probe(context.Background(), bytes.NewReader(source), ProbeOptions{})
+
+	assert.Nil(t, err)
+	assert.Nil(t, input)
+	var result realdomain.ProbeResult
+	assert.Nil(t, json.Unmarshal([]byte(data), &result))
+	return result, result.Summary()
+}
+
+func Test_NativeProber_Mp4_Returns_Summary(t *testing.T) {
+	result, summary := nativeSummary(t, testMp4())
+
+	assert.EqualValues(t, "isom", result.Format.Tags["major_brand"])
+	assert.EqualValues(t, "mp4", summary.Container)
+	assert.EqualValues(t, 10, summary.DurationSeconds)
+	assert.EqualValues(t, "h264", summary.Video.Codec)
+	assert.EqualValues(t, "High", summary.Video.Profile)
+	assert.EqualValues(t, 1920, summary.Video.Width)
+	assert.EqualValues(t, "16:9", summary.Video.DisplayAspectRatio)
+	assert.EqualValues(t, "25/1", summary.Video.FrameRate.Rational)
+	assert.EqualValues(t, "progressive", summary.Video.ScanType)
+	assert.EqualValues(t, 8, summary.Video.BitDepth)
+	assert.EqualValues(t, "bt709", summary.Video.ColourPrimaries)
+	assert.EqualValues(t, 1, len(summary.Audio))
+	assert.EqualValues(t, "aac", summary.Audio[0].Codec)
+	assert.EqualValues(t, "stereo", summary.Audio[0].ChannelLayout)
+	assert.EqualValues(t, 48000, summary.Audio[0].SampleRate)
+	assert.EqualValues(t, "deu", summary.Audio[0].Language)
+}
+
+func Test_NativeProber_Wav_Returns_Summary(t *testing.T) {
+	result, summary := nativeSummary(t, testWav())
+
+	assert.EqualValues(t, "Interview", result.Format.Tags["description"])
+	assert.EqualValues(t, "Recorder", result.Format.Tags["originator"])
+	assert.EqualValues(t, "2024-01-01", result.Format.Tags["origination_date"])
+	assert.EqualValues(t, "172800000", result.Format.Tags["time_reference"])
+	assert.EqualValues(t, "A=PCM,F=48000,W=16,M=stereo", result.Format.Tags["coding_history"])
+	assert.EqualValues(t, "wav", summary.Container)
+	assert.EqualValues(t, 10, summary.DurationSeconds)
+	assert.Nil(t, summary.Video)
+	assert.EqualValues(t, "pcm_s16le", summary.Audio[0].Codec)
+	assert.EqualValues(t, 2, summary.Audio[0].Channels)
+	assert.EqualValues(t, 48000, summary.Audio[0].SampleRate)
+}
+
+func Test_NativeProber_Matroska_Returns_Summary(t *testing.T) {
+	_, summary := nativeSummary(t, testMatroska())
+
+	assert.EqualValues(t, "matroska", summary.Container)
+	assert.EqualValues(t, 10, summary.DurationSeconds)
+	assert.EqualValues(t, "hevc", summary.Video.Codec)
+	assert.EqualValues(t, 3840, summary.Video.Width)
+	assert.EqualValues(t, "16:9", summary.Video.DisplayAspectRatio)
+	assert.EqualValues(t, "24000/1001", summary.Video.FrameRate.Rational)
+	assert.EqualValues(t, "interlaced", summary.Video.ScanType)
+	assert.EqualValues(t, "top_field_first", summary.Video.FieldOrder)
+	assert.EqualValues(t, 10, summary.Video.BitDepth)
+	assert.EqualValues(t, "HDR10", summary.Video.HdrType)
+	assert.EqualValues(t, "opus", summary.Audio[0].Codec)
+	assert.EqualValues(t, 6, summary.Audio[0].Channels)
+	assert.EqualValues(t, "ger", summary.Audio[0].Language)
+}
+
+func Test_NativeProber_UnknownContainer_Uses_Fallback(t *testing.T) {
+	var input []byte
+	prober := NewNativeProber(recordingProber{&input})
+	source := []byte("\x00\x00\x01\xba mpeg program stream")
+
+	data, err := prober.Probe(context.Background(), bytes.NewReader(source), ProbeOptions{})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, testResult, data)
+	assert.EqualValues(t, source, input)
+}
+
+func Test_NativeProber_MovieBoxAfterMediaData_Uses_Fallback(t *testing.T) {
+	var input []byte
+	prober := NewNativeProber(recordingProber{&input})
+	source := bytes.Join([][]byte{be32(nativeHeaderSize + 16), []byte("mdat"), make([]byte, nativeHeaderSize+8)}, nil)
+
+	_, err := prober.Probe(context.Background(), io.MultiReader(bytes.NewReader(source), strings.NewReader("moov")), ProbeOptions{})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, len(source)+4, len(input))
+}
+
+func Test_NativeProber_UnknownCodec_Uses_Fallback(t *testing.T) {
+	var input []byte
+	prober := NewNativeProber(recordingProber{&input})
+	source := bytes.Replace(testMatroska(), []byte("A_OPUS"), []byte("A_XYZW"), 1)
+
+	_, err := prober.Probe(context.Background(), bytes.NewReader(source), ProbeOptions{})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, source, input)
+}
+
+func Test_NativeProber_WithProfile_Uses_Fallback(t *testing.T) {
+	var input []byte
+	prober := NewNativeProber(recordingProber{&input})
+	source := testWav()
+
+	_, err := prober.Probe(context.Background(), bytes.NewReader(source), ProbeOptions{Profile: "chapters"})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, source, input)
+}
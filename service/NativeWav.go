@@ -0,0 +1,168 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/johannes-kuhfuss/probesvc/domain"
+)
+
+// parseWav reads the fmt and bext chunks of a RIFF WAVE file up to the data
+// chunk, whose size gives the duration.
+func parseWav(head []byte) (*domain.ProbeResult, error) {
+	var stream *domain.ProbeStream
+	var byteRate, blockAlign uint32
+	var tags map[string]string
+	var dataSize uint32
+	dataFound := false
+	chunks := head[12:]
+	for len(chunks) >= 8 {
+		id := string(chunks[0:4])
+		size := binary.LittleEndian.Uint32(chunks[4:])
+		if id == "data" {
+			dataSize, dataFound = size, true
+			break
+		}
+		if uint64(size) > uint64(len(chunks)-8) {
+			return nil, errNativeUnsupported
+		}
+		body := chunks[8 : 8+size]
+		switch id {
+		case "fmt ":
+			var err error
+			stream, byteRate, blockAlign, err = wavFormat(body)
+			if err != nil {
+				return nil, err
+			}
+		case "bext":
+			tags = wavBext(body)
+		}
+		next := 8 + uint64(size) + uint64(size&1)
+		if next > uint64(len(chunks)) {
+			break
+		}
+		chunks = chunks[next:]
+	}
+	// Without a data size, as for WAV streamed with a placeholder size,
+	// the duration can only be estimated from the file size.
+	if stream == nil || !dataFound || dataSize == 0xffffffff || byteRate == 0 || blockAlign == 0 {
+		return nil, errNativeUnsupported
+	}
+	seconds := float64(dataSize) / float64(byteRate)
+	stream.DurationTs = int64(dataSize / blockAlign)
+	stream.Duration = nativeSeconds(seconds)
+	streams := []domain.ProbeStream{*stream}
+	result := domain.ProbeResult{
+		Streams: streams,
+		Format:  nativeFormat("wav", "WAV / WAVE (Waveform Audio)", streams, seconds),
+	}
+	result.Format.BitRate = strconv.FormatUint(uint64(byteRate)*8, 10)
+	result.Format.Tags = tags
+	return &result, nil
+}
+
+func wavFormat(fmtChunk []byte) (*domain.ProbeStream, uint32, uint32, error) {
+	if len(fmtChunk) < 16 {
+		return nil, 0, 0, errNativeUnsupported
+	}
+	tag := binary.LittleEndian.Uint16(fmtChunk)
+	channels := int(binary.LittleEndian.Uint16(fmtChunk[2:]))
+	sampleRate := binary.LittleEndian.Uint32(fmtChunk[4:])
+	byteRate := binary.LittleEndian.Uint32(fmtChunk[8:])
+	blockAlign := uint32(binary.LittleEndian.Uint16(fmtChunk[12:]))
+	bits := int(binary.LittleEndian.Uint16(fmtChunk[14:]))
+	codecTag := tag
+	// WAVE_FORMAT_EXTENSIBLE carries the actual format in its sub format GUID.
+	if tag == 0xfffe {
+		if len(fmtChunk) < 26 {
+			return nil, 0, 0, errNativeUnsupported
+		}
+		tag = binary.LittleEndian.Uint16(fmtChunk[24:])
+	}
+	codec := wavCodec(tag, bits)
+	if codec == "" || channels == 0 || sampleRate == 0 {
+		return nil, 0, 0, errNativeUnsupported
+	}
+	stream := domain.ProbeStream{
+		CodecName:      codec,
+		CodecType:      "audio",
+		CodecTagString: fmt.Sprintf("[%d][%d][0][0]", codecTag&0xff, codecTag>>8),
+		CodecTag:       fmt.Sprintf("0x%04x", codecTag),
+		SampleRate:     strconv.FormatUint(uint64(sampleRate), 10),
+		Channels:       channels,
+		ChannelLayout:  nativeChannelLayout(channels),
+		TimeBase:       fmt.Sprintf("1/%d", sampleRate),
+		StartTime:      "0.000000",
+		BitRate:        strconv.FormatUint(uint64(byteRate)*8, 10),
+	}
+	if tag == 1 || tag == 3 {
+		stream.BitsPerSample = bits
+	}
+	return &stream, byteRate, blockAlign, nil
+}
+
+func wavCodec(tag uint16, bits int) string {
+	switch {
+	case tag == 1 && bits == 8:
+		return "pcm_u8"
+	case tag == 1 && (bits == 16 || bits == 24 || bits == 32):
+		return fmt.Sprintf("pcm_s%dle", bits)
+	case tag == 3 && (bits == 32 || bits == 64):
+		return fmt.Sprintf("pcm_f%dle", bits)
+	case tag == 6:
+		return "pcm_alaw"
+	case tag == 7:
+		return "pcm_mulaw"
+	case tag == 0x50:
+		return "mp2"
+	case tag == 0x55:
+		return "mp3"
+	default:
+		return ""
+	}
+}
+
+// wavBext reads the broadcast extension chunk of BWF files into the tags
+// ffprobe reports for it.
+func wavBext(bext []byte) map[string]string {
+	tags := make(map[string]string)
+	fields := []struct {
+		key        string
+		start, end int
+	}{
+		{"description", 0, 256},
+		{"originator", 256, 288},
+		{"originator_reference", 288, 320},
+		{"origination_date", 320, 330},
+		{"origination_time", 330, 338},
+	}
+	for _, field := range fields {
+		if len(bext) < field.end {
+			break
+		}
+		if value := wavText(bext[field.start:field.end]); value != "" {
+			tags[field.key] = value
+		}
+	}
+	if len(bext) >= 346 {
+		tags["time_reference"] = strconv.FormatUint(binary.LittleEndian.Uint64(bext[338:]), 10)
+	}
+	if len(bext) > 602 {
+		if history := wavText(bext[602:]); history != "" {
+			tags["coding_history"] = history
+		}
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
+
+func wavText(field []byte) string {
+	if end := bytes.IndexByte(field, 0); end >= 0 {
+		field = field[:end]
+	}
+	return string(bytes.TrimSpace(field))
+}
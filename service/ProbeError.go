@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/johannes-kuhfuss/probesvc/domain"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+// probeError carries the classification of a failed data extraction along
+// with the detailed error.
+type probeError struct {
+	api_error.ApiErr
+	code domain.JobErrorCode
+}
+
+func newProbeError(code domain.JobErrorCode, err api_error.ApiErr) probeError {
+	return probeError{err, code}
+}
+
+// MarshalJSON adds the error code to the usual api error fields, so clients
+// of the synchronous probe endpoint can tell failures apart.
+func (e probeError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Message    string              `json:"message"`
+		StatusCode int                 `json:"statuscode"`
+		Causes     []interface{}       `json:"causes"`
+		ErrorCode  domain.JobErrorCode `json:"error_code"`
+	}{e.Message(), e.StatusCode(), e.Causes(), e.code})
+}
+
+func errorCode(err api_error.ApiErr) domain.JobErrorCode {
+	if probeErr, ok := err.(probeError); ok {
+		return probeErr.code
+	}
+	return domain.ErrorCodeInternal
+}
+
+func isTimeout(ctx context.Context) bool {
+	return ctx.Err() == context.DeadlineExceeded
+}
+
+func timeoutError(seconds int) probeError {
+	msg := fmt.Sprintf("Data extraction did not finish within %v seconds", seconds)
+	return newProbeError(domain.ErrorCodeTimeout, api_error.NewError(msg, http.StatusGatewayTimeout, nil))
+}
+
+func tooLargeError(maxSize int64) probeError {
+	msg := fmt.Sprintf("source file is larger than the limit of %v bytes", maxSize)
+	return newProbeError(domain.ErrorCodeTooLarge, api_error.NewError(msg, http.StatusRequestEntityTooLarge, nil))
+}
+
+// readError is returned when reading the source failed while a prober was
+// working on it. The prober then only saw part of the file, so its own
+// verdict says nothing about the media.
+func readError(err error) probeError {
+	apiErr := api_error.NewInternalServerError(fmt.Sprintf("could not read source file: %v", err), err)
+	return newProbeError(domain.ErrorCodeNetwork, apiErr)
+}
+
+func storageError(err api_error.ApiErr) probeError {
+	msg := fmt.Sprintf("could not read source file: %v", err.Message())
+	var code domain.JobErrorCode
+	switch err.StatusCode() {
+	case http.StatusBadRequest:
+		code = domain.ErrorCodeInvalidSource
+	case http.StatusNotFound:
+		code = domain.ErrorCodeStorageNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		code = domain.ErrorCodeStorageAuth
+	default:
+		code = domain.ErrorCodeNetwork
+	}
+	return newProbeError(code, api_error.NewError(msg, err.StatusCode(), err.Causes()))
+}
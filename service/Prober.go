@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/johannes-kuhfuss/probesvc/config"
+	"github.com/johannes-kuhfuss/probesvc/domain"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+//go:generate mockgen -destination=../mocks/service/mockProber.go -package=service github.com/johannes-kuhfuss/probesvc/service Prober
+
+// Prober extracts technical metadata from a media stream with one engine and
+// returns the engine's JSON output.
+type Prober interface {
+	Engine() domain.ProbeEngine
+	Probe(context.Context, io.Reader, ProbeOptions) (string, api_error.ApiErr)
+}
+
+// ProbeOptions carries the per job settings for a probe. Engines ignore
+// settings that do not apply to them.
+type ProbeOptions struct {
+	Profile string
+}
+
+type FfprobeProber struct {
+	path     string
+	profiles map[string][]string
+}
+
+func NewFfprobeProber(path string, profiles map[string][]string) FfprobeProber {
+	return FfprobeProber{path, profiles}
+}
+
+func (p FfprobeProber) Engine() domain.ProbeEngine {
+	return domain.EngineFfprobe
+}
+
+func (p FfprobeProber) Probe(ctx context.Context, src io.Reader, opts ProbeOptions) (string, api_error.ApiErr) {
+	profile, err := p.profile(opts.Profile)
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.CommandContext(ctx, p.path, ffprobeArgs(profile, "-")...)
+	cmd.Stdin = src
+	return runProbe(cmd)
+}
+
+// ProbeSeekable lets ffprobe open input, a file path or URL, itself.
+func (p FfprobeProber) ProbeSeekable(ctx context.Context, input string, opts ProbeOptions) (string, api_error.ApiErr) {
+	profile, err := p.profile(opts.Profile)
+	if err != nil {
+		return "", err
+	}
+	return runProbe(exec.CommandContext(ctx, p.path, ffprobeArgs(profile, input)...))
+}
+
+func (p FfprobeProber) profile(name string) ([]string, api_error.ApiErr) {
+	profile, ok := p.profiles[name]
+	if !ok && name != "" {
+		msg := fmt.Sprintf("probe profile %v is not configured", name)
+		return nil, newProbeError(domain.ErrorCodeInternal, api_error.NewInternalServerError(msg, nil))
+	}
+	return profile, nil
+}
+
+// ffprobeArgs adds the options of a probe profile to the default arguments.
+// Profiles that pick their own entries replace the default sections.
+func ffprobeArgs(profile []string, input string) []string {
+	args := []string{"-loglevel", "fatal", "-print_format", "json"}
+	showEntries := false
+	for _, arg := range profile {
+		if arg == "-show_entries" {
+			showEntries = true
+		}
+	}
+	if !showEntries {
+		args = append(args, "-show_format", "-show_streams")
+	}
+	args = append(args, profile...)
+	return append(args, input)
+}
+
+// pathOnlyProber is implemented by probers that cannot read a source from
+// stdin and always have to be given a file path or URL.
+type pathOnlyProber interface {
+	SeekableProber
+	pathOnly()
+}
+
+type MediaInfoProber struct {
+	path string
+}
+
+func NewMediaInfoProber(path string) MediaInfoProber {
+	return MediaInfoProber{path}
+}
+
+func (p MediaInfoProber) Engine() domain.ProbeEngine {
+	return domain.EngineMediaInfo
+}
+
+// Probe spools src to a file, as MediaInfo does not read from stdin.
+func (p MediaInfoProber) Probe(ctx context.Context, src io.Reader, opts ProbeOptions) (string, api_error.ApiErr) {
+	spool, err := spoolSource(src, config.SpoolMaxSize)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(spool)
+	return p.ProbeSeekable(ctx, spool, opts)
+}
+
+func (p MediaInfoProber) pathOnly() {}
+
+func (p MediaInfoProber) ProbeSeekable(ctx context.Context, input string, opts ProbeOptions) (string, api_error.ApiErr) {
+	return runProbe(exec.CommandContext(ctx, p.path, "--Output=JSON", input))
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	realdomain "github.com/johannes-kuhfuss/probesvc/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// mediaInfoPath returns the MediaInfo binary for the tests that run it, and
+// skips them where it is not installed.
+func mediaInfoPath(t *testing.T) string {
+	path, err := exec.LookPath("mediainfo")
+	if err != nil {
+		t.Skip("mediainfo not installed")
+	}
+	return path
+}
+
+// pcmWav returns a complete WAV file with a second of silent 8 kHz mono audio.
+func pcmWav() []byte {
+	data := make([]byte, 16000)
+	chunks := bytes.Join([][]byte{
+		[]byte("WAVE"),
+		[]byte("fmt "), le32(16), le16(1), le16(1), le32(8000), le32(16000), le16(2), le16(16),
+		[]byte("data"), le32(uint32(len(data))), data,
+	}, nil)
+	return bytes.Join([][]byte{[]byte("RIFF"), le32(uint32(len(chunks))), chunks}, nil)
+}
+
+func Test_MediaInfoProber_ProbeSeekable_Returns_MediaInfoResult(t *testing.T) {
+	prober := NewMediaInfoProber(mediaInfoPath(t))
+	input := filepath.Join(t.TempDir(), "silence.wav")
+	os.WriteFile(input, pcmWav(), 0600)
+
+	output, err := prober.ProbeSeekable(context.Background(), input, ProbeOptions{})
+	result, parseErr := realdomain.ParseMediaInfoResult(output)
+
+	assert.Nil(t, err)
+	assert.Nil(t, parseErr)
+	assert.EqualValues(t, "Wave", result.Media.Tracks[0]["Format"])
+}
+
+func Test_MediaInfoProber_Probe_Spools_Source(t *testing.T) {
+	prober := NewMediaInfoProber(mediaInfoPath(t))
+
+	output, err := prober.Probe(context.Background(), bytes.NewReader(pcmWav()), ProbeOptions{})
+	result, parseErr := realdomain.ParseMediaInfoResult(output)
+
+	assert.Nil(t, err)
+	assert.Nil(t, parseErr)
+	assert.EqualValues(t, "Wave", result.Media.Tracks[0]["Format"])
+}
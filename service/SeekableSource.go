@@ -0,0 +1,291 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/johannes-kuhfuss/probesvc/config"
+	"github.com/johannes-kuhfuss/probesvc/domain"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+// SeekableProber is implemented by probers that can open the source
+// themselves, given a file path or URL, and so seek within it. That is needed
+// for sources whose index is at the end, such as MP4 with the moov box after
+// the media data, and helps with MXF that has the index in the footer
+// partition.
+type SeekableProber interface {
+	Prober
+	ProbeSeekable(context.Context, string, ProbeOptions) (string, api_error.ApiErr)
+}
+
+const (
+	seekModeAuto     = "auto"
+	seekModeStream   = "stream"
+	seekModeSeekable = "seekable"
+	// seekSniffSize is how much of a source is looked at to decide whether
+	// it has to be probed with seeking.
+	seekSniffSize = 64 * 1024
+)
+
+// mxfPartitionKey starts every MXF partition pack.
+var mxfPartitionKey = []byte{0x06, 0x0e, 0x2b, 0x34, 0x02, 0x05, 0x01, 0x01, 0x0d, 0x01, 0x02, 0x01, 0x01}
+
+const (
+	// mxfHeaderPartition is the partition kind byte of a header partition.
+	mxfHeaderPartition = 0x02
+	// mxfIndexByteCountOffset is where IndexByteCount sits in the value of a
+	// partition pack, after the version, KAG size, partition offsets and
+	// HeaderByteCount.
+	mxfIndexByteCountOffset = 40
+)
+
+// needsSeeking reports whether the start of a source shows a container that
+// cannot be probed properly from a stream.
+func needsSeeking(head []byte) bool {
+	if len(head) >= 8 && isMp4Box(string(head[4:8])) {
+		return mp4MoovAfterMdat(head)
+	}
+	return false
+}
+
+// prefersSeeking reports whether a source can be probed from a stream, but
+// gives better results with seeking. That is the case for MXF files without
+// an index in the header partition; the index then is further back, usually
+// in the footer partition.
+func prefersSeeking(head []byte) bool {
+	if len(head) < len(mxfPartitionKey) || string(head[:len(mxfPartitionKey)]) != string(mxfPartitionKey) {
+		return false
+	}
+	indexByteCount, ok := mxfHeaderIndexByteCount(head)
+	return !ok || indexByteCount == 0
+}
+
+// mxfHeaderIndexByteCount reads IndexByteCount from the header partition pack
+// at the start of head. It reports false if head does not hold a complete
+// header partition pack.
+func mxfHeaderIndexByteCount(head []byte) (uint64, bool) {
+	if len(head) < 17 || head[13] != mxfHeaderPartition {
+		return 0, false
+	}
+	valueStart := 17
+	if head[16] >= 0x80 {
+		valueStart += int(head[16] & 0x7f)
+	}
+	if valueStart+mxfIndexByteCountOffset+8 > len(head) {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(head[valueStart+mxfIndexByteCountOffset:]), true
+}
+
+// mp4MoovAfterMdat walks the top level box headers and reports whether the
+// media data comes before the movie box. Only the headers have to be within
+// head; the boxes themselves may extend past it.
+func mp4MoovAfterMdat(head []byte) bool {
+	var offset uint64
+	for offset+8 <= uint64(len(head)) {
+		size := uint64(binary.BigEndian.Uint32(head[offset:]))
+		boxType := string(head[offset+4 : offset+8])
+		switch boxType {
+		case "moov":
+			return false
+		case "mdat":
+			return true
+		}
+		switch size {
+		case 0:
+			return false
+		case 1:
+			if offset+16 > uint64(len(head)) {
+				return false
+			}
+			size = binary.BigEndian.Uint64(head[offset+8:])
+		}
+		if size < 8 {
+			return false
+		}
+		offset += size
+	}
+	return false
+}
+
+// canSeek reports whether a source can be handed to a prober for seeking,
+// either through the range proxy or by spooling it to a local file.
+func canSeek(src *domain.SourceFile, limits probeLimits) bool {
+	if _, ok := src.Reader.(io.ReadSeeker); ok && src.Size > 0 {
+		return true
+	}
+	maxSize := spoolLimit(limits)
+	return maxSize > 0 && src.Size <= maxSize
+}
+
+func spoolLimit(limits probeLimits) int64 {
+	if limits.maxSize > 0 && (config.SpoolMaxSize == 0 || limits.maxSize < config.SpoolMaxSize) {
+		return limits.maxSize
+	}
+	return config.SpoolMaxSize
+}
+
+// sourceOpener opens a fresh reader for a source.
+type sourceOpener func(context.Context) (*domain.SourceFile, api_error.ApiErr)
+
+// analyzeSeekable runs a prober on a source it can seek in. Seekable sources
+// are served to the prober through a local HTTP server that answers range
+// requests; anything else is spooled to a temporary file first. rest is
+// what is left to read of the source if part of it has been buffered.
+func analyzeSeekable(ctx context.Context, prober SeekableProber, src *domain.SourceFile, rest io.Reader, open sourceOpener, limits probeLimits, opts ProbeOptions) (string, api_error.ApiErr) {
+	var input string
+	var proxy *rangeProxy
+	if _, ok := src.Reader.(io.ReadSeeker); ok && src.Size > 0 {
+		var err api_error.ApiErr
+		proxy, err = newRangeProxy(open)
+		if err != nil {
+			return "", err
+		}
+		defer proxy.Close()
+		input = proxy.Url()
+	} else {
+		spool, err := spoolSource(rest, spoolLimit(limits))
+		if err != nil {
+			if isTimeout(ctx) {
+				return "", timeoutError(limits.timeout)
+			}
+			return "", err
+		}
+		defer os.Remove(spool)
+		input = spool
+	}
+	result, err := prober.ProbeSeekable(ctx, input, opts)
+	if err != nil && isTimeout(ctx) {
+		return "", timeoutError(limits.timeout)
+	}
+	if err != nil && proxy != nil {
+		if readErr := proxy.errs.first(); readErr != nil {
+			return "", readError(readErr)
+		}
+	}
+	return result, err
+}
+
+// spoolSource copies a source to a temporary file, giving up once it is
+// larger than maxSize.
+func spoolSource(src io.Reader, maxSize int64) (string, api_error.ApiErr) {
+	file, fileErr := os.CreateTemp(config.SpoolDir, "probesvc-spool-*")
+	if fileErr != nil {
+		apiErr := api_error.NewInternalServerError("could not create spool file", fileErr)
+		return "", newProbeError(domain.ErrorCodeInternal, apiErr)
+	}
+	limited := &sizeLimitReader{reader: src, remaining: maxSize}
+	_, copyErr := io.Copy(file, limited)
+	closeErr := file.Close()
+	switch {
+	case limited.exceeded:
+		os.Remove(file.Name())
+		return "", tooLargeError(maxSize)
+	case copyErr != nil:
+		os.Remove(file.Name())
+		return "", readError(copyErr)
+	case closeErr != nil:
+		os.Remove(file.Name())
+		apiErr := api_error.NewInternalServerError("could not write spool file", closeErr)
+		return "", newProbeError(domain.ErrorCodeInternal, apiErr)
+	}
+	return file.Name(), nil
+}
+
+// rangeProxy serves one seekable source on the loopback interface under a
+// random path, so only the prober it is handed to finds it. Every request
+// opens its own reader: ffmpeg starts the request for a new position before
+// it drops the old one, so requests must not wait for each other.
+type rangeProxy struct {
+	server   *http.Server
+	listener net.Listener
+	path     string
+	errs     readErrors
+}
+
+func newRangeProxy(open sourceOpener) (*rangeProxy, api_error.ApiErr) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		apiErr := api_error.NewInternalServerError("could not start range proxy", err)
+		return nil, newProbeError(domain.ErrorCodeInternal, apiErr)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		apiErr := api_error.NewInternalServerError("could not start range proxy", err)
+		return nil, newProbeError(domain.ErrorCodeInternal, apiErr)
+	}
+	proxy := rangeProxy{
+		listener: listener,
+		path:     "/" + hex.EncodeToString(token),
+	}
+	proxy.server = &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != proxy.path {
+				http.NotFound(w, r)
+				return
+			}
+			src, err := open(r.Context())
+			if err != nil {
+				logger.Error("Range proxy cannot open source", err)
+				http.Error(w, err.Message(), http.StatusBadGateway)
+				return
+			}
+			defer src.Reader.Close()
+			seeker, ok := src.Reader.(io.ReadSeeker)
+			if !ok {
+				http.Error(w, "source is not seekable", http.StatusBadGateway)
+				return
+			}
+			w.Header().Set("Content-Type", "application/octet-stream")
+			http.ServeContent(w, r, "", time.Time{}, recordingSeeker{seeker, &proxy.errs, r.Context()})
+		}),
+	}
+	go func() {
+		if err := proxy.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Error("Range proxy stopped", err)
+		}
+	}()
+	return &proxy, nil
+}
+
+// recordingSeeker passes read errors on to the proxy. Errors after the
+// prober dropped the request are its own doing and are not recorded.
+type recordingSeeker struct {
+	io.ReadSeeker
+	errs *readErrors
+	ctx  context.Context
+}
+
+func (s recordingSeeker) Read(p []byte) (int, error) {
+	n, err := s.ReadSeeker.Read(p)
+	if s.ctx.Err() == nil {
+		s.errs.record(err)
+	}
+	return n, err
+}
+
+func (p *rangeProxy) Url() string {
+	return "http://" + p.listener.Addr().String() + p.path
+}
+
+func (p *rangeProxy) Close() error {
+	return p.server.Close()
+}
+
+// sniffSource buffers the start of a source without consuming it for the
+// prober.
+func sniffSource(src io.Reader) ([]byte, io.Reader) {
+	buffered := bufio.NewReaderSize(src, seekSniffSize)
+	head, _ := buffered.Peek(seekSniffSize)
+	return head, buffered
+}
@@ -0,0 +1,404 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/johannes-kuhfuss/probesvc/config"
+	realdomain "github.com/johannes-kuhfuss/probesvc/domain"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/stretchr/testify/assert"
+)
+
+// seekingProber reads what it is given, from stdin or from the file or URL it
+// is pointed to, and fails on stdin if failStream is set.
+type seekingProber struct {
+	failStream bool
+	inputs     *[]string
+	content    *[]byte
+}
+
+func (p seekingProber) Engine() realdomain.ProbeEngine {
+	return realdomain.EngineFfprobe
+}
+
+func (p seekingProber) Probe(ctx context.Context, src io.Reader, opts ProbeOptions) (string, api_error.ApiErr) {
+	*p.inputs = append(*p.inputs, "-")
+	*p.content, _ = io.ReadAll(src)
+	if p.failStream {
+		apiErr := api_error.NewInternalServerError("error running ffprobe [moov atom not found]", nil)
+		return "", newProbeError(realdomain.ErrorCodeUnsupportedMedia, apiErr)
+	}
+	return testResult, nil
+}
+
+func (p seekingProber) ProbeSeekable(ctx context.Context, input string, opts ProbeOptions) (string, api_error.ApiErr) {
+	*p.inputs = append(*p.inputs, input)
+	if strings.HasPrefix(input, "http://") {
+		resp, err := http.Get(input)
+		if err != nil {
+			return "", api_error.NewInternalServerError("could not fetch input", err)
+		}
+		defer resp.Body.Close()
+		*p.content, _ = io.ReadAll(resp.Body)
+	} else {
+		*p.content, _ = os.ReadFile(input)
+	}
+	return testResult, nil
+}
+
+// pathOnlySeekingProber cannot read from a stream, like MediaInfo.
+type pathOnlySeekingProber struct {
+	seekingProber
+}
+
+func (p pathOnlySeekingProber) pathOnly() {}
+
+type readSeekNopCloser struct {
+	*bytes.Reader
+}
+
+func (readSeekNopCloser) Close() error {
+	return nil
+}
+
+func bytesOpener(content []byte) sourceOpener {
+	return func(ctx context.Context) (*realdomain.SourceFile, api_error.ApiErr) {
+		return &realdomain.SourceFile{Reader: readSeekNopCloser{bytes.NewReader(content)}, Size: int64(len(content))}, nil
+	}
+}
+
+func mp4TestSource(boxTypes ...string) []byte {
+	var source []byte
+	for _, boxType := range boxTypes {
+		source = append(source, mp4TestBox(boxType, make([]byte, 24))...)
+	}
+	return source
+}
+
+// mxfTestSource returns a header partition pack with the given
+// IndexByteCount, with its length in long BER form as muxers write it.
+func mxfTestSource(indexByteCount uint64) []byte {
+	pack := append(append([]byte{}, mxfPartitionKey...), mxfHeaderPartition, 0x04, 0x00)
+	pack = append(pack, 0x83, 0x00, 0x00, 0x58)
+	value := make([]byte, 0x58)
+	binary.BigEndian.PutUint16(value[0:], 1)
+	binary.BigEndian.PutUint16(value[2:], 3)
+	binary.BigEndian.PutUint32(value[4:], 512)
+	binary.BigEndian.PutUint64(value[32:], 16384)
+	binary.BigEndian.PutUint64(value[mxfIndexByteCountOffset:], indexByteCount)
+	return append(pack, value...)
+}
+
+func Test_needsSeeking_Mxf_Returns_False(t *testing.T) {
+	assert.False(t, needsSeeking(mxfTestSource(0)))
+}
+
+func Test_prefersSeeking_MxfWithoutHeaderIndex_Returns_True(t *testing.T) {
+	assert.True(t, prefersSeeking(mxfTestSource(0)))
+}
+
+func Test_prefersSeeking_MxfWithHeaderIndex_Returns_False(t *testing.T) {
+	assert.False(t, prefersSeeking(mxfTestSource(1024)))
+}
+
+func Test_prefersSeeking_MxfShortBerLength_Reads_IndexByteCount(t *testing.T) {
+	source := mxfTestSource(1024)
+	head := append(append([]byte{}, source[:16]...), 0x58)
+	head = append(head, source[20:]...)
+
+	assert.False(t, prefersSeeking(head))
+}
+
+func Test_prefersSeeking_TruncatedMxf_Returns_True(t *testing.T) {
+	assert.True(t, prefersSeeking(mxfTestSource(1024)[:40]))
+}
+
+func Test_prefersSeeking_OtherContainer_Returns_False(t *testing.T) {
+	assert.False(t, prefersSeeking(mp4TestSource("ftyp", "mdat", "moov")))
+}
+
+func Test_needsSeeking_Mp4MoovAtEnd_Returns_True(t *testing.T) {
+	assert.True(t, needsSeeking(mp4TestSource("ftyp", "free", "mdat", "moov")))
+}
+
+func Test_needsSeeking_Mp4MoovAtStart_Returns_False(t *testing.T) {
+	assert.False(t, needsSeeking(mp4TestSource("ftyp", "moov", "mdat")))
+}
+
+func Test_needsSeeking_Mp4LargeMdat_Returns_True(t *testing.T) {
+	mdat := make([]byte, 16)
+	binary.BigEndian.PutUint32(mdat, 1)
+	copy(mdat[4:], "mdat")
+	binary.BigEndian.PutUint64(mdat[8:], 1<<33)
+	head := append(mp4TestSource("ftyp"), mdat...)
+
+	assert.True(t, needsSeeking(head))
+}
+
+func Test_needsSeeking_OtherContainer_Returns_False(t *testing.T) {
+	assert.False(t, needsSeeking([]byte("RIFF\x00\x00\x00\x00WAVEfmt ")))
+}
+
+func Test_rangeProxy_Serves_Ranges(t *testing.T) {
+	proxy, err := newRangeProxy(bytesOpener([]byte("0123456789")))
+	assert.Nil(t, err)
+	defer proxy.Close()
+	req, _ := http.NewRequest(http.MethodGet, proxy.Url(), nil)
+	req.Header.Set("Range", "bytes=6-")
+
+	resp, getErr := http.DefaultClient.Do(req)
+	assert.Nil(t, getErr)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	assert.EqualValues(t, http.StatusPartialContent, resp.StatusCode)
+	assert.EqualValues(t, "6789", string(body))
+}
+
+// failingSeeker stops with an error after the first bytes of a source.
+type failingSeeker struct {
+	*bytes.Reader
+}
+
+func (f failingSeeker) Read(p []byte) (int, error) {
+	if f.Reader.Len() < 6 {
+		return 0, errors.New("connection reset by peer")
+	}
+	return f.Reader.Read(p[:1])
+}
+
+func (f failingSeeker) Close() error {
+	return nil
+}
+
+func Test_rangeProxy_SourceFailsPartway_Records_ReadError(t *testing.T) {
+	proxy, err := newRangeProxy(func(ctx context.Context) (*realdomain.SourceFile, api_error.ApiErr) {
+		return &realdomain.SourceFile{Reader: failingSeeker{bytes.NewReader([]byte("0123456789"))}, Size: 10}, nil
+	})
+	assert.Nil(t, err)
+	defer proxy.Close()
+
+	resp, getErr := http.Get(proxy.Url())
+	if getErr == nil {
+		io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	assert.NotNil(t, proxy.errs.first())
+	assert.EqualValues(t, "connection reset by peer", proxy.errs.first().Error())
+}
+
+func Test_rangeProxy_UnknownPath_Returns_NotFound(t *testing.T) {
+	proxy, err := newRangeProxy(bytesOpener([]byte("0123456789")))
+	assert.Nil(t, err)
+	defer proxy.Close()
+
+	resp, getErr := http.Get(strings.TrimSuffix(proxy.Url(), proxy.path) + "/other")
+	assert.Nil(t, getErr)
+	resp.Body.Close()
+
+	assert.EqualValues(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func Test_rangeProxy_OverlappingRequests_DoNot_Block(t *testing.T) {
+	file, _ := os.CreateTemp("", "probesvc-proxy-test-*")
+	defer os.Remove(file.Name())
+	content := make([]byte, 16*1024*1024)
+	for i := range content {
+		content[i] = byte(i)
+	}
+	file.Write(content)
+	file.Close()
+	proxy, err := newRangeProxy(func(ctx context.Context) (*realdomain.SourceFile, api_error.ApiErr) {
+		f, _ := os.Open(file.Name())
+		return &realdomain.SourceFile{Reader: f, Size: int64(len(content))}, nil
+	})
+	assert.Nil(t, err)
+	defer proxy.Close()
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	// The first response is left unread, as ffmpeg does when it seeks.
+	first, firstErr := client.Get(proxy.Url())
+	assert.Nil(t, firstErr)
+	defer first.Body.Close()
+	req, _ := http.NewRequest(http.MethodGet, proxy.Url(), nil)
+	req.Header.Set("Range", "bytes=15000000-15000009")
+	second, secondErr := client.Do(req)
+	assert.Nil(t, secondErr)
+	defer second.Body.Close()
+	body, _ := io.ReadAll(second.Body)
+
+	assert.EqualValues(t, http.StatusPartialContent, second.StatusCode)
+	assert.EqualValues(t, content[15000000:15000010], body)
+}
+
+func Test_spoolSource_TooLarge_Returns_TooLargeError(t *testing.T) {
+	spool, err := spoolSource(strings.NewReader("0123456789"), 5)
+
+	assert.EqualValues(t, "", spool)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, realdomain.ErrorCodeTooLarge, errorCode(err))
+}
+
+func Test_spoolSource_Writes_File(t *testing.T) {
+	spool, err := spoolSource(strings.NewReader("0123456789"), 10)
+	defer os.Remove(spool)
+	content, _ := os.ReadFile(spool)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "0123456789", string(content))
+}
+
+func Test_analyzeFile_MoovAtEnd_Spools_Source(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	source := mp4TestSource("ftyp", "mdat", "moov")
+	var inputs []string
+	var content []byte
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), "url1").Return(&realdomain.SourceFile{Reader: io.NopCloser(bytes.NewReader(source)), Size: -1}, nil)
+
+	result, err := fileService.(DefaultFileService).analyzeFile(context.Background(), seekingProber{inputs: &inputs, content: &content}, "url1", probeLimits{}, ProbeOptions{})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, testResult, result)
+	assert.EqualValues(t, 1, len(inputs))
+	assert.NotEqual(t, "-", inputs[0])
+	assert.EqualValues(t, source, content)
+	_, statErr := os.Stat(inputs[0])
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func Test_analyzeFile_MxfWithoutHeaderIndex_Uses_RangeProxy(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	source := mxfTestSource(0)
+	var inputs []string
+	var content []byte
+	reader := func(ctx context.Context, srcUrl string) (*realdomain.SourceFile, api_error.ApiErr) {
+		return &realdomain.SourceFile{Reader: readSeekNopCloser{bytes.NewReader(source)}, Size: int64(len(source))}, nil
+	}
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), "url1").DoAndReturn(reader).Times(2)
+
+	result, err := fileService.(DefaultFileService).analyzeFile(context.Background(), seekingProber{inputs: &inputs, content: &content}, "url1", probeLimits{}, ProbeOptions{})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, testResult, result)
+	assert.EqualValues(t, 1, len(inputs))
+	assert.True(t, strings.HasPrefix(inputs[0], "http://127.0.0.1:"))
+	assert.EqualValues(t, source, content)
+}
+
+func Test_analyzeFile_MxfNotSeekable_Streams_Source(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	source := mxfTestSource(0)
+	var inputs []string
+	var content []byte
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), "url1").Return(&realdomain.SourceFile{Reader: io.NopCloser(bytes.NewReader(source)), Size: int64(len(source))}, nil)
+
+	result, err := fileService.(DefaultFileService).analyzeFile(context.Background(), seekingProber{inputs: &inputs, content: &content}, "url1", probeLimits{}, ProbeOptions{})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, testResult, result)
+	assert.EqualValues(t, []string{"-"}, inputs)
+	assert.EqualValues(t, source, content)
+}
+
+func Test_analyzeFile_PathOnlyProber_Spools_Source(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	config.SeekMode = seekModeStream
+	defer func() { config.SeekMode = seekModeAuto }()
+	var inputs []string
+	var content []byte
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), "url1").DoAndReturn(stubReader)
+
+	result, err := fileService.(DefaultFileService).analyzeFile(context.Background(), pathOnlySeekingProber{seekingProber{inputs: &inputs, content: &content}}, "url1", probeLimits{}, ProbeOptions{})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, testResult, result)
+	assert.EqualValues(t, 1, len(inputs))
+	assert.NotEqual(t, "-", inputs[0])
+	assert.EqualValues(t, "media", string(content))
+}
+
+func Test_analyzeFile_PathOnlyProber_Uses_RangeProxy(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	source := []byte("media data")
+	var inputs []string
+	var content []byte
+	reader := func(ctx context.Context, srcUrl string) (*realdomain.SourceFile, api_error.ApiErr) {
+		return &realdomain.SourceFile{Reader: readSeekNopCloser{bytes.NewReader(source)}, Size: int64(len(source))}, nil
+	}
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), "url1").DoAndReturn(reader).Times(2)
+
+	_, err := fileService.(DefaultFileService).analyzeFile(context.Background(), pathOnlySeekingProber{seekingProber{inputs: &inputs, content: &content}}, "url1", probeLimits{}, ProbeOptions{})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, len(inputs))
+	assert.True(t, strings.HasPrefix(inputs[0], "http://127.0.0.1:"))
+	assert.EqualValues(t, source, content)
+}
+
+func Test_analyzeFile_StreamFails_Retries_WithRangeProxy(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	source := []byte("media data")
+	var inputs []string
+	var content []byte
+	reader := func(ctx context.Context, srcUrl string) (*realdomain.SourceFile, api_error.ApiErr) {
+		return &realdomain.SourceFile{Reader: readSeekNopCloser{bytes.NewReader(source)}, Size: int64(len(source))}, nil
+	}
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), "url1").DoAndReturn(reader).Times(3)
+
+	result, err := fileService.(DefaultFileService).analyzeFile(context.Background(), seekingProber{failStream: true, inputs: &inputs, content: &content}, "url1", probeLimits{}, ProbeOptions{})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, testResult, result)
+	assert.EqualValues(t, 2, len(inputs))
+	assert.EqualValues(t, "-", inputs[0])
+	assert.True(t, strings.HasPrefix(inputs[1], "http://127.0.0.1:"))
+	assert.EqualValues(t, source, content)
+}
+
+func Test_analyzeFile_StreamMode_DoesNot_Seek(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	config.SeekMode = seekModeStream
+	defer func() { config.SeekMode = seekModeAuto }()
+	var inputs []string
+	var content []byte
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), "url1").Return(&realdomain.SourceFile{Reader: io.NopCloser(bytes.NewReader(mp4TestSource("ftyp", "mdat", "moov"))), Size: -1}, nil)
+
+	_, err := fileService.(DefaultFileService).analyzeFile(context.Background(), seekingProber{failStream: true, inputs: &inputs, content: &content}, "url1", probeLimits{}, ProbeOptions{})
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, []string{"-"}, inputs)
+}
+
+func Test_analyzeFile_SpoolDisabled_Returns_StreamError(t *testing.T) {
+	teardown := setupFile(t)
+	defer teardown()
+	config.SpoolMaxSize = 0
+	defer func() { config.SpoolMaxSize = 4 * 1024 * 1024 * 1024 }()
+	var inputs []string
+	var content []byte
+	mockFileRepo.EXPECT().GetReader(gomock.Any(), "url1").DoAndReturn(stubReader)
+
+	_, err := fileService.(DefaultFileService).analyzeFile(context.Background(), seekingProber{failStream: true, inputs: &inputs, content: &content}, "url1", probeLimits{}, ProbeOptions{})
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, realdomain.ErrorCodeUnsupportedMedia, errorCode(err))
+	assert.EqualValues(t, []string{"-"}, inputs)
+}
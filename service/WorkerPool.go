@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+//go:generate mockgen -destination=../mocks/service/mockWorkerPool.go -package=service github.com/johannes-kuhfuss/probesvc/service WorkerPool
+type WorkerPool interface {
+	Start()
+	Stop(time.Duration) bool
+	Size() int
+}
+
+type DefaultWorkerPool struct {
+	fileSrv  FileService
+	size     int
+	name     string
+	wg       sync.WaitGroup
+	stop     chan struct{}
+	ctx      context.Context
+	abort    context.CancelFunc
+	stopOnce sync.Once
+}
+
+func NewWorkerPool(fileSrv FileService, size int, name string) *DefaultWorkerPool {
+	if size < 1 {
+		size = 1
+	}
+	ctx, abort := context.WithCancel(context.Background())
+	return &DefaultWorkerPool{
+		fileSrv: fileSrv,
+		size:    size,
+		name:    name,
+		stop:    make(chan struct{}),
+		ctx:     ctx,
+		abort:   abort,
+	}
+}
+
+func (p *DefaultWorkerPool) Size() int {
+	return p.size
+}
+
+func (p *DefaultWorkerPool) Start() {
+	logger.Info(fmt.Sprintf("Starting %v probe workers", p.size))
+	for i := 1; i <= p.size; i++ {
+		p.wg.Add(1)
+		go func(workerId string) {
+			defer p.wg.Done()
+			p.fileSrv.Run(p.ctx, p.stop, workerId)
+		}(fmt.Sprintf("%s-%d", p.name, i))
+	}
+}
+
+// Stop keeps workers from claiming new jobs and waits up to grace for running
+// probes to finish. Probes still running after that are aborted and their jobs
+// requeued. It returns false if probes had to be aborted.
+func (p *DefaultWorkerPool) Stop(grace time.Duration) bool {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.abort()
+		logger.Info("All probe workers stopped")
+		return true
+	case <-time.After(grace):
+		logger.Info(fmt.Sprintf("Grace period of %v expired, aborting running probes", grace))
+		p.abort()
+		<-done
+		return false
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/probesvc/domain"
+	"github.com/johannes-kuhfuss/probesvc/dto"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingFileService struct {
+	mu         sync.Mutex
+	started    sync.WaitGroup
+	workerIds  []string
+	aborted    int
+	ignoreStop bool
+}
+
+func (r *recordingFileService) Run(ctx context.Context, stop <-chan struct{}, workerId string) {
+	r.mu.Lock()
+	r.workerIds = append(r.workerIds, workerId)
+	r.mu.Unlock()
+	r.started.Done()
+	if r.ignoreStop {
+		<-ctx.Done()
+		r.mu.Lock()
+		r.aborted++
+		r.mu.Unlock()
+		return
+	}
+	<-stop
+}
+
+func (r *recordingFileService) processJob(context.Context, *dto.JobResponse) {
+}
+
+func (r *recordingFileService) requeueJob(*dto.JobResponse) api_error.ApiErr {
+	return nil
+}
+
+func (r *recordingFileService) failJob(*dto.JobResponse, api_error.ApiErr) api_error.ApiErr {
+	return nil
+}
+
+func (r *recordingFileService) finishJob(*dto.JobResponse) api_error.ApiErr {
+	return nil
+}
+
+func (r *recordingFileService) addResultToJob(*dto.JobResponse, domain.ProbeEngine, string) api_error.ApiErr {
+	return nil
+}
+
+func Test_NewWorkerPool_InvalidSize_Uses_OneWorker(t *testing.T) {
+	pool := NewWorkerPool(&recordingFileService{}, 0, "host")
+
+	assert.EqualValues(t, 1, pool.Size())
+}
+
+func Test_Start_Runs_AllWorkers_WithDistinctIds(t *testing.T) {
+	fileSrv := &recordingFileService{}
+	fileSrv.started.Add(3)
+	pool := NewWorkerPool(fileSrv, 3, "host")
+
+	pool.Start()
+	fileSrv.started.Wait()
+	pool.Stop(time.Second)
+
+	assert.ElementsMatch(t, []string{"host-1", "host-2", "host-3"}, fileSrv.workerIds)
+}
+
+func Test_Stop_IdleWorkers_Returns_True(t *testing.T) {
+	fileSrv := &recordingFileService{}
+	fileSrv.started.Add(2)
+	pool := NewWorkerPool(fileSrv, 2, "host")
+
+	pool.Start()
+	fileSrv.started.Wait()
+	drained := pool.Stop(time.Second)
+
+	assert.True(t, drained)
+	assert.EqualValues(t, 0, fileSrv.aborted)
+}
+
+func Test_Stop_BusyWorkers_AbortsAfterGrace(t *testing.T) {
+	fileSrv := &recordingFileService{ignoreStop: true}
+	fileSrv.started.Add(2)
+	pool := NewWorkerPool(fileSrv, 2, "host")
+
+	pool.Start()
+	fileSrv.started.Wait()
+	drained := pool.Stop(10 * time.Millisecond)
+
+	assert.False(t, drained)
+	assert.EqualValues(t, 2, fileSrv.aborted)
+}